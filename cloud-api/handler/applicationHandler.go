@@ -116,6 +116,8 @@ func CreateApplication(c *gin.Context) {
 	// 生成新ID
 	app.ID = uuid.New().String()
 	app.Status = "created"
+	// 通过API创建的应用不由git源管理
+	app.SourceID = ""
 	
 	// 确保必要字段存在
 	if app.KubeConfigID == "" {
@@ -171,20 +173,25 @@ func CreateApplication(c *gin.Context) {
 		app.ImagePullPolicy = "IfNotPresent" // 默认为IfNotPresent
 	}
 	
-	// 如果未设置默认的存活探针，但设置了端口，则创建一个HTTP存活探针
-	if app.LivenessProbe == nil && app.Port > 0 {
-		app.LivenessProbe = &model.ProbeConfig{
-			Path:                "/",
-			Port:                app.Port,
-			InitialDelaySeconds: 0,
-			PeriodSeconds:       10,
-			TimeoutSeconds:      1,
-			FailureThreshold:    3,
-			SuccessThreshold:    1,
-			ProbeType:           "http",
+	// 端口协议默认值
+	if app.PortProtocol == "" {
+		app.PortProtocol = "http"
+	}
+	
+	// 校验调度预设
+	for _, preset := range app.SchedulingPresets {
+		if !model.IsValidSchedulingPreset(preset) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "不支持的调度预设: " + preset})
+			return
 		}
 	}
 	
+	// 如果未设置默认的存活探针，但设置了端口，则根据端口协议创建存活探针
+	// http/https使用HTTP探针，grpc使用gRPC探针，tcp使用TCP探针
+	if app.LivenessProbe == nil && app.Port > 0 {
+		app.LivenessProbe = model.DefaultProbeConfig(app.Port, app.PortProtocol)
+	}
+	
 	// 注释掉自动添加就绪检测的逻辑，让前端决定是否启用就绪检测
 	// 如果未设置默认的就绪探针，但设置了端口，则创建一个HTTP就绪探针
 	// if app.ReadinessProbe == nil && app.Port > 0 {
@@ -215,6 +222,54 @@ func CreateApplication(c *gin.Context) {
 		app.ServiceType = "ClusterIP"
 	}
 	
+	// 校验Service配置
+	if err := model.ValidateServiceConfig(app.ServiceType, app.ServiceConfig); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 校验网络策略配置
+	if err := model.ValidateNetworkPolicyConfig(app.NetworkPolicy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 校验Pod中断预算配置
+	if err := model.ValidateDisruptionBudget(app.DisruptionBudget); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 校验ServiceAccount和RBAC配置
+	if err := model.ValidateServiceAccountConfig(app.ServiceAccount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 校验资源配置
+	if err := model.ValidateResourceRequirements(app.Resources); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 校验生命周期钩子
+	if err := model.ValidateLifecycleConfig(app.Lifecycle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	
+	// 部署策略检查
+	policyWarnings, ok := checkDeploymentPolicies(c, &app)
+	if !ok {
+		return
+	}
+	
+	// 资源配额检查，集群暂时不可用时只给出警告
+	quotaWarning, ok := checkResourceQuota(c, &app, false)
+	if !ok {
+		return
+	}
+	
 	// 保存到数据库
 	err = model.SaveApplicationToDB(&app)
 	if err != nil {
@@ -287,13 +342,18 @@ func CreateApplication(c *gin.Context) {
 	}()
 	
 	// 返回成功响应
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"id": app.ID,
 		"name": app.Name,
 		"namespace": app.Namespace,
 		"status": "deploying",
 		"message": "应用创建成功并开始部署",
-	})
+		"policyWarnings": policyWarnings,
+	}
+	if quotaWarning != "" {
+		response["quotaWarning"] = quotaWarning
+	}
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateApplication 更新应用
@@ -313,6 +373,15 @@ func UpdateApplication(c *gin.Context) {
 		return
 	}
 	
+	// 由git源管理的应用只能通过修改仓库中的定义更新
+	if app.SourceID != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "应用由git源管理，不能通过API修改",
+			"sourceId": app.SourceID,
+		})
+		return
+	}
+	
 	// 更新字段
 	app.Name = updateData.Name
 	app.Description = updateData.Description
@@ -321,6 +390,20 @@ func UpdateApplication(c *gin.Context) {
 	app.Port = updateData.Port
 	app.ServiceType = updateData.ServiceType
 	app.DeploymentYAML = updateData.DeploymentYAML
+	// 未提供资源配置时保持不变，便于直接提交资源建议中的应用配置
+	if updateData.Resources != nil {
+		if err := model.ValidateResourceRequirements(updateData.Resources); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		app.Resources = updateData.Resources
+	}
+	
+	// 部署策略检查
+	policyWarnings, ok := checkDeploymentPolicies(c, app)
+	if !ok {
+		return
+	}
 	
 	// 保存到数据库
 	err = model.SaveApplicationToDB(app)
@@ -329,7 +412,10 @@ func UpdateApplication(c *gin.Context) {
 		return
 	}
 	
-	c.JSON(http.StatusOK, app)
+	c.JSON(http.StatusOK, struct {
+		*model.Application
+		PolicyWarnings []model.PolicyViolation `json:"policyWarnings,omitempty"`
+	}{app, policyWarnings})
 }
 
 // DeleteApplication 删除应用
@@ -411,7 +497,7 @@ func DeleteApplication(c *gin.Context) {
 		}
 		
 		// 创建错误通道，用于收集删除过程中的错误
-		errorChan := make(chan error, 6)
+		errorChan := make(chan error, 8)
 		
 		// 并行删除所有相关资源以加快删除速度
 		go func() {
@@ -484,6 +570,26 @@ func DeleteApplication(c *gin.Context) {
 			}
 		}()
 		
+		go func() {
+			// 删除PodDisruptionBudget
+			if err := model.GetK8sManager().DeletePodDisruptionBudget(app.KubeConfigID, namespace, appName); err != nil {
+				log.Printf("删除PodDisruptionBudget失败: %v", err)
+				errorChan <- fmt.Errorf("删除PodDisruptionBudget失败: %v", err)
+			} else {
+				errorChan <- nil
+			}
+		}()
+		
+		go func() {
+			// 删除ServiceAccount、Role和RoleBinding
+			if err := model.GetK8sManager().DeleteServiceAccountResources(app.KubeConfigID, namespace, appName); err != nil {
+				log.Printf("删除ServiceAccount和RBAC失败: %v", err)
+				errorChan <- fmt.Errorf("删除ServiceAccount和RBAC失败: %v", err)
+			} else {
+				errorChan <- nil
+			}
+		}()
+		
 		go func() {
 			// 删除相关的Pod
 			if err := model.GetK8sManager().DeletePodsForApp(app.KubeConfigID, namespace, appName); err != nil {
@@ -500,7 +606,7 @@ func DeleteApplication(c *gin.Context) {
 		
 		// 收集错误
 		var errors []error
-		for i := 0; i < 6; i++ {
+		for i := 0; i < 8; i++ {
 			if err := <-errorChan; err != nil {
 				errors = append(errors, err)
 			}
@@ -528,6 +634,17 @@ func DeployApplication(c *gin.Context) {
 		return
 	}
 	
+	// 部署策略检查
+	policyWarnings, ok := checkDeploymentPolicies(c, app)
+	if !ok {
+		return
+	}
+	
+	// 资源配额检查
+	if _, ok := checkResourceQuota(c, app, true); !ok {
+		return
+	}
+	
 	// 备份原始创建时间
 	originalCreatedAt := app.CreatedAt
 	
@@ -567,6 +684,7 @@ func DeployApplication(c *gin.Context) {
 		"message": "应用部署请求已发送，正在部署中",
 		"appId": id,
 		"status": "deploying",
+		"policyWarnings": policyWarnings,
 	})
 }
 
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetApplicationDrifts 获取最近一次漂移检测结果，默认只返回存在漂移的应用，all=true时返回全部
+func GetApplicationDrifts(c *gin.Context) {
+	drifts, err := model.GetApplicationDriftsFromDB(c.Query("all") != "true")
+	if err != nil {
+		log.Printf("获取漂移检测结果失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取漂移检测结果失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, drifts)
+}
+
+// CheckApplicationDrift 立即检测应用的配置漂移
+func CheckApplicationDrift(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+
+	drift, err := model.GetK8sManager().CheckApplicationDrift(app)
+	if err != nil {
+		log.Printf("检测应用配置漂移失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("检测应用配置漂移失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, drift)
+}
+
+// ResyncApplicationDrift 按应用配置重新部署，覆盖集群中被手动修改的字段，并记录同步结果
+func ResyncApplicationDrift(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+
+	attempt := model.GetK8sManager().SyncApplication(app, "manual", "手动重新同步")
+	switch attempt.Result {
+	case "blocked":
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": attempt.Message, "sync": attempt})
+	case "failed":
+		c.JSON(http.StatusInternalServerError, gin.H{"error": attempt.Message, "sync": attempt})
+	default:
+		c.JSON(http.StatusOK, gin.H{"message": "应用已重新同步", "sync": attempt})
+	}
+}
+
+// GetApplicationSyncAttempts 获取应用最近的同步记录，limit默认50
+func GetApplicationSyncAttempts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit必须是正整数"})
+		return
+	}
+
+	attempts, err := model.GetSyncAttemptsFromDB(c.Param("id"), limit)
+	if err != nil {
+		log.Printf("获取同步记录失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取同步记录失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// AcceptApplicationDrift 接受集群中的当前状态作为新的期望状态
+func AcceptApplicationDrift(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+
+	// 接受集群状态会修改应用配置，由git源管理的应用不允许
+	if app.SourceID != "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "应用由git源管理，请在仓库中修改应用定义", "sourceId": app.SourceID})
+		return
+	}
+
+	km := model.GetK8sManager()
+	synced, err := km.AcceptLiveState(app)
+	if err != nil {
+		log.Printf("接受集群状态失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("接受集群状态失败: %v", err)})
+		return
+	}
+
+	response := gin.H{
+		"message":      "已接受集群中的当前状态",
+		"syncedFields": synced,
+	}
+	// 只有副本数、镜像和资源配置会写回应用，其他手动修改会在下次部署时被覆盖
+	response["note"] = "仅副本数、镜像和资源配置会同步到应用配置，其他字段的修改在下次部署时会被覆盖"
+
+	if drift, err := km.CheckApplicationDrift(app); err == nil {
+		response["drift"] = drift
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNamespaceEvents 获取命名空间中的事件，支持type=Warning过滤，按最后发生时间倒序
+func GetNamespaceEvents(c *gin.Context) {
+	id := c.Param("id")
+	namespace := c.Param("namespace")
+	eventType := c.Query("type")
+	if err := model.ValidateEventType(eventType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := model.GetK8sManager().GetNamespaceEvents(id, namespace, eventType)
+	if err != nil {
+		log.Printf("获取命名空间 %s 的事件失败: %v", namespace, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取事件失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetApplicationEvents 获取应用及其所属资源的事件
+func GetApplicationEvents(c *gin.Context) {
+	eventType := c.Query("type")
+	if err := model.ValidateEventType(eventType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+	if app.KubeConfigID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "应用未配置Kubernetes集群"})
+		return
+	}
+
+	events, err := model.GetK8sManager().GetApplicationEvents(app, eventType)
+	if err != nil {
+		log.Printf("获取应用 %s 的事件失败: %v", app.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取事件失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetPodEvents 获取单个Pod的事件
+func GetPodEvents(c *gin.Context) {
+	kubeConfigID := c.Query("kubeConfigId")
+	podName := c.Param("name")
+	namespace := c.Query("namespace")
+	eventType := c.Query("type")
+
+	if kubeConfigID == "" || podName == "" || namespace == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "必须提供kubeConfigId、podName和namespace"})
+		return
+	}
+	if err := model.ValidateEventType(eventType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	events, err := model.GetK8sManager().GetPodEvents(kubeConfigID, namespace, podName, eventType)
+	if err != nil {
+		log.Printf("获取Pod %s/%s 的事件失败: %v", namespace, podName, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取事件失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// SearchArchivedEvents 查询归档事件，支持按集群、时间范围、命名空间、应用、类型、原因和消息内容过滤
+func SearchArchivedEvents(c *gin.Context) {
+	query := model.EventSearchQuery{
+		KubeConfigID:  c.Query("kubeConfigId"),
+		Namespace:     c.Query("namespace"),
+		ApplicationID: c.Query("applicationId"),
+		Type:          c.Query("type"),
+		Reason:        c.Query("reason"),
+		Text:          c.Query("q"),
+	}
+	if err := model.ValidateEventType(query.Type); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var err error
+	if query.Since, err = parseEventTime(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("since参数无效: %v", err)})
+		return
+	}
+	if query.Until, err = parseEventTime(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("until参数无效: %v", err)})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit参数无效"})
+			return
+		}
+	}
+
+	events, err := model.SearchArchivedEventsFromDB(query)
+	if err != nil {
+		log.Printf("查询归档事件失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// 解析时间参数，支持RFC3339时间和相对时长（如"12h"表示12小时前），为空时返回nil
+func parseEventTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		t := time.Now().Add(-d)
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetEventArchiveSettings 获取集群的事件归档设置
+func GetEventArchiveSettings(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := model.GetEventArchiveSettingsFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateEventArchiveSettings 更新集群的事件保留天数
+func UpdateEventArchiveSettings(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var settings model.EventArchiveSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	settings.KubeConfigID = id
+	if err := model.ValidateEventArchiveSettings(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveEventArchiveSettingsToDB(&settings); err != nil {
+		log.Printf("保存事件归档设置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"cloud-deployment-api/model"
-	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,20 +11,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// 修改缓存类型定义
-var (
-	namespacesCache     = make(map[string][]map[string]interface{})
-	namespacesTimestamp = make(map[string]time.Time)
-	podsCache           = make(map[string]interface{})  // 新增 pods 缓存
-	podsTimestamp       = make(map[string]time.Time)    // 新增 pods 时间戳
-	cacheMutex          = &sync.RWMutex{}
-	inProgressRequests  = make(map[string]bool)
-	requestMutex        = &sync.Mutex{}
-)
-
 // 辅助函数：将命名空间对象转换为字符串数组
 func convertNamespacesToStrings(namespaces []map[string]interface{}) []string {
 	result := make([]string, 0, len(namespaces))
@@ -37,142 +28,27 @@ func convertNamespacesToStrings(namespaces []map[string]interface{}) []string {
 	return result
 }
 
-// GetK8sNamespaces 获取Kubernetes命名空间列表（优化版）
+// GetK8sNamespaces 获取Kubernetes命名空间列表，从集群的informer缓存读取
 func GetK8sNamespaces(c *gin.Context) {
 	id := c.Param("id")
-	requestKey := "namespaces:" + id
-	
-	// 检查是否有相同请求正在处理
-	requestMutex.Lock()
-	if inProgressRequests[requestKey] {
-		// 当有重复请求时直接返回默认命名空间列表，但状态码保持200
-		requestMutex.Unlock()
-		log.Printf("检测到并发命名空间请求，返回默认命名空间: %s", id)
-		c.JSON(http.StatusOK, []string{"default", "kube-system", "kube-public"})
-		return
-	}
-	
-	// 标记请求处理中
-	inProgressRequests[requestKey] = true
-	requestMutex.Unlock()
-	
-	// 请求结束时清理标记
-	defer func() {
-		requestMutex.Lock()
-		delete(inProgressRequests, requestKey)
-		requestMutex.Unlock()
-		log.Printf("命名空间请求处理完成: %s", id)
-	}()
-	
-	// 直接从缓存获取命名空间
-	namespacesCache, err := model.GetNamespacesFromCache(id)
-	if err == nil && len(namespacesCache) > 0 {
-		// 缓存命中，直接返回
-		log.Printf("命名空间缓存命中，直接返回: %s", id)
-		
-		// 转换并返回结果
-		namespaceList := make([]string, len(namespacesCache))
-		for i, ns := range namespacesCache {
-			namespaceList[i] = ns.Name
-		}
-		
-		c.JSON(http.StatusOK, namespaceList)
-		
-		// 检查是否需要后台异步更新缓存（超过1小时）
-		if len(namespacesCache) > 0 && time.Since(namespacesCache[0].LastSyncedAt) > 1*time.Hour {
-			// 使用goroutine异步触发更新，不阻塞当前请求
-			go func() {
-				if err := model.RefreshNamespaceCache(id, model.GetK8sManager()); err != nil {
-					log.Printf("后台刷新命名空间缓存失败: %s, %v", id, err)
-				}
-			}()
-		}
-		return
-	}
-	
-	// 缓存未命中，尝试使用优化函数一次性获取并缓存
-	log.Printf("命名空间缓存未命中，从API获取并缓存: %s", id)
-	namespaceList, err := model.GetNamespacesList(id, model.GetK8sManager())
+
+	// 获取失败时GetNamespaces会返回默认命名空间
+	namespaces, err := model.GetK8sManager().GetNamespaces(id)
 	if err != nil {
 		log.Printf("获取命名空间列表失败: %s, %v", id, err)
 		c.JSON(http.StatusOK, []string{"default", "kube-system", "kube-public"})
 		return
 	}
-	
-	log.Printf("成功获取命名空间列表: %s, 数量: %d", id, len(namespaceList))
-	c.JSON(http.StatusOK, namespaceList)
+
+	c.JSON(http.StatusOK, convertNamespacesToStrings(namespaces))
 }
 
 // GetK8sPods 获取Kubernetes Pod列表
 func GetK8sPods(c *gin.Context) {
 	id := c.Param("id")
 	namespace := c.DefaultQuery("namespace", "")
-	requestKey := fmt.Sprintf("pods:%s:%s", id, namespace)
-	
-	// 检查是否有相同请求正在处理
-	requestMutex.Lock()
-	if inProgressRequests[requestKey] {
-		requestMutex.Unlock()
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error": "请求处理中，请稍后再试",
-			"code": "REQUEST_IN_PROGRESS",
-		})
-		return
-	}
-	
-	// 检查缓存
-	cacheMutex.RLock()
-	pods, cacheExists := podsCache[requestKey]
-	timestamp, timeExists := podsTimestamp[requestKey]
-	cacheMutex.RUnlock()
-	
-	// 如果缓存有效（存在且不超过5分钟）
-	if cacheExists && timeExists && time.Since(timestamp) < 5*time.Minute {
-		// 添加缓存命中日志
-		log.Printf("Pod缓存命中: %s, 命名空间: %s", id, namespace)
-		c.JSON(http.StatusOK, pods)
-		return
-	}
-	
-	// 标记请求处理中
-	inProgressRequests[requestKey] = true
-	requestMutex.Unlock()
-	
-	// 请求结束时清理标记
-	defer func() {
-		requestMutex.Lock()
-		delete(inProgressRequests, requestKey)
-		requestMutex.Unlock()
-	}()
-	
-	// 首先从数据库获取 kubeconfig
-	kubeConfig, err := model.GetKubeConfigByIDFromDB(id)
-	if err != nil {
-		log.Printf("无法从数据库获取KubeConfig (ID: %s): %v", id, err)
-		// 返回空数组而不是错误
-		c.JSON(http.StatusOK, []map[string]interface{}{})
-		return
-	}
-	
-	// 确保 K8s Manager 已初始化该配置
-	err = model.GetK8sManager().InitializeConfig(id, kubeConfig.Content)
-	if err != nil {
-		log.Printf("初始化KubeConfig失败 (ID: %s): %v", id, err)
-		// 返回空数组而不是错误
-		c.JSON(http.StatusOK, []map[string]interface{}{})
-		return
-	}
-	
-	// 验证 kubeconfig
-	err = model.GetK8sManager().ValidateKubeConfig(id)
-	if err != nil {
-		log.Printf("验证KubeConfig失败 (ID: %s): %v", id, err)
-		// 返回空数组而不是错误
-		c.JSON(http.StatusOK, []map[string]interface{}{})
-		return
-	}
-	
-	// 调用 Kubernetes 获取 Pods
+
+	// 从informer缓存获取Pods
 	result, err := model.GetK8sManager().GetPods(id, namespace)
 	if err != nil {
 		log.Printf("获取Pod列表失败 (ID: %s, namespace: %s): %v", id, namespace, err)
@@ -180,188 +56,151 @@ func GetK8sPods(c *gin.Context) {
 		c.JSON(http.StatusOK, []map[string]interface{}{})
 		return
 	}
-	
-	// 更新缓存
-	cacheMutex.Lock()
-	podsCache[requestKey] = result
-	podsTimestamp[requestKey] = time.Now()
-	cacheMutex.Unlock()
-	
+
 	c.JSON(http.StatusOK, result)
 }
 
 // GetK8sDeployments 获取Kubernetes Deployment列表
 func GetK8sDeployments(c *gin.Context) {
 	id := c.Param("id")
-	namespace := c.DefaultQuery("namespace", "")  // 修改为空字符串
-	
-	// 验证kubeconfig
-	err := model.GetK8sManager().ValidateKubeConfig(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Invalid kubeconfig: %v", err)})
-		return
-	}
-	
-	// 获取客户端
-	client, err := model.GetK8sManager().GetClient(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取客户端失败: %v", err),
-			"code": "CLIENT_ERROR",
-		})
-		return
-	}
-	
-	// 直接使用客户端获取 Deployments
-	deployments, err := client.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	namespace := c.DefaultQuery("namespace", "") // 默认为空字符串，表示所有命名空间
+
+	deployments, err := model.GetK8sManager().ListCachedDeployments(id, namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("获取 Deployments 失败: %v", err),
-			"code": "DEPLOYMENTS_FETCH_FAILED",
+			"code":  "DEPLOYMENTS_FETCH_FAILED",
 		})
 		return
 	}
-	
-	c.JSON(http.StatusOK, deployments)
+
+	c.JSON(http.StatusOK, appsv1.DeploymentList{
+		TypeMeta: metav1.TypeMeta{Kind: "DeploymentList", APIVersion: "apps/v1"},
+		Items:    deployments,
+	})
 }
 
 // GetK8sServices 获取Kubernetes Service列表
 func GetK8sServices(c *gin.Context) {
 	id := c.Param("id")
-	namespace := c.DefaultQuery("namespace", "")  // 修改为空字符串
-	
-	// 验证kubeconfig
-	err := model.GetK8sManager().ValidateKubeConfig(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get services: %v", err)})
-		return
-	}
-	
-	// 获取客户端
-	client, err := model.GetK8sManager().GetClient(id)
+	namespace := c.DefaultQuery("namespace", "") // 默认为空字符串，表示所有命名空间
+
+	services, err := model.GetK8sManager().ListCachedServices(id, namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取客户端失败: %v", err),
-			"code": "CLIENT_ERROR",
+			"error": fmt.Sprintf("获取 Services 失败: %v", err),
+			"code":  "SERVICES_FETCH_FAILED",
 		})
 		return
 	}
-	
-	// 直接使用客户端获取 Services
-	services, err := client.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+
+	// 获取端点就绪情况，获取失败时仅返回Service信息
+	endpointsByName, err := model.GetK8sManager().ListCachedEndpoints(id, namespace)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取 Services 失败: %v", err),
-			"code": "SERVICES_FETCH_FAILED",
-		})
-		return
+		log.Printf("获取 Endpoints 失败: %v", err)
 	}
-	
-	c.JSON(http.StatusOK, services)
+
+	type serviceWithEndpoints struct {
+		corev1.Service
+		Endpoints map[string]interface{} `json:"endpoints"`
+	}
+	items := make([]serviceWithEndpoints, 0, len(services))
+	for _, service := range services {
+		item := serviceWithEndpoints{Service: service}
+		if service.Spec.Type != corev1.ServiceTypeExternalName {
+			item.Endpoints = model.SummarizeEndpoints(endpointsByName[service.Namespace+"/"+service.Name])
+		}
+		items = append(items, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kind":       "ServiceList",
+		"apiVersion": "v1",
+		"metadata":   metav1.ListMeta{},
+		"items":      items,
+	})
 }
 
 // GetK8sStatefulSets 获取Kubernetes StatefulSet列表
 func GetK8sStatefulSets(c *gin.Context) {
 	id := c.Param("id")
-	namespace := c.DefaultQuery("namespace", "")  // 默认为空字符串，表示所有命名空间
-	
-	// 首先从数据库获取 kubeconfig
-	kubeConfig, err := model.GetKubeConfigByIDFromDB(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("无法从数据库获取 KubeConfig: %v", err),
-			"code": "CONFIG_NOT_FOUND",
-		})
-		return
-	}
-	
-	// 确保 K8s Manager 已初始化该配置
-	err = model.GetK8sManager().InitializeConfig(id, kubeConfig.Content)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("初始化 KubeConfig 失败: %v", err),
-			"code": "CONFIG_INIT_FAILED",
-		})
-		return
-	}
-	
-	// 获取客户端
-	client, err := model.GetK8sManager().GetClient(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("获取 Kubernetes 客户端失败: %v", err),
-			"code": "CLIENT_ERROR",
-		})
-		return
-	}
-	
-	// 获取 StatefulSets
-	statefulsets, err := client.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	namespace := c.DefaultQuery("namespace", "") // 默认为空字符串，表示所有命名空间
+
+	statefulsets, err := model.GetK8sManager().ListCachedStatefulSets(id, namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("获取 StatefulSets 失败: %v", err),
-			"code": "RESOURCE_FETCH_FAILED",
+			"code":  "RESOURCE_FETCH_FAILED",
 		})
 		return
 	}
-	
-	c.JSON(http.StatusOK, statefulsets)
+
+	c.JSON(http.StatusOK, appsv1.StatefulSetList{
+		TypeMeta: metav1.TypeMeta{Kind: "StatefulSetList", APIVersion: "apps/v1"},
+		Items:    statefulsets,
+	})
 }
 
 // GetK8sDaemonSets 获取Kubernetes DaemonSet列表
 func GetK8sDaemonSets(c *gin.Context) {
 	id := c.Param("id")
-	namespace := c.DefaultQuery("namespace", "")  // 默认为空字符串，表示所有命名空间
-	
-	// 验证kubeconfig
-	err := model.GetK8sManager().ValidateKubeConfig(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Invalid kubeconfig: %v", err)})
-		return
-	}
-	
-	// 调用Kubernetes获取DaemonSets
-	client, err := model.GetK8sManager().GetClient(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取客户端失败: %v", err)})
-		return
-	}
-	
-	daemonsets, err := client.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	namespace := c.DefaultQuery("namespace", "") // 默认为空字符串，表示所有命名空间
+
+	daemonsets, err := model.GetK8sManager().ListCachedDaemonSets(id, namespace)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 DaemonSets 失败: %v", err)})
 		return
 	}
-	
-	c.JSON(http.StatusOK, daemonsets)
+
+	c.JSON(http.StatusOK, appsv1.DaemonSetList{
+		TypeMeta: metav1.TypeMeta{Kind: "DaemonSetList", APIVersion: "apps/v1"},
+		Items:    daemonsets,
+	})
 }
 
 // GetK8sJobs 获取Kubernetes Job列表
 func GetK8sJobs(c *gin.Context) {
 	id := c.Param("id")
-	namespace := c.DefaultQuery("namespace", "")  // 默认为空字符串，表示所有命名空间
-	
-	// 验证kubeconfig
-	err := model.GetK8sManager().ValidateKubeConfig(id)
+	namespace := c.DefaultQuery("namespace", "") // 默认为空字符串，表示所有命名空间
+
+	jobs, err := model.GetK8sManager().ListCachedJobs(id, namespace)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Invalid kubeconfig: %v", err)})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 Jobs 失败: %v", err)})
 		return
 	}
-	
-	// 调用Kubernetes获取Jobs
-	client, err := model.GetK8sManager().GetClient(id)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取客户端失败: %v", err)})
+
+	c.JSON(http.StatusOK, batchv1.JobList{
+		TypeMeta: metav1.TypeMeta{Kind: "JobList", APIVersion: "batch/v1"},
+		Items:    jobs,
+	})
+}
+
+// GetK8sCacheStatus 获取集群informer缓存的同步状态
+func GetK8sCacheStatus(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	
-	jobs, err := client.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取 Jobs 失败: %v", err)})
+
+	c.JSON(http.StatusOK, model.GetK8sManager().GetCacheStatus(id))
+}
+
+// RefreshK8sCache 丢弃集群的informer缓存并重新同步
+func RefreshK8sCache(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
-	
-	c.JSON(http.StatusOK, jobs)
+
+	if err := model.GetK8sManager().RefreshCache(id); err != nil {
+		log.Printf("刷新集群 %s 的缓存失败: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("刷新缓存失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.GetK8sManager().GetCacheStatus(id))
 }
 
 // GetK8sPodLogs 获取Kubernetes Pod日志
@@ -851,43 +690,3 @@ func DeleteK8sDeployment(c *gin.Context) {
     log.Printf("成功删除部署 %s/%s", namespace, name)
     c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("部署 %s/%s 已删除", namespace, name)})
 }
-
-// CleanupCaches 清理过期的缓存
-func CleanupCaches() {
-	now := time.Now()
-	cacheTimeout := 10 * time.Minute // 10分钟超时
-
-	cacheMutex.Lock()
-	defer cacheMutex.Unlock()
-
-	// 清理命名空间缓存
-	for id, timestamp := range namespacesTimestamp {
-		if now.Sub(timestamp) > cacheTimeout {
-			delete(namespacesCache, id)
-			delete(namespacesTimestamp, id)
-			log.Printf("清理过期命名空间缓存: %s", id)
-		}
-	}
-
-	// 清理Pod缓存
-	for key, timestamp := range podsTimestamp {
-		if now.Sub(timestamp) > cacheTimeout {
-			delete(podsCache, key)
-			delete(podsTimestamp, key)
-			log.Printf("清理过期Pod缓存: %s", key)
-		}
-	}
-}
-
-// 在文件开头添加init函数
-func init() {
-	// 启动缓存清理协程
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			CleanupCaches()
-		}
-	}()
-} 
\ No newline at end of file
@@ -141,6 +141,11 @@ func UploadKubeConfig(c *gin.Context) {
 		return
 	}
 	
+	// 启动集群informer，失败不影响上传结果
+	if err := model.GetK8sManager().StartInformers(config.ID); err != nil {
+		log.Printf("启动集群 %s 的informer失败: %v", config.ID, err)
+	}
+	
 	// 返回前移除敏感信息
 	config.Content = ""
 	c.JSON(http.StatusCreated, gin.H{
@@ -172,7 +177,8 @@ func getServerURL(kubeConfig *clientcmdapi.Config) string {
 func DeleteKubeConfig(c *gin.Context) {
 	id := c.Param("id")
 	
-	// 从Kubernetes客户端管理器移除
+	// 停止集群informer并从Kubernetes客户端管理器移除
+	model.GetK8sManager().StopInformers(id)
 	model.GetK8sManager().RemoveClient(id)
 
 	// 从数据库删除
@@ -218,6 +224,11 @@ func SetKubeConfigContext(c *gin.Context) {
 		return
 	}
 
+	// 上下文变化后informer需要连接新的集群
+	if err := model.GetK8sManager().RestartInformers(id); err != nil {
+		log.Printf("重启集群 %s 的informer失败: %v", id, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "上下文已成功设置"})
 }
 
@@ -284,6 +295,11 @@ func AddKubeConfig(c *gin.Context) {
 		return
 	}
 
+	// 启动集群informer，失败不影响添加结果
+	if err := model.GetK8sManager().StartInformers(config.ID); err != nil {
+		log.Printf("启动集群 %s 的informer失败: %v", config.ID, err)
+	}
+
 	// 返回创建的KubeConfig，但不包含配置内容
 	config.Content = ""
 	c.JSON(http.StatusCreated, config)
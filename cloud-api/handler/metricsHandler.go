@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 返回资源用量，集群未安装metrics-server时返回available=false和空列表而不是错误
+func respondMetrics(c *gin.Context, items interface{}, err error) {
+	if err == model.ErrMetricsUnavailable {
+		c.JSON(http.StatusOK, gin.H{"available": false, "message": err.Error(), "items": []interface{}{}})
+		return
+	}
+	if err != nil {
+		log.Printf("获取资源用量失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"available": true, "items": items})
+}
+
+// GetNodeMetrics 获取集群节点的CPU和内存用量
+func GetNodeMetrics(c *gin.Context) {
+	usages, err := model.GetK8sManager().GetNodeMetrics(c.Param("id"))
+	respondMetrics(c, usages, err)
+}
+
+// GetPodMetrics 获取命名空间中Pod及其容器的CPU和内存用量，未指定命名空间时返回所有命名空间
+func GetPodMetrics(c *gin.Context) {
+	usages, err := model.GetK8sManager().GetPodMetrics(c.Param("id"), c.Query("namespace"), c.Query("labelSelector"))
+	respondMetrics(c, usages, err)
+}
+
+// GetApplicationMetrics 获取应用各Pod当前的CPU和内存用量
+func GetApplicationMetrics(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+	if app.KubeConfigID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "应用未配置Kubernetes集群"})
+		return
+	}
+
+	usages, err := model.GetK8sManager().GetApplicationMetrics(app)
+	respondMetrics(c, usages, err)
+}
+
+// GetApplicationMetricsHistory 获取应用的CPU和内存用量时间序列，since为时长（如1h），默认1小时，最长保留时长
+func GetApplicationMetricsHistory(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+
+	window, err := time.ParseDuration(c.DefaultQuery("since", "1h"))
+	if err != nil || window <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "since参数无效，应为时长，如30m、6h"})
+		return
+	}
+	if window > model.MetricsHistoryRetention {
+		window = model.MetricsHistoryRetention
+	}
+
+	history, err := model.GetApplicationMetricsHistoryFromDB(app.ID, time.Now().Add(-window))
+	if err != nil {
+		log.Printf("获取应用 %s 的资源用量历史失败: %v", app.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// GetApplicationRecommendation 根据记录的资源用量给出应用的资源请求和限制建议，since为统计时长，默认为全部保留的记录
+func GetApplicationRecommendation(c *gin.Context) {
+	app, err := model.GetApplicationByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("应用不存在: %v", err)})
+		return
+	}
+
+	window := model.MetricsHistoryRetention
+	if since := c.Query("since"); since != "" {
+		window, err = time.ParseDuration(since)
+		if err != nil || window <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since参数无效，应为时长，如6h"})
+			return
+		}
+	}
+
+	recommendation, err := model.GetK8sManager().RecommendApplicationResources(app, time.Now().Add(-window))
+	if err == model.ErrNoMetricsHistory {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("生成应用 %s 的资源建议失败: %v", app.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, recommendation)
+}
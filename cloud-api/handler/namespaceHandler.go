@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateK8sNamespace 创建命名空间并应用集群的初始化配置，返回每一项初始化的结果
+func CreateK8sNamespace(c *gin.Context) {
+	var request model.NamespaceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	if err := model.ValidateNamespaceRequest(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := model.GetK8sManager().CreateNamespace(c.Param("id"), &request)
+	if err == model.ErrNamespaceExists {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("创建命名空间失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// DeleteK8sNamespace 删除命名空间，命名空间中还有应用时需要force=true
+func DeleteK8sNamespace(c *gin.Context) {
+	force := c.Query("force") == "true"
+	err := model.GetK8sManager().DeleteNamespace(c.Param("id"), c.Param("namespace"), force)
+
+	var inUse *model.NamespaceInUseError
+	switch {
+	case err == model.ErrProtectedNamespace:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &inUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "applications": inUse.Applications})
+		return
+	case err != nil:
+		log.Printf("删除命名空间失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "命名空间删除成功"})
+}
+
+// GetNamespaceOnboarding 获取集群的命名空间初始化配置
+func GetNamespaceOnboarding(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	onboarding, err := model.GetNamespaceOnboardingFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, onboarding)
+}
+
+// UpdateNamespaceOnboarding 更新集群的命名空间初始化配置，只影响之后创建的命名空间
+func UpdateNamespaceOnboarding(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := model.GetKubeConfigByIDFromDB(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var onboarding model.NamespaceOnboarding
+	if err := c.ShouldBindJSON(&onboarding); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	onboarding.KubeConfigID = id
+	if err := model.ValidateNamespaceOnboarding(&onboarding); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveNamespaceOnboardingToDB(&onboarding); err != nil {
+		log.Printf("保存命名空间初始化配置失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, onboarding)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNamespaceDefaultDeny 获取命名空间默认拒绝网络策略的状态
+func GetNamespaceDefaultDeny(c *gin.Context) {
+	id := c.Param("id")
+	namespace := c.Param("namespace")
+
+	status, err := model.GetK8sManager().GetNamespaceDefaultDeny(id, namespace)
+	if err != nil {
+		log.Printf("获取默认拒绝策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取默认拒绝策略失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// SetNamespaceDefaultDeny 开启或关闭命名空间默认拒绝网络策略
+func SetNamespaceDefaultDeny(c *gin.Context) {
+	id := c.Param("id")
+	namespace := c.Param("namespace")
+
+	var req struct {
+		Enabled    bool `json:"enabled"`
+		DenyEgress bool `json:"denyEgress"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	if err := model.GetK8sManager().SetNamespaceDefaultDeny(id, namespace, req.Enabled, req.DenyEgress); err != nil {
+		log.Printf("设置默认拒绝策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("设置默认拒绝策略失败: %v", err)})
+		return
+	}
+
+	status, err := model.GetK8sManager().GetNamespaceDefaultDeny(id, namespace)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"namespace": namespace, "enabled": req.Enabled})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 修改节点的结果，节点不存在时返回404
+func respondNodeUpdate(c *gin.Context, node *model.NodeInfo, err error) {
+	if err == model.ErrNodeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("修改节点失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, node)
+}
+
+// GetK8sNodes 获取集群节点列表，包括容量、可分配资源、状态条件、标签、污点和Pod数量
+func GetK8sNodes(c *gin.Context) {
+	nodes, err := model.GetK8sManager().GetNodes(c.Param("id"))
+	if err != nil {
+		log.Printf("获取节点列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": nodes})
+}
+
+// GetK8sNode 获取节点详情及节点上的Pod
+func GetK8sNode(c *gin.Context) {
+	node, err := model.GetK8sManager().GetNode(c.Param("id"), c.Param("node"))
+	if err == model.ErrNodeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("获取节点详情失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, node)
+}
+
+// CordonK8sNode 禁止新Pod调度到节点
+func CordonK8sNode(c *gin.Context) {
+	node, err := model.GetK8sManager().CordonNode(c.Param("id"), c.Param("node"), true)
+	respondNodeUpdate(c, node, err)
+}
+
+// UncordonK8sNode 恢复节点调度
+func UncordonK8sNode(c *gin.Context) {
+	node, err := model.GetK8sManager().CordonNode(c.Param("id"), c.Param("node"), false)
+	respondNodeUpdate(c, node, err)
+}
+
+// UpdateK8sNodeLabels 新增、修改或删除节点标签
+func UpdateK8sNodeLabels(c *gin.Context) {
+	var update model.NodeLabelsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	if err := model.ValidateNodeLabelsUpdate(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	node, err := model.GetK8sManager().UpdateNodeLabels(c.Param("id"), c.Param("node"), &update)
+	respondNodeUpdate(c, node, err)
+}
+
+// SetK8sNodeTaints 替换节点上的污点，Kubernetes维护的node.kubernetes.io/污点保持不变
+func SetK8sNodeTaints(c *gin.Context) {
+	var request struct {
+		Taints []model.NodeTaint `json:"taints"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	if err := model.ValidateNodeTaints(request.Taints); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	node, err := model.GetK8sManager().SetNodeTaints(c.Param("id"), c.Param("node"), request.Taints)
+	respondNodeUpdate(c, node, err)
+}
+
+// DrainK8sNode 禁止节点调度并在后台驱逐节点上的Pod，返回202和排空进度
+func DrainK8sNode(c *gin.Context) {
+	var options model.DrainOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&options); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+			return
+		}
+	}
+	if err := model.ValidateDrainOptions(&options); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	drain, err := model.GetK8sManager().DrainNode(c.Param("id"), c.Param("node"), options)
+	var rejected *model.DrainRejectedError
+	switch {
+	case err == model.ErrNodeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err == model.ErrDrainInProgress:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.As(err, &rejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "pods": rejected.Pods})
+		return
+	case err != nil:
+		log.Printf("排空节点失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, drain)
+}
+
+// GetK8sNodeDrain 获取节点最近一次排空的进度
+func GetK8sNodeDrain(c *gin.Context) {
+	drain := model.GetNodeDrain(c.Param("id"), c.Param("node"))
+	if drain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "节点没有排空记录"})
+		return
+	}
+
+	c.JSON(http.StatusOK, drain)
+}
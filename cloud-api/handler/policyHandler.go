@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDeploymentPolicies 获取所有部署策略
+func GetDeploymentPolicies(c *gin.Context) {
+	policies, err := model.GetDeploymentPoliciesFromDB()
+	if err != nil {
+		log.Printf("获取部署策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取部署策略失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetDeploymentPolicyByID 获取指定部署策略
+func GetDeploymentPolicyByID(c *gin.Context) {
+	policy, err := model.GetDeploymentPolicyByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// CreateDeploymentPolicy 创建部署策略
+func CreateDeploymentPolicy(c *gin.Context) {
+	policy := model.DeploymentPolicy{Enabled: true}
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	policy.ID = ""
+	if err := model.ValidateDeploymentPolicy(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveDeploymentPolicyToDB(&policy); err != nil {
+		log.Printf("创建部署策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建部署策略失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusCreated, policy)
+}
+
+// UpdateDeploymentPolicy 更新部署策略
+func UpdateDeploymentPolicy(c *gin.Context) {
+	id := c.Param("id")
+
+	existing, err := model.GetDeploymentPolicyByIDFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var policy model.DeploymentPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	policy.ID = id
+	policy.CreatedAt = existing.CreatedAt
+	if err := model.ValidateDeploymentPolicy(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveDeploymentPolicyToDB(&policy); err != nil {
+		log.Printf("更新部署策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新部署策略失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteDeploymentPolicy 删除部署策略
+func DeleteDeploymentPolicy(c *gin.Context) {
+	if err := model.DeleteDeploymentPolicyFromDB(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "部署策略删除成功"})
+}
+
+// EvaluateDeploymentPolicies 对提交的应用配置进行策略评估（不保存、不部署）
+func EvaluateDeploymentPolicies(c *gin.Context) {
+	var app model.Application
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	result, err := model.EvaluateDeploymentPolicies(&app)
+	if err != nil {
+		log.Printf("评估部署策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("评估部署策略失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// checkDeploymentPolicies 评估应用是否符合部署策略
+// 违反enforce策略时写入422响应并返回false；否则返回warn策略的警告
+func checkDeploymentPolicies(c *gin.Context, app *model.Application) ([]model.PolicyViolation, bool) {
+	result, err := model.EvaluateDeploymentPolicies(app)
+	if err != nil {
+		log.Printf("评估部署策略失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("评估部署策略失败: %v", err)})
+		return nil, false
+	}
+
+	if !result.Allowed {
+		log.Printf("应用 %s 违反%d条部署策略", app.Name, len(result.Violations))
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      "应用违反部署策略",
+			"violations": result.Violations,
+			"warnings":   result.Warnings,
+		})
+		return nil, false
+	}
+
+	for _, w := range result.Warnings {
+		log.Printf("应用 %s 部署策略警告 [%s] %s: %s", app.Name, w.PolicyName, w.Field, w.Message)
+	}
+	return result.Warnings, true
+}
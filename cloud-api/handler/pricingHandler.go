@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 路由中使用default表示全局默认价格表
+func priceBookKubeConfigID(c *gin.Context) string {
+	id := c.Param("kubeConfigId")
+	if id == "default" {
+		return ""
+	}
+	return id
+}
+
+// GetPriceBooks 获取所有已配置的价格表
+func GetPriceBooks(c *gin.Context) {
+	books, err := model.GetPriceBooksFromDB()
+	if err != nil {
+		log.Printf("获取价格表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取价格表失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, books)
+}
+
+// GetPriceBook 获取集群生效的价格表
+func GetPriceBook(c *gin.Context) {
+	book, err := model.GetEffectivePriceBook(priceBookKubeConfigID(c))
+	if err != nil {
+		log.Printf("获取价格表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取价格表失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// UpdatePriceBook 设置集群的价格表
+func UpdatePriceBook(c *gin.Context) {
+	var book model.PriceBook
+	if err := c.ShouldBindJSON(&book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	book.KubeConfigID = priceBookKubeConfigID(c)
+	if book.KubeConfigID != "" {
+		if _, err := model.GetKubeConfigByIDFromDB(book.KubeConfigID); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("集群不存在: %v", err)})
+			return
+		}
+	}
+	if err := model.ValidatePriceBook(&book); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SavePriceBookToDB(&book); err != nil {
+		log.Printf("保存价格表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存价格表失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, book)
+}
+
+// DeletePriceBook 删除集群的价格表，恢复使用默认价格
+func DeletePriceBook(c *gin.Context) {
+	if err := model.DeletePriceBookFromDB(priceBookKubeConfigID(c)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "价格表删除成功"})
+}
+
+// GetChargebackReport 生成按应用、命名空间和集群汇总的每月费用报表
+// 查询参数: kubeConfigId(为空表示所有集群)、namespace(为空表示所有命名空间)、format=csv导出CSV
+func GetChargebackReport(c *gin.Context) {
+	kubeConfigID := c.Query("kubeConfigId")
+	namespace := c.Query("namespace")
+
+	report, err := model.GetK8sManager().GenerateChargebackReport(kubeConfigID, namespace)
+	if err != nil {
+		log.Printf("生成费用报表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成费用报表失败: %v", err)})
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		filename := fmt.Sprintf("chargeback-%s.csv", report.GeneratedAt.Format("20060102"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		c.Status(http.StatusOK)
+		if err := model.WriteChargebackCSV(c.Writer, report); err != nil {
+			log.Printf("导出费用报表失败: %v", err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// QuoteApplication 根据提交的应用配置估算每月费用，并与集群中当前部署的版本比较（不保存、不部署）
+func QuoteApplication(c *gin.Context) {
+	var app model.Application
+	if err := c.ShouldBindJSON(&app); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	if app.KubeConfigID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kubeConfigId不能为空"})
+		return
+	}
+	if err := model.ValidateResourceRequirements(app.Resources); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.ValidateAutoscalingConfig(app.Autoscaling); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := model.ValidateVolumeConfigs(app.Volumes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 指定了已有应用ID时，未提交的名称和命名空间沿用已保存的值，以便与其当前部署版本比较
+	if app.ID != "" {
+		if existing, err := model.GetApplicationByIDFromDB(app.ID); err == nil {
+			if app.Name == "" {
+				app.Name = existing.Name
+			}
+			if app.Namespace == "" {
+				app.Namespace = existing.Namespace
+			}
+		}
+	}
+
+	quote, err := model.GetK8sManager().QuoteApplication(&app)
+	if err != nil {
+		log.Printf("计算应用报价失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("计算应用报价失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
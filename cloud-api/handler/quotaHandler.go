@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetResourceQuota 获取集群命名空间的资源配额使用情况
+func GetResourceQuota(c *gin.Context) {
+	kubeConfigID := c.Query("kubeConfigId")
+	namespace := c.DefaultQuery("namespace", "default")
+	if kubeConfigID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少kubeConfigId参数"})
+		return
+	}
+
+	km := model.GetK8sManager()
+	quotas, err := km.ListResourceQuotas(kubeConfigID, namespace)
+	if err != nil {
+		log.Printf("获取资源配额失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取资源配额失败: %v", err)})
+		return
+	}
+
+	limitRanges, err := km.ListLimitRanges(kubeConfigID, namespace)
+	if err != nil {
+		log.Printf("获取LimitRange失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取LimitRange失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kubeConfigId": kubeConfigID,
+		"namespace":    namespace,
+		"quotas":       quotas,
+		"limitRanges":  limitRanges,
+	})
+}
+
+// GetK8sResourceQuotas 获取命名空间中的ResourceQuota
+func GetK8sResourceQuotas(c *gin.Context) {
+	quotas, err := model.GetK8sManager().ListResourceQuotas(c.Param("id"), c.Param("namespace"))
+	if err != nil {
+		log.Printf("获取资源配额失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取资源配额失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, quotas)
+}
+
+// CreateK8sResourceQuota 创建ResourceQuota
+func CreateK8sResourceQuota(c *gin.Context) {
+	var spec model.ResourceQuotaSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	if err := model.ValidateResourceQuotaSpec(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota, err := model.GetK8sManager().CreateResourceQuota(c.Param("id"), c.Param("namespace"), &spec)
+	if err != nil {
+		log.Printf("创建资源配额失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, quota)
+}
+
+// UpdateK8sResourceQuota 更新ResourceQuota
+func UpdateK8sResourceQuota(c *gin.Context) {
+	var spec model.ResourceQuotaSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	spec.Name = c.Param("name")
+	if err := model.ValidateResourceQuotaSpec(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	quota, err := model.GetK8sManager().UpdateResourceQuota(c.Param("id"), c.Param("namespace"), spec.Name, &spec)
+	if err != nil {
+		log.Printf("更新资源配额失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quota)
+}
+
+// DeleteK8sResourceQuota 删除ResourceQuota
+func DeleteK8sResourceQuota(c *gin.Context) {
+	if err := model.GetK8sManager().DeleteResourceQuota(c.Param("id"), c.Param("namespace"), c.Param("name")); err != nil {
+		log.Printf("删除资源配额失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "资源配额删除成功"})
+}
+
+// GetK8sLimitRanges 获取命名空间中的LimitRange
+func GetK8sLimitRanges(c *gin.Context) {
+	limitRanges, err := model.GetK8sManager().ListLimitRanges(c.Param("id"), c.Param("namespace"))
+	if err != nil {
+		log.Printf("获取LimitRange失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取LimitRange失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, limitRanges)
+}
+
+// CreateK8sLimitRange 创建LimitRange
+func CreateK8sLimitRange(c *gin.Context) {
+	var spec model.LimitRangeSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	if err := model.ValidateLimitRangeSpec(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limitRange, err := model.GetK8sManager().CreateLimitRange(c.Param("id"), c.Param("namespace"), &spec)
+	if err != nil {
+		log.Printf("创建LimitRange失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, limitRange)
+}
+
+// UpdateK8sLimitRange 更新LimitRange
+func UpdateK8sLimitRange(c *gin.Context) {
+	var spec model.LimitRangeSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+	spec.Name = c.Param("name")
+	if err := model.ValidateLimitRangeSpec(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limitRange, err := model.GetK8sManager().UpdateLimitRange(c.Param("id"), c.Param("namespace"), spec.Name, &spec)
+	if err != nil {
+		log.Printf("更新LimitRange失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, limitRange)
+}
+
+// DeleteK8sLimitRange 删除LimitRange
+func DeleteK8sLimitRange(c *gin.Context) {
+	if err := model.GetK8sManager().DeleteLimitRange(c.Param("id"), c.Param("namespace"), c.Param("name")); err != nil {
+		log.Printf("删除LimitRange失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "LimitRange删除成功"})
+}
+
+// checkResourceQuota 检查应用是否会超出命名空间剩余配额，超出时写入422响应并返回false
+// strict为false时（创建应用）查询配额失败不拒绝请求，返回警告信息
+func checkResourceQuota(c *gin.Context, app *model.Application, strict bool) (string, bool) {
+	violations, err := model.GetK8sManager().CheckApplicationQuota(app)
+	if err != nil {
+		log.Printf("检查资源配额失败: %v", err)
+		if !strict {
+			return fmt.Sprintf("无法检查资源配额: %v", err), true
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("检查资源配额失败: %v", err)})
+		return "", false
+	}
+
+	if len(violations) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":      fmt.Sprintf("超出命名空间资源配额: %s", strings.Join(violations, "; ")),
+			"violations": violations,
+		})
+		return "", false
+	}
+	return "", true
+}
@@ -114,6 +114,17 @@ func UpdateImageRegistry(c *gin.Context) {
 		return
 	}
 
+	// 凭据变更时，异步刷新各命名空间中由该仓库生成的镜像拉取Secret
+	if updateData.Username != existingRegistry.Username || updateData.Password != existingRegistry.Password ||
+		updateData.Email != existingRegistry.Email || updateData.URL != existingRegistry.URL {
+		registry := updateData
+		go func() {
+			if _, err := model.GetK8sManager().RefreshRegistryPullSecrets(&registry); err != nil {
+				log.Printf("刷新镜像拉取Secret失败: %v", err)
+			}
+		}()
+	}
+
 	// 过滤掉敏感信息
 	updateData.Password = ""
 
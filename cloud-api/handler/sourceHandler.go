@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetGitSources 获取所有git源及其最近一次同步状态
+func GetGitSources(c *gin.Context) {
+	sources, err := model.GetGitSourcesFromDB()
+	if err != nil {
+		log.Printf("获取git源失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取git源失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, sources)
+}
+
+// GetGitSource 获取git源详情，包括最近同步的提交和每个应用的同步结果
+func GetGitSource(c *gin.Context) {
+	source, err := model.GetGitSourceByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// CreateGitSource 创建git源并立即开始首次同步
+func CreateGitSource(c *gin.Context) {
+	var source model.GitSource
+	if err := c.ShouldBindJSON(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	source.ID = ""
+	source.Status = ""
+	source.LastCommit = ""
+	source.LastSyncedAt = nil
+	source.Applications = nil
+	if err := model.ValidateGitSource(&source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveGitSourceToDB(&source); err != nil {
+		log.Printf("保存git源失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("保存git源失败: %v", err)})
+		return
+	}
+
+	// 异步执行首次同步
+	go func(source model.GitSource) {
+		if err := model.GetK8sManager().SyncGitSource(&source); err != nil {
+			log.Printf("同步git源 %s 失败: %v", source.Name, err)
+		}
+	}(source)
+
+	c.JSON(http.StatusCreated, source)
+}
+
+// UpdateGitSource 更新git源配置，同步状态保持不变
+func UpdateGitSource(c *gin.Context) {
+	source, err := model.GetGitSourceByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updateData model.GitSource
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	source.Name = updateData.Name
+	source.RepoURL = updateData.RepoURL
+	source.Branch = updateData.Branch
+	source.Path = updateData.Path
+	source.KubeConfigID = updateData.KubeConfigID
+	source.IntervalSeconds = updateData.IntervalSeconds
+	source.Prune = updateData.Prune
+	if err := model.ValidateGitSource(source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveGitSourceToDB(source); err != nil {
+		log.Printf("更新git源失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新git源失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
+
+// DeleteGitSource 删除git源，其管理的应用保留并恢复为可通过API修改
+func DeleteGitSource(c *gin.Context) {
+	if err := model.SoftDeleteGitSourceFromDB(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "git源删除成功"})
+}
+
+// SyncGitSource 立即同步git源，返回同步后的状态
+func SyncGitSource(c *gin.Context) {
+	source, err := model.GetGitSourceByIDFromDB(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.GetK8sManager().SyncGitSource(source); err != nil {
+		log.Printf("同步git源 %s 失败: %v", source.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "source": source})
+		return
+	}
+
+	c.JSON(http.StatusOK, source)
+}
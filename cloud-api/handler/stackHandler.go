@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"cloud-deployment-api/model"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStacks 获取所有应用栈
+func GetStacks(c *gin.Context) {
+	stacks, err := model.GetStacksFromDB()
+	if err != nil {
+		log.Printf("获取应用栈列表失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("获取应用栈列表失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, stacks)
+}
+
+// GetStackByID 获取指定应用栈，包括部署顺序和各应用状态
+func GetStackByID(c *gin.Context) {
+	id := c.Param("id")
+
+	stack, err := model.GetStackByIDFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := model.StackDeployOrder(stack)
+	if err != nil {
+		log.Printf("计算应用栈部署顺序失败: %v", err)
+	}
+
+	applications := make([]gin.H, 0, len(order))
+	for _, appID := range order {
+		app, err := model.GetApplicationByIDFromDB(appID)
+		if err != nil {
+			applications = append(applications, gin.H{"id": appID, "status": "notfound"})
+			continue
+		}
+		applications = append(applications, gin.H{
+			"id":        app.ID,
+			"name":      app.Name,
+			"namespace": app.Namespace,
+			"status":    app.Status,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stack":        stack,
+		"deployOrder":  order,
+		"applications": applications,
+	})
+}
+
+// CreateStack 创建应用栈
+func CreateStack(c *gin.Context) {
+	var stack model.Stack
+	if err := c.ShouldBindJSON(&stack); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	stack.ID = ""
+	stack.Status = ""
+	stack.Message = ""
+	if err := model.ValidateStack(&stack); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveStackToDB(&stack); err != nil {
+		log.Printf("创建应用栈失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("创建应用栈失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusCreated, stack)
+}
+
+// UpdateStack 更新应用栈的名称、描述和成员
+func UpdateStack(c *gin.Context) {
+	id := c.Param("id")
+
+	stack, err := model.GetStackByIDFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if stack.Status == "deploying" || stack.Status == "deleting" {
+		c.JSON(http.StatusConflict, gin.H{"error": "应用栈正在部署或删除中，请稍后再试"})
+		return
+	}
+
+	var updateData model.Stack
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("解析请求体失败: %v", err)})
+		return
+	}
+
+	stack.Name = updateData.Name
+	stack.Description = updateData.Description
+	stack.Members = updateData.Members
+	if err := model.ValidateStack(stack); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := model.SaveStackToDB(stack); err != nil {
+		log.Printf("更新应用栈失败: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("更新应用栈失败: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, stack)
+}
+
+// DeployStack 按依赖顺序部署应用栈
+func DeployStack(c *gin.Context) {
+	id := c.Param("id")
+
+	stack, err := model.GetStackByIDFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := model.StackDeployOrder(stack)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 检查和修改状态在同一条语句中完成，避免并发的部署和删除请求同时通过
+	started, err := model.BeginStackOperationToDB(stack.ID, "deploying", "等待部署")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !started {
+		c.JSON(http.StatusConflict, gin.H{"error": "应用栈正在部署或删除中"})
+		return
+	}
+
+	// 异步部署，进度通过应用栈状态查询
+	go func() {
+		if err := model.GetK8sManager().DeployStack(stack); err != nil {
+			log.Printf("部署应用栈 %s 失败: %v", stack.Name, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "应用栈部署请求已发送，正在按依赖顺序部署",
+		"stackId":     id,
+		"status":      "deploying",
+		"deployOrder": order,
+	})
+}
+
+// DeleteStack 按部署顺序的逆序删除应用栈中的应用
+// 查询参数keepApplications=true时只卸载集群资源，保留应用记录
+func DeleteStack(c *gin.Context) {
+	id := c.Param("id")
+	keepApplications := c.Query("keepApplications") == "true"
+
+	stack, err := model.GetStackByIDFromDB(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	started, err := model.BeginStackOperationToDB(stack.ID, "deleting", "等待删除")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !started {
+		c.JSON(http.StatusConflict, gin.H{"error": "应用栈正在部署或删除中"})
+		return
+	}
+
+	go func() {
+		if err := model.GetK8sManager().DeleteStack(stack, !keepApplications); err != nil {
+			log.Printf("删除应用栈 %s 失败: %v", stack.Name, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "应用栈删除请求已发送，正在按逆序删除",
+		"stackId": id,
+		"status":  "deleting",
+	})
+}
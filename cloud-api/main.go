@@ -24,9 +24,27 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// 启动各集群的informer，由informer维护应用状态
+	go model.GetK8sManager().StartAllInformers()
+
 	// 启动命名空间缓存同步任务
 	go startNamespaceSyncTask()
 
+	// 启动配置漂移检测任务
+	go startDriftDetectionTask()
+
+	// 启动自动同步任务
+	go startReconcileTask()
+
+	// 启动git源同步任务
+	go startSourceSyncTask()
+
+	// 启动归档事件清理任务
+	go startEventArchivePurgeTask()
+
+	// 启动资源用量采集任务
+	go startMetricsRecordTask()
+
 	// 初始化路由
 	r := setupRouter()
 
@@ -85,6 +103,126 @@ func startNamespaceSyncTask() {
 	}
 }
 
+// startDriftDetectionTask 定时比较已部署应用的期望清单与集群中的实际对象
+func startDriftDetectionTask() {
+	// 等待初始化完成，并错开命名空间同步
+	time.Sleep(30 * time.Second)
+
+	detect := func() {
+		// 使用defer-recover防止崩溃
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("配置漂移检测panic: %v", r)
+			}
+		}()
+
+		model.GetK8sManager().DetectDriftForAllApplications()
+	}
+
+	detect()
+
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		detect()
+	}
+}
+
+// startReconcileTask 定时将开启自动同步的应用与集群状态对齐
+func startReconcileTask() {
+	// 等待初始化完成
+	time.Sleep(20 * time.Second)
+
+	// 失败的应用由模型层按指数退避控制重试间隔
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		func() {
+			// 使用defer-recover防止崩溃
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("自动同步panic: %v", r)
+				}
+			}()
+
+			model.GetK8sManager().ReconcileAutoSyncApplications()
+		}()
+	}
+}
+
+// startSourceSyncTask 定时拉取git源并同步其中的应用定义，每个git源按自己的同步间隔执行
+func startSourceSyncTask() {
+	// 等待初始化完成
+	time.Sleep(15 * time.Second)
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		func() {
+			// 使用defer-recover防止崩溃
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("git源同步panic: %v", r)
+				}
+			}()
+
+			model.GetK8sManager().SyncDueGitSources()
+		}()
+		<-ticker.C
+	}
+}
+
+// startEventArchivePurgeTask 定时按各集群的保留天数清理过期的归档事件
+func startEventArchivePurgeTask() {
+	// 等待初始化完成
+	time.Sleep(time.Minute)
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		func() {
+			// 使用defer-recover防止崩溃
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("归档事件清理panic: %v", r)
+				}
+			}()
+
+			if _, err := model.PurgeExpiredEventsFromDB(); err != nil {
+				log.Printf("清理归档事件失败: %v", err)
+			}
+		}()
+		<-ticker.C
+	}
+}
+
+// startMetricsRecordTask 定时采集应用Pod的资源用量，用于趋势图
+func startMetricsRecordTask() {
+	// 等待初始化完成
+	time.Sleep(30 * time.Second)
+
+	ticker := time.NewTicker(model.MetricsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		func() {
+			// 使用defer-recover防止崩溃
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("资源用量采集panic: %v", r)
+				}
+			}()
+
+			model.GetK8sManager().RecordApplicationMetrics()
+		}()
+		<-ticker.C
+	}
+}
+
 func setupLogging() *os.File {
 	// 创建日志目录
 	logDir := "logs"
@@ -138,6 +276,79 @@ func registerRoutes(r *gin.Engine) {
 		api.GET("/applications/:id/status", handler.GetDeploymentStatus)
 		api.GET("/applications/:id/yaml", handler.ExportApplicationToYaml)
 
+		// 应用栈相关路由
+		api.POST("/stacks", handler.CreateStack)
+		api.GET("/stacks", handler.GetStacks)
+		api.GET("/stacks/:id", handler.GetStackByID)
+		api.PUT("/stacks/:id", handler.UpdateStack)
+		api.DELETE("/stacks/:id", handler.DeleteStack)
+		api.POST("/stacks/:id/deploy", handler.DeployStack)
+
+		// 部署策略相关路由
+		api.GET("/policies", handler.GetDeploymentPolicies)
+		api.POST("/policies", handler.CreateDeploymentPolicy)
+		api.POST("/policies/evaluate", handler.EvaluateDeploymentPolicies)
+		api.GET("/policies/:id", handler.GetDeploymentPolicyByID)
+		api.PUT("/policies/:id", handler.UpdateDeploymentPolicy)
+		api.DELETE("/policies/:id", handler.DeleteDeploymentPolicy)
+
+		// 价格表和费用报表路由
+		api.GET("/pricebooks", handler.GetPriceBooks)
+		api.GET("/pricebooks/:kubeConfigId", handler.GetPriceBook)
+		api.PUT("/pricebooks/:kubeConfigId", handler.UpdatePriceBook)
+		api.DELETE("/pricebooks/:kubeConfigId", handler.DeletePriceBook)
+		api.GET("/reports/chargeback", handler.GetChargebackReport)
+		api.POST("/applications/quote", handler.QuoteApplication)
+
+		// 配置漂移检测路由
+		api.GET("/drift", handler.GetApplicationDrifts)
+		api.GET("/applications/:id/drift", handler.CheckApplicationDrift)
+		api.POST("/applications/:id/drift/resync", handler.ResyncApplicationDrift)
+		api.POST("/applications/:id/drift/accept", handler.AcceptApplicationDrift)
+		api.GET("/applications/:id/syncs", handler.GetApplicationSyncAttempts)
+
+		// git源路由
+		api.GET("/sources", handler.GetGitSources)
+		api.POST("/sources", handler.CreateGitSource)
+		api.GET("/sources/:id", handler.GetGitSource)
+		api.PUT("/sources/:id", handler.UpdateGitSource)
+		api.DELETE("/sources/:id", handler.DeleteGitSource)
+		api.POST("/sources/:id/sync", handler.SyncGitSource)
+
+		// 事件查询路由
+		api.GET("/applications/:id/events", handler.GetApplicationEvents)
+		api.GET("/kubeconfig/:id/namespaces/:namespace/events", handler.GetNamespaceEvents)
+
+		// 事件归档路由
+		api.GET("/events/archive", handler.SearchArchivedEvents)
+		api.GET("/kubeconfig/:id/events/settings", handler.GetEventArchiveSettings)
+		api.PUT("/kubeconfig/:id/events/settings", handler.UpdateEventArchiveSettings)
+
+		// 监控指标路由
+		api.GET("/applications/:id/metrics", handler.GetApplicationMetrics)
+		api.GET("/applications/:id/metrics/history", handler.GetApplicationMetricsHistory)
+		api.GET("/kubeconfig/:id/metrics/nodes", handler.GetNodeMetrics)
+		api.GET("/kubeconfig/:id/metrics/pods", handler.GetPodMetrics)
+
+		// 资源推荐路由
+		api.GET("/applications/:id/recommendations", handler.GetApplicationRecommendation)
+
+		// 节点管理路由
+		api.GET("/kubeconfig/:id/nodes", handler.GetK8sNodes)
+		api.GET("/kubeconfig/:id/nodes/:node", handler.GetK8sNode)
+		api.POST("/kubeconfig/:id/nodes/:node/cordon", handler.CordonK8sNode)
+		api.POST("/kubeconfig/:id/nodes/:node/uncordon", handler.UncordonK8sNode)
+		api.PATCH("/kubeconfig/:id/nodes/:node/labels", handler.UpdateK8sNodeLabels)
+		api.PUT("/kubeconfig/:id/nodes/:node/taints", handler.SetK8sNodeTaints)
+		api.POST("/kubeconfig/:id/nodes/:node/drain", handler.DrainK8sNode)
+		api.GET("/kubeconfig/:id/nodes/:node/drain", handler.GetK8sNodeDrain)
+
+		// 命名空间管理路由
+		api.POST("/kubeconfig/:id/namespaces", handler.CreateK8sNamespace)
+		api.DELETE("/kubeconfig/:id/namespaces/:namespace", handler.DeleteK8sNamespace)
+		api.GET("/kubeconfig/:id/onboarding", handler.GetNamespaceOnboarding)
+		api.PUT("/kubeconfig/:id/onboarding", handler.UpdateNamespaceOnboarding)
+
 		// Kubernetes资源相关路由
 		api.GET("/kubeconfig/:id/namespaces", handler.GetK8sNamespaces)
 		api.GET("/kubeconfig/:id/pods", handler.GetK8sPods)
@@ -147,6 +358,18 @@ func registerRoutes(r *gin.Engine) {
 		api.GET("/kubeconfig/:id/daemonsets", handler.GetK8sDaemonSets)
 		api.GET("/kubeconfig/:id/jobs", handler.GetK8sJobs)
 		api.GET("/kubeconfig/:id/resources", handler.GetK8sResources)
+		api.GET("/kubeconfig/:id/cache", handler.GetK8sCacheStatus)
+		api.POST("/kubeconfig/:id/cache/refresh", handler.RefreshK8sCache)
+		api.GET("/kubeconfig/:id/namespaces/:namespace/default-deny", handler.GetNamespaceDefaultDeny)
+		api.PUT("/kubeconfig/:id/namespaces/:namespace/default-deny", handler.SetNamespaceDefaultDeny)
+		api.GET("/kubeconfig/:id/namespaces/:namespace/resourcequotas", handler.GetK8sResourceQuotas)
+		api.POST("/kubeconfig/:id/namespaces/:namespace/resourcequotas", handler.CreateK8sResourceQuota)
+		api.PUT("/kubeconfig/:id/namespaces/:namespace/resourcequotas/:name", handler.UpdateK8sResourceQuota)
+		api.DELETE("/kubeconfig/:id/namespaces/:namespace/resourcequotas/:name", handler.DeleteK8sResourceQuota)
+		api.GET("/kubeconfig/:id/namespaces/:namespace/limitranges", handler.GetK8sLimitRanges)
+		api.POST("/kubeconfig/:id/namespaces/:namespace/limitranges", handler.CreateK8sLimitRange)
+		api.PUT("/kubeconfig/:id/namespaces/:namespace/limitranges/:name", handler.UpdateK8sLimitRange)
+		api.DELETE("/kubeconfig/:id/namespaces/:namespace/limitranges/:name", handler.DeleteK8sLimitRange)
 		
 		// 添加Kubernetes资源操作API
 		// 直接操作特定类型的Kubernetes资源
@@ -188,6 +411,7 @@ func registerRoutes(r *gin.Engine) {
 		// 添加Pod相关路由
 		api.GET("/pods", handler.GetPods)
 		api.GET("/pods/:name/logs", handler.GetPodLogs)
+		api.GET("/pods/:name/events", handler.GetPodEvents)
 		api.GET("/pods/exec", handler.ExecPodTerminal) // WebSocket连接
 	}
 } 
\ No newline at end of file
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -68,6 +69,48 @@ type Application struct {
 	// 新增字段: 标签和注解
 	Labels          map[string]string `json:"labels,omitempty" db:"labels_json"`
 	Annotations     map[string]string `json:"annotations,omitempty" db:"annotations_json"`
+	
+	// 新增字段: 端口协议 (http, https, grpc, tcp)，用于生成默认探针
+	PortProtocol    string            `json:"portProtocol,omitempty" db:"port_protocol"`
+	
+	// 新增字段: Pod优雅终止时间（秒）
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty" db:"termination_grace_period_seconds"`
+	
+	// 新增字段: 拓扑分布约束、优先级类和调度预设
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty" db:"topology_spread_constraints_json"`
+	PriorityClassName string            `json:"priorityClassName,omitempty" db:"priority_class_name"`
+	SchedulingPresets []string          `json:"schedulingPresets,omitempty" db:"scheduling_presets_json"`
+	
+	// 新增字段: Pod级别安全上下文
+	PodSecurityContext *PodSecurityContext `json:"podSecurityContext,omitempty" db:"pod_security_context_json"`
+	
+	// 新增字段: Service高级配置
+	ServiceConfig   *ServiceConfig    `json:"serviceConfig,omitempty" db:"service_config_json"`
+	
+	// 新增字段: 网络策略（允许访问的来源和出站白名单）
+	NetworkPolicy   *NetworkPolicyConfig `json:"networkPolicy,omitempty" db:"network_policy_json"`
+	
+	// 新增字段: Pod中断预算
+	DisruptionBudget *DisruptionBudget `json:"disruptionBudget,omitempty" db:"disruption_budget_json"`
+	
+	// 新增字段: 专用ServiceAccount和RBAC规则
+	ServiceAccount  *ServiceAccountConfig `json:"serviceAccount,omitempty" db:"service_account_json"`
+	
+	// 新增字段: 容器资源请求和限制，未设置时使用默认值
+	Resources       *ResourceRequirements `json:"resources,omitempty" db:"resources_json"`
+	
+	// 新增字段: 水平自动扩缩容，仅作为费用估算的输入，不保存也不部署
+	Autoscaling     *AutoscalingConfig `json:"autoscaling,omitempty" db:"-"`
+	
+	// 新增字段: 自动同步，开启后集群中的对象缺失或漂移时自动重新部署
+	AutoSync        bool              `json:"autoSync,omitempty" db:"auto_sync"`
+	
+	// 新增字段: 管理该应用的git源ID，由git源管理的应用不能通过API修改
+	SourceID        string            `json:"sourceId,omitempty" db:"source_id"`
+	
+	// 新增字段: 由集群informer维护的就绪副本数和最近一次状态变化时间，保存应用时不写入
+	ReadyReplicas   int               `json:"readyReplicas" db:"ready_replicas"`
+	StatusChangedAt *time.Time        `json:"statusChangedAt,omitempty" db:"status_changed_at"`
 }
 
 // 健康检查配置
@@ -79,8 +122,61 @@ type ProbeConfig struct {
 	TimeoutSeconds      int    `json:"timeoutSeconds,omitempty"`
 	FailureThreshold    int    `json:"failureThreshold,omitempty"`
 	SuccessThreshold    int    `json:"successThreshold,omitempty"`
-	ProbeType           string `json:"probeType,omitempty"` // http, tcp, command
+	ProbeType           string `json:"probeType,omitempty"` // http, tcp, command, grpc
 	Command             string `json:"command,omitempty"`
+	
+	// 新增字段: exec探针的命令参数数组，设置后优先于Command字符串
+	ExecCommand         []string `json:"execCommand,omitempty"`
+	
+	// 新增字段: HTTP探针的协议、主机和请求头
+	Scheme              string       `json:"scheme,omitempty"` // HTTP, HTTPS
+	Host                string       `json:"host,omitempty"`
+	HTTPHeaders         []HTTPHeader `json:"httpHeaders,omitempty"`
+	
+	// 新增字段: gRPC探针检查的服务名，为空时检查整个服务器
+	GRPCService         string `json:"grpcService,omitempty"`
+	
+	// 新增字段: 探针失败触发重启时的优雅终止时间（仅对存活和启动探针生效）
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// HTTP请求头
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// DefaultProbeConfig 根据端口协议生成默认的健康检查探针
+func DefaultProbeConfig(port int, protocol string) *ProbeConfig {
+	probe := &ProbeConfig{
+		Port:                port,
+		InitialDelaySeconds: 0,
+		PeriodSeconds:       10,
+		TimeoutSeconds:      1,
+		FailureThreshold:    3,
+		SuccessThreshold:    1,
+	}
+	
+	switch strings.ToLower(protocol) {
+	case "grpc":
+		probe.ProbeType = "grpc"
+	case "tcp", "udp":
+		probe.ProbeType = "tcp"
+	case "https":
+		probe.ProbeType = "http"
+		probe.Path = "/"
+		probe.Scheme = "HTTPS"
+	default:
+		probe.ProbeType = "http"
+		probe.Path = "/"
+	}
+	
+	return probe
+}
+
+// isValidProbeConfig 检查探针是否配置了可用的检查方式
+func isValidProbeConfig(probe *ProbeConfig) bool {
+	return len(probe.Command) > 0 || len(probe.ExecCommand) > 0 || probe.Port > 0
 }
 
 // 生命周期钩子配置
@@ -94,6 +190,50 @@ type Handler struct {
 	Command []string `json:"command,omitempty"`
 	Path    string   `json:"path,omitempty"`
 	Port    int      `json:"port,omitempty"`
+	
+	// 新增字段: HTTP钩子的协议、主机和请求头
+	Scheme      string       `json:"scheme,omitempty"` // HTTP, HTTPS
+	Host        string       `json:"host,omitempty"`
+	HTTPHeaders []HTTPHeader `json:"httpHeaders,omitempty"`
+	
+	// 新增字段: 等待指定秒数，常用于preStop等待流量摘除。
+	// 通过exec执行 /bin/sh -c "sleep N" 实现，镜像中必须有/bin/sh，distroless等无shell的镜像请改用command
+	SleepSeconds int `json:"sleepSeconds,omitempty"`
+}
+
+// isValidLifecycleHandler 检查生命周期钩子是否配置了可用的动作
+func isValidLifecycleHandler(handler *Handler) bool {
+	return len(handler.Command) > 0 ||
+		handler.SleepSeconds > 0 ||
+		(handler.Path != "" && handler.Port > 0)
+}
+
+// ValidateLifecycleConfig 校验生命周期钩子，sleepSeconds不能为负数，也不能与command或HTTP钩子同时设置
+func ValidateLifecycleConfig(lifecycle *LifecycleConfig) error {
+	if lifecycle == nil {
+		return nil
+	}
+
+	hooks := []struct {
+		name    string
+		handler *Handler
+	}{
+		{"postStart", lifecycle.PostStart},
+		{"preStop", lifecycle.PreStop},
+	}
+	for _, hook := range hooks {
+		if hook.handler == nil {
+			continue
+		}
+		if hook.handler.SleepSeconds < 0 {
+			return fmt.Errorf("%s.sleepSeconds不能为负数", hook.name)
+		}
+		// sleepSeconds本身就是exec钩子，同时设置时会被command或HTTP钩子覆盖
+		if hook.handler.SleepSeconds > 0 && (len(hook.handler.Command) > 0 || hook.handler.Path != "") {
+			return fmt.Errorf("%s.sleepSeconds不能与command或HTTP钩子同时设置", hook.name)
+		}
+	}
+	return nil
 }
 
 // 安全上下文配置
@@ -104,6 +244,126 @@ type SecurityContext struct {
 	ReadOnlyRootFilesystem *bool  `json:"readOnlyRootFilesystem,omitempty"`
 	Privileged            *bool  `json:"privileged,omitempty"`
 	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+	Capabilities          *Capabilities   `json:"capabilities,omitempty"`
+	SeccompProfile        *SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// Pod安全上下文配置
+type PodSecurityContext struct {
+	RunAsUser           *int64          `json:"runAsUser,omitempty"`
+	RunAsGroup          *int64          `json:"runAsGroup,omitempty"`
+	RunAsNonRoot        *bool           `json:"runAsNonRoot,omitempty"`
+	FSGroup             *int64          `json:"fsGroup,omitempty"`
+	FSGroupChangePolicy string          `json:"fsGroupChangePolicy,omitempty"` // OnRootMismatch 或 Always
+	SupplementalGroups  []int64         `json:"supplementalGroups,omitempty"`
+	Sysctls             []Sysctl        `json:"sysctls,omitempty"`
+	SeccompProfile      *SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
+// Linux能力配置
+type Capabilities struct {
+	Add  []string `json:"add,omitempty"`
+	Drop []string `json:"drop,omitempty"`
+}
+
+// Seccomp配置
+type SeccompProfile struct {
+	Type             string `json:"type"` // RuntimeDefault, Localhost 或 Unconfined
+	LocalhostProfile string `json:"localhostProfile,omitempty"`
+}
+
+// 内核参数配置
+type Sysctl struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Service高级配置
+type ServiceConfig struct {
+	Headless                      bool              `json:"headless,omitempty"`     // ClusterIP类型时创建无头服务
+	ExternalName                  string            `json:"externalName,omitempty"` // ExternalName类型的目标域名
+	NodePort                      int32             `json:"nodePort,omitempty"`     // 固定NodePort端口
+	SessionAffinity               string            `json:"sessionAffinity,omitempty"` // None 或 ClientIP
+	SessionAffinityTimeoutSeconds *int32            `json:"sessionAffinityTimeoutSeconds,omitempty"`
+	ExternalTrafficPolicy         string            `json:"externalTrafficPolicy,omitempty"` // Cluster 或 Local
+	InternalTrafficPolicy         string            `json:"internalTrafficPolicy,omitempty"` // Cluster 或 Local
+	LoadBalancerSourceRanges      []string          `json:"loadBalancerSourceRanges,omitempty"`
+	Annotations                   map[string]string `json:"annotations,omitempty"` // Service注解，如云厂商负载均衡器配置
+}
+
+// 网络策略配置
+type NetworkPolicyConfig struct {
+	Ingress  []NetworkPolicyIngressRule `json:"ingress,omitempty"` // 允许访问本应用的来源，为空表示拒绝所有入站流量
+	Egress   []NetworkPolicyEgressRule  `json:"egress,omitempty"`  // 出站白名单，为空表示不限制出站流量
+	AllowDNS *bool                      `json:"allowDNS,omitempty"` // 限制出站时是否允许访问集群DNS，默认允许
+}
+
+// 网络策略入站规则
+type NetworkPolicyIngressRule struct {
+	From  []NetworkPolicyPeer `json:"from,omitempty"`
+	Ports []NetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// 网络策略出站规则
+type NetworkPolicyEgressRule struct {
+	To    []NetworkPolicyPeer `json:"to,omitempty"`
+	Ports []NetworkPolicyPort `json:"ports,omitempty"`
+}
+
+// 网络策略来源/目标，可以是应用、命名空间或CIDR
+type NetworkPolicyPeer struct {
+	Application       string            `json:"application,omitempty"` // 应用名称
+	PodSelector       map[string]string `json:"podSelector,omitempty"`
+	Namespace         string            `json:"namespace,omitempty"` // 命名空间名称，为空表示本命名空间
+	NamespaceSelector map[string]string `json:"namespaceSelector,omitempty"`
+	CIDR              string            `json:"cidr,omitempty"`
+	Except            []string          `json:"except,omitempty"`
+}
+
+// 网络策略端口
+type NetworkPolicyPort struct {
+	Port     int32  `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"` // TCP, UDP 或 SCTP，默认TCP
+}
+
+// Pod中断预算配置，minAvailable和maxUnavailable只能指定一个，支持数值或百分比
+type DisruptionBudget struct {
+	MinAvailable   string `json:"minAvailable,omitempty"`   // 如 "1" 或 "50%"
+	MaxUnavailable string `json:"maxUnavailable,omitempty"` // 如 "1" 或 "25%"
+}
+
+// 水平自动扩缩容配置，费用估算时最小/最大费用分别按minReplicas和maxReplicas计算
+type AutoscalingConfig struct {
+	MinReplicas int `json:"minReplicas,omitempty"` // 默认使用应用的副本数
+	MaxReplicas int `json:"maxReplicas"`
+}
+
+// ServiceAccount配置
+type ServiceAccountConfig struct {
+	Create                       bool       `json:"create,omitempty"` // 是否为应用创建专用ServiceAccount
+	Name                         string     `json:"name,omitempty"`   // ServiceAccount名称，创建时默认与应用同名
+	AutomountServiceAccountToken *bool      `json:"automountServiceAccountToken,omitempty"`
+	Rules                        []RBACRule `json:"rules,omitempty"` // 授予该ServiceAccount的命名空间内权限
+}
+
+// RBAC权限规则
+type RBACRule struct {
+	APIGroups     []string `json:"apiGroups,omitempty"` // 为空表示核心API组
+	Resources     []string `json:"resources"`
+	Verbs         []string `json:"verbs"`
+	ResourceNames []string `json:"resourceNames,omitempty"`
+}
+
+// 容器资源配置
+type ResourceRequirements struct {
+	Requests ResourceList `json:"requests,omitempty"`
+	Limits   ResourceList `json:"limits,omitempty"`
+}
+
+// 资源数量，使用Kubernetes数量格式，如 "500m"、"1"、"512Mi"、"2Gi"
+type ResourceList struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
 }
 
 // 容忍配置
@@ -124,6 +384,13 @@ type Affinity struct {
 // 节点亲和性
 type NodeAffinity struct {
 	RequiredDuringSchedulingIgnoredDuringExecution *NodeSelector `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []PreferredSchedulingTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// 节点偏好调度条件
+type PreferredSchedulingTerm struct {
+	Weight     int32            `json:"weight"`
+	Preference NodeSelectorTerm `json:"preference"`
 }
 
 // 节点选择器
@@ -146,11 +413,19 @@ type NodeSelectorRequirement struct {
 // Pod亲和性
 type PodAffinity struct {
 	RequiredDuringSchedulingIgnoredDuringExecution []PodAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
 }
 
 // Pod反亲和性
 type PodAntiAffinity struct {
 	RequiredDuringSchedulingIgnoredDuringExecution []PodAffinityTerm `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+	PreferredDuringSchedulingIgnoredDuringExecution []WeightedPodAffinityTerm `json:"preferredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+
+// 带权重的Pod亲和性条件
+type WeightedPodAffinityTerm struct {
+	Weight          int32           `json:"weight"`
+	PodAffinityTerm PodAffinityTerm `json:"podAffinityTerm"`
 }
 
 // Pod亲和性条件
@@ -172,6 +447,15 @@ type LabelSelectorRequirement struct {
 	Values   []string `json:"values,omitempty"`
 }
 
+// 拓扑分布约束
+type TopologySpreadConstraint struct {
+	MaxSkew           int32             `json:"maxSkew,omitempty"`
+	TopologyKey       string            `json:"topologyKey"`
+	WhenUnsatisfiable string            `json:"whenUnsatisfiable,omitempty"` // DoNotSchedule 或 ScheduleAnyway
+	LabelSelector     *PodLabelSelector `json:"labelSelector,omitempty"`
+	MinDomains        *int32            `json:"minDomains,omitempty"`
+}
+
 // 卷配置
 type VolumeConfig struct {
 	Name        string `json:"name,omitempty"`
@@ -181,6 +465,9 @@ type VolumeConfig struct {
 	ClaimName   string `json:"claimName,omitempty"`
 	HostPath    string `json:"hostPath,omitempty"`
 	Medium      string `json:"medium,omitempty"` // "" or "Memory"
+	
+	// 新增字段: pvc类型卷的容量，用于费用估算
+	Size        string `json:"size,omitempty"` // 如 "10Gi"
 }
 
 // 卷挂载
@@ -228,45 +515,18 @@ func SaveApplicationToDB(app *Application) error {
 	// 序列化健康检查和生命周期钩子JSON字段前进行验证
 	
 	// 处理存活探针
-	if app.LivenessProbe != nil {
-		livenessProbeValid := false
-		if len(app.LivenessProbe.Command) > 0 || 
-			(app.LivenessProbe.Path != "" && app.LivenessProbe.Port > 0) ||
-			(app.LivenessProbe.Port > 0) {
-			livenessProbeValid = true
-		}
-		
-		if !livenessProbeValid {
-			app.LivenessProbe = nil
-		}
+	if app.LivenessProbe != nil && !isValidProbeConfig(app.LivenessProbe) {
+		app.LivenessProbe = nil
 	}
 	
 	// 处理就绪探针
-	if app.ReadinessProbe != nil {
-		readinessProbeValid := false
-		if len(app.ReadinessProbe.Command) > 0 || 
-			(app.ReadinessProbe.Path != "" && app.ReadinessProbe.Port > 0) ||
-			(app.ReadinessProbe.Port > 0) {
-			readinessProbeValid = true
-		}
-		
-		if !readinessProbeValid {
-			app.ReadinessProbe = nil
-		}
+	if app.ReadinessProbe != nil && !isValidProbeConfig(app.ReadinessProbe) {
+		app.ReadinessProbe = nil
 	}
 	
 	// 处理启动探针
-	if app.StartupProbe != nil {
-		startupProbeValid := false
-		if len(app.StartupProbe.Command) > 0 || 
-			(app.StartupProbe.Path != "" && app.StartupProbe.Port > 0) ||
-			(app.StartupProbe.Port > 0) {
-			startupProbeValid = true
-		}
-		
-		if !startupProbeValid {
-			app.StartupProbe = nil
-		}
+	if app.StartupProbe != nil && !isValidProbeConfig(app.StartupProbe) {
+		app.StartupProbe = nil
 	}
 	
 	// 处理生命周期钩子
@@ -275,13 +535,7 @@ func SaveApplicationToDB(app *Application) error {
 		
 		// 检查PostStart钩子
 		if app.Lifecycle.PostStart != nil {
-			postStartValid := false
-			if len(app.Lifecycle.PostStart.Command) > 0 ||
-				(app.Lifecycle.PostStart.Path != "" && app.Lifecycle.PostStart.Port > 0) {
-				postStartValid = true
-			}
-			
-			if !postStartValid {
+			if !isValidLifecycleHandler(app.Lifecycle.PostStart) {
 				app.Lifecycle.PostStart = nil
 			} else {
 				lifecycleValid = true
@@ -290,13 +544,7 @@ func SaveApplicationToDB(app *Application) error {
 		
 		// 检查PreStop钩子
 		if app.Lifecycle.PreStop != nil {
-			preStopValid := false
-			if len(app.Lifecycle.PreStop.Command) > 0 ||
-				(app.Lifecycle.PreStop.Path != "" && app.Lifecycle.PreStop.Port > 0) {
-				preStopValid = true
-			}
-			
-			if !preStopValid {
+			if !isValidLifecycleHandler(app.Lifecycle.PreStop) {
 				app.Lifecycle.PreStop = nil
 			} else {
 				lifecycleValid = true
@@ -402,6 +650,46 @@ func SaveApplicationToDB(app *Application) error {
 		return fmt.Errorf("序列化注解失败: %v", err)
 	}
 
+	topologySpreadConstraintsJSON, err := serializeJSONField(app.TopologySpreadConstraints)
+	if err != nil {
+		return fmt.Errorf("序列化拓扑分布约束失败: %v", err)
+	}
+
+	schedulingPresetsJSON, err := serializeJSONField(app.SchedulingPresets)
+	if err != nil {
+		return fmt.Errorf("序列化调度预设失败: %v", err)
+	}
+
+	podSecurityContextJSON, err := serializeJSONField(app.PodSecurityContext)
+	if err != nil {
+		return fmt.Errorf("序列化Pod安全上下文失败: %v", err)
+	}
+
+	serviceConfigJSON, err := serializeJSONField(app.ServiceConfig)
+	if err != nil {
+		return fmt.Errorf("序列化服务配置失败: %v", err)
+	}
+
+	networkPolicyJSON, err := serializeJSONField(app.NetworkPolicy)
+	if err != nil {
+		return fmt.Errorf("序列化网络策略失败: %v", err)
+	}
+
+	disruptionBudgetJSON, err := serializeJSONField(app.DisruptionBudget)
+	if err != nil {
+		return fmt.Errorf("序列化中断预算失败: %v", err)
+	}
+
+	serviceAccountJSON, err := serializeJSONField(app.ServiceAccount)
+	if err != nil {
+		return fmt.Errorf("序列化ServiceAccount配置失败: %v", err)
+	}
+
+	resourcesJSON, err := serializeJSONField(app.Resources)
+	if err != nil {
+		return fmt.Errorf("序列化资源配置失败: %v", err)
+	}
+
 	// 检查是否已存在
 	var exists bool
 	err = DB.Get(&exists, "SELECT EXISTS(SELECT 1 FROM applications WHERE id = $1)", app.ID)
@@ -421,8 +709,21 @@ func SaveApplicationToDB(app *Application) error {
                 security_context_json = $20, node_selector_json = $21, tolerations_json = $22,
                 affinity_json = $23, volumes_json = $24, volume_mounts_json = $25,
                 sync_host_timezone = $26, update_strategy = $27, rolling_update_json = $28,
-                labels_json = $29, annotations_json = $30
-            WHERE id = $31
+                labels_json = $29, annotations_json = $30,
+                port_protocol = $31,
+                termination_grace_period_seconds = $32,
+                topology_spread_constraints_json = $33,
+                priority_class_name = $34,
+                scheduling_presets_json = $35,
+                pod_security_context_json = $36,
+                service_config_json = $37,
+                network_policy_json = $38,
+                disruption_budget_json = $39,
+                service_account_json = $40,
+                resources_json = $41,
+                auto_sync = $42,
+                source_id = $43
+            WHERE id = $44
         `
 		_, err = DB.Exec(query, 
 			app.Name, app.Namespace, app.KubeConfigID, app.Description,
@@ -432,7 +733,18 @@ func SaveApplicationToDB(app *Application) error {
 			lifecycleJSON, commandJSON, argsJSON, envVarsJSON,
 			securityContextJSON, nodeSelectorJSON, tolerationsJSON,
 			affinityJSON, volumesJSON, volumeMountsJSON, app.SyncHostTimezone,
-			app.UpdateStrategy, rollingUpdateJSON, labelsJSON, annotationsJSON, app.ID)
+			app.UpdateStrategy, rollingUpdateJSON, labelsJSON, annotationsJSON,
+			app.PortProtocol,
+			app.TerminationGracePeriodSeconds,
+			topologySpreadConstraintsJSON, app.PriorityClassName, schedulingPresetsJSON,
+			podSecurityContextJSON,
+			serviceConfigJSON,
+			networkPolicyJSON,
+			disruptionBudgetJSON,
+			serviceAccountJSON,
+			resourcesJSON,
+			app.AutoSync,
+			app.SourceID, app.ID)
 		if err != nil {
 			return fmt.Errorf("更新应用失败: %v", err)
 		}
@@ -445,9 +757,31 @@ func SaveApplicationToDB(app *Application) error {
                 readiness_probe_json, startup_probe_json, lifecycle_json, command_json,
                 args_json, env_vars_json, security_context_json, node_selector_json,
                 tolerations_json, affinity_json, volumes_json, volume_mounts_json,
-                sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json)
+                sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json,
+                port_protocol,
+                termination_grace_period_seconds,
+                topology_spread_constraints_json, priority_class_name, scheduling_presets_json,
+                pod_security_context_json,
+                service_config_json,
+                network_policy_json,
+                disruption_budget_json,
+                service_account_json,
+                resources_json,
+                auto_sync,
+                source_id)
             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, 
-                $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32)
+                $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32,
+                $33,
+                $34,
+                $35, $36, $37,
+                $38,
+                $39,
+                $40,
+                $41,
+                $42,
+                $43,
+                $44,
+                $45)
         `
 		_, err = DB.Exec(query, 
 			app.ID, app.Name, app.Namespace, app.KubeConfigID, app.Description,
@@ -457,7 +791,18 @@ func SaveApplicationToDB(app *Application) error {
 			lifecycleJSON, commandJSON, argsJSON, envVarsJSON,
 			securityContextJSON, nodeSelectorJSON, tolerationsJSON,
 			affinityJSON, volumesJSON, volumeMountsJSON, app.SyncHostTimezone,
-			app.UpdateStrategy, rollingUpdateJSON, labelsJSON, annotationsJSON)
+			app.UpdateStrategy, rollingUpdateJSON, labelsJSON, annotationsJSON,
+			app.PortProtocol,
+			app.TerminationGracePeriodSeconds,
+			topologySpreadConstraintsJSON, app.PriorityClassName, schedulingPresetsJSON,
+			podSecurityContextJSON,
+			serviceConfigJSON,
+			networkPolicyJSON,
+			disruptionBudgetJSON,
+			serviceAccountJSON,
+			resourcesJSON,
+			app.AutoSync,
+			app.SourceID)
 		if err != nil {
 			return fmt.Errorf("插入应用失败: %v", err)
 		}
@@ -477,7 +822,19 @@ func GetApplicationsFromDB() ([]Application, error) {
                startup_probe_json, lifecycle_json, command_json, args_json,
                env_vars_json, security_context_json, node_selector_json,
                tolerations_json, affinity_json, volumes_json, volume_mounts_json,
-               sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json
+               sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json,
+               port_protocol,
+               termination_grace_period_seconds,
+               topology_spread_constraints_json, priority_class_name, scheduling_presets_json,
+               pod_security_context_json,
+               service_config_json,
+               network_policy_json,
+               disruption_budget_json,
+               service_account_json,
+               resources_json,
+               auto_sync,
+               source_id,
+               ready_replicas, status_changed_at
         FROM applications
         WHERE deleted_at IS NULL
         ORDER BY created_at DESC
@@ -499,6 +856,14 @@ func GetApplicationsFromDB() ([]Application, error) {
 		var volumesJSON, volumeMountsJSON sql.NullString
 		var rollingUpdateJSON sql.NullString
 		var labelsJSON, annotationsJSON sql.NullString
+		var topologySpreadConstraintsJSON sql.NullString
+		var schedulingPresetsJSON sql.NullString
+		var podSecurityContextJSON sql.NullString
+		var serviceConfigJSON sql.NullString
+		var networkPolicyJSON sql.NullString
+		var disruptionBudgetJSON sql.NullString
+		var serviceAccountJSON sql.NullString
+		var resourcesJSON sql.NullString
 		
 		err := rows.Scan(
 			&app.ID, &app.Name, &app.Namespace, &app.KubeConfigID, &app.Description,
@@ -510,6 +875,21 @@ func GetApplicationsFromDB() ([]Application, error) {
 			&tolerationsJSON, &affinityJSON, &volumesJSON, &volumeMountsJSON,
 			&app.SyncHostTimezone, &app.UpdateStrategy, &rollingUpdateJSON,
 			&labelsJSON, &annotationsJSON,
+			&app.PortProtocol,
+			&app.TerminationGracePeriodSeconds,
+			&topologySpreadConstraintsJSON,
+			&app.PriorityClassName,
+			&schedulingPresetsJSON,
+			&podSecurityContextJSON,
+			&serviceConfigJSON,
+			&networkPolicyJSON,
+			&disruptionBudgetJSON,
+			&serviceAccountJSON,
+			&resourcesJSON,
+			&app.AutoSync,
+			&app.SourceID,
+			&app.ReadyReplicas,
+			&app.StatusChangedAt,
 		)
 		
 		if err != nil {
@@ -589,6 +969,38 @@ func GetApplicationsFromDB() ([]Application, error) {
 			json.Unmarshal([]byte(annotationsJSON.String), &app.Annotations)
 		}
 		
+		if topologySpreadConstraintsJSON.Valid && topologySpreadConstraintsJSON.String != "" {
+			json.Unmarshal([]byte(topologySpreadConstraintsJSON.String), &app.TopologySpreadConstraints)
+		}
+		
+		if schedulingPresetsJSON.Valid && schedulingPresetsJSON.String != "" {
+			json.Unmarshal([]byte(schedulingPresetsJSON.String), &app.SchedulingPresets)
+		}
+		
+		if podSecurityContextJSON.Valid && podSecurityContextJSON.String != "" {
+			json.Unmarshal([]byte(podSecurityContextJSON.String), &app.PodSecurityContext)
+		}
+		
+		if serviceConfigJSON.Valid && serviceConfigJSON.String != "" {
+			json.Unmarshal([]byte(serviceConfigJSON.String), &app.ServiceConfig)
+		}
+		
+		if networkPolicyJSON.Valid && networkPolicyJSON.String != "" {
+			json.Unmarshal([]byte(networkPolicyJSON.String), &app.NetworkPolicy)
+		}
+		
+		if disruptionBudgetJSON.Valid && disruptionBudgetJSON.String != "" {
+			json.Unmarshal([]byte(disruptionBudgetJSON.String), &app.DisruptionBudget)
+		}
+		
+		if serviceAccountJSON.Valid && serviceAccountJSON.String != "" {
+			json.Unmarshal([]byte(serviceAccountJSON.String), &app.ServiceAccount)
+		}
+		
+		if resourcesJSON.Valid && resourcesJSON.String != "" {
+			json.Unmarshal([]byte(resourcesJSON.String), &app.Resources)
+		}
+		
 		apps = append(apps, app)
 	}
 	
@@ -605,7 +1017,19 @@ func GetApplicationByIDFromDB(id string) (*Application, error) {
                startup_probe_json, lifecycle_json, command_json, args_json,
                env_vars_json, security_context_json, node_selector_json,
                tolerations_json, affinity_json, volumes_json, volume_mounts_json,
-               sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json
+               sync_host_timezone, update_strategy, rolling_update_json, labels_json, annotations_json,
+               port_protocol,
+               termination_grace_period_seconds,
+               topology_spread_constraints_json, priority_class_name, scheduling_presets_json,
+               pod_security_context_json,
+               service_config_json,
+               network_policy_json,
+               disruption_budget_json,
+               service_account_json,
+               resources_json,
+               auto_sync,
+               source_id,
+               ready_replicas, status_changed_at
         FROM applications
         WHERE id = $1 AND deleted_at IS NULL
     `
@@ -617,6 +1041,14 @@ func GetApplicationByIDFromDB(id string) (*Application, error) {
 	var volumesJSON, volumeMountsJSON sql.NullString
 	var rollingUpdateJSON sql.NullString
 	var labelsJSON, annotationsJSON sql.NullString
+	var topologySpreadConstraintsJSON sql.NullString
+	var schedulingPresetsJSON sql.NullString
+	var podSecurityContextJSON sql.NullString
+	var serviceConfigJSON sql.NullString
+	var networkPolicyJSON sql.NullString
+	var disruptionBudgetJSON sql.NullString
+	var serviceAccountJSON sql.NullString
+	var resourcesJSON sql.NullString
 	
 	err := DB.QueryRow(query, id).Scan(
 		&app.ID, &app.Name, &app.Namespace, &app.KubeConfigID, &app.Description,
@@ -628,6 +1060,21 @@ func GetApplicationByIDFromDB(id string) (*Application, error) {
 		&tolerationsJSON, &affinityJSON, &volumesJSON, &volumeMountsJSON,
 		&app.SyncHostTimezone, &app.UpdateStrategy, &rollingUpdateJSON,
 		&labelsJSON, &annotationsJSON,
+		&app.PortProtocol,
+		&app.TerminationGracePeriodSeconds,
+		&topologySpreadConstraintsJSON,
+		&app.PriorityClassName,
+		&schedulingPresetsJSON,
+		&podSecurityContextJSON,
+		&serviceConfigJSON,
+		&networkPolicyJSON,
+		&disruptionBudgetJSON,
+		&serviceAccountJSON,
+		&resourcesJSON,
+		&app.AutoSync,
+		&app.SourceID,
+		&app.ReadyReplicas,
+		&app.StatusChangedAt,
 	)
 	
 	if err != nil {
@@ -735,6 +1182,54 @@ func GetApplicationByIDFromDB(id string) (*Application, error) {
 		json.Unmarshal([]byte(annotationsJSON.String), &app.Annotations)
 	}
 	
+	if topologySpreadConstraintsJSON.Valid && topologySpreadConstraintsJSON.String != "" {
+		if err := json.Unmarshal([]byte(topologySpreadConstraintsJSON.String), &app.TopologySpreadConstraints); err != nil {
+			log.Printf("反序列化拓扑分布约束失败: %v", err)
+		}
+	}
+	
+	if schedulingPresetsJSON.Valid && schedulingPresetsJSON.String != "" {
+		if err := json.Unmarshal([]byte(schedulingPresetsJSON.String), &app.SchedulingPresets); err != nil {
+			log.Printf("反序列化调度预设失败: %v", err)
+		}
+	}
+	
+	if podSecurityContextJSON.Valid && podSecurityContextJSON.String != "" {
+		if err := json.Unmarshal([]byte(podSecurityContextJSON.String), &app.PodSecurityContext); err != nil {
+			log.Printf("反序列化Pod安全上下文失败: %v", err)
+		}
+	}
+	
+	if serviceConfigJSON.Valid && serviceConfigJSON.String != "" {
+		if err := json.Unmarshal([]byte(serviceConfigJSON.String), &app.ServiceConfig); err != nil {
+			log.Printf("反序列化服务配置失败: %v", err)
+		}
+	}
+	
+	if networkPolicyJSON.Valid && networkPolicyJSON.String != "" {
+		if err := json.Unmarshal([]byte(networkPolicyJSON.String), &app.NetworkPolicy); err != nil {
+			log.Printf("反序列化网络策略失败: %v", err)
+		}
+	}
+	
+	if disruptionBudgetJSON.Valid && disruptionBudgetJSON.String != "" {
+		if err := json.Unmarshal([]byte(disruptionBudgetJSON.String), &app.DisruptionBudget); err != nil {
+			log.Printf("反序列化中断预算失败: %v", err)
+		}
+	}
+	
+	if serviceAccountJSON.Valid && serviceAccountJSON.String != "" {
+		if err := json.Unmarshal([]byte(serviceAccountJSON.String), &app.ServiceAccount); err != nil {
+			log.Printf("反序列化ServiceAccount配置失败: %v", err)
+		}
+	}
+	
+	if resourcesJSON.Valid && resourcesJSON.String != "" {
+		if err := json.Unmarshal([]byte(resourcesJSON.String), &app.Resources); err != nil {
+			log.Printf("反序列化资源配置失败: %v", err)
+		}
+	}
+	
 	return &app, nil
 }
 
@@ -801,10 +1296,11 @@ func UpdateApplicationStatusToDB(id string, status string) error {
 		return fmt.Errorf("获取应用失败: %v", err)
 	}
 	
-	// 更新状态
+	// 更新状态，状态发生变化时记录变化时间
 	query := `
         UPDATE applications 
-        SET status = $1, updated_at = $2
+        SET status = $1, updated_at = $2,
+            status_changed_at = CASE WHEN status IS DISTINCT FROM $1 THEN $2 ELSE status_changed_at END
         WHERE id = $3
     `
 	now := time.Now()
@@ -817,6 +1313,22 @@ func UpdateApplicationStatusToDB(id string, status string) error {
 	return nil
 }
 
+// UpdateApplicationRuntimeStatusToDB 更新集群中观察到的应用状态和就绪副本数，不修改应用的更新时间。
+// 用户主动停止的应用只更新就绪副本数，保留stopped状态；状态和副本数都没有变化时不写入
+func UpdateApplicationRuntimeStatusToDB(id string, status string, readyReplicas int) error {
+	query := `
+        UPDATE applications
+        SET status = CASE WHEN status = 'stopped' THEN status ELSE $1 END, ready_replicas = $2,
+            status_changed_at = CASE WHEN status IS DISTINCT FROM $1 AND status <> 'stopped' THEN $3 ELSE status_changed_at END
+        WHERE id = $4 AND deleted_at IS NULL
+          AND ((status IS DISTINCT FROM $1 AND status IS DISTINCT FROM 'stopped') OR ready_replicas <> $2)
+    `
+	if _, err := DB.Exec(query, status, readyReplicas, time.Now(), id); err != nil {
+		return fmt.Errorf("更新应用运行状态失败: %v", err)
+	}
+	return nil
+}
+
 // ApplicationV2 应用配置
 type ApplicationV2 struct {
 	ID          string    `json:"id"`
@@ -882,20 +1394,14 @@ type DeploymentStatus struct {
 	FinishTime time.Time `json:"finishTime,omitempty"`
 }
 
-// ResourceQuota 资源配额
-type ResourceQuota struct {
-	CPU     float64 `json:"cpu"`
-	Memory  float64 `json:"memory"` // GB
-	Storage float64 `json:"storage"` // GB
-	Total   float64 `json:"total"`
-}
-
 // PriceEstimation 价格预估
 type PriceEstimation struct {
-	CPU     float64 `json:"cpu"`
-	Memory  float64 `json:"memory"`
-	Storage float64 `json:"storage"`
-	Total   float64 `json:"total"`
+	CPU          float64 `json:"cpu"`
+	Memory       float64 `json:"memory"`
+	Storage      float64 `json:"storage"`
+	LoadBalancer float64 `json:"loadBalancer"`
+	Total        float64 `json:"total"`
+	Currency     string  `json:"currency"`
 }
 
 // NewApplication 创建新应用
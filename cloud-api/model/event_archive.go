@@ -0,0 +1,203 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEventRetentionDays = 7
+	maxEventRetentionDays     = 365
+	defaultEventSearchLimit   = 200
+	maxEventSearchLimit       = 1000
+)
+
+// ArchivedEvent 归档的集群事件
+type ArchivedEvent struct {
+	ID             string    `json:"id" db:"id"`
+	KubeConfigID   string    `json:"kubeConfigId" db:"kube_config_id"`
+	EventUID       string    `json:"eventUid" db:"event_uid"`
+	Namespace      string    `json:"namespace" db:"namespace"`
+	ObjectKind     string    `json:"objectKind" db:"object_kind"`
+	ObjectName     string    `json:"objectName" db:"object_name"`
+	ObjectUID      string    `json:"objectUid" db:"object_uid"`
+	ApplicationID  string    `json:"applicationId" db:"application_id"`
+	Type           string    `json:"type" db:"type"`
+	Reason         string    `json:"reason" db:"reason"`
+	Message        string    `json:"message" db:"message"`
+	Count          int       `json:"count" db:"count"`
+	Source         string    `json:"source" db:"source"`
+	FirstTimestamp time.Time `json:"firstTimestamp" db:"first_timestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp" db:"last_timestamp"`
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+}
+
+// EventSearchQuery 归档事件的查询条件，空值表示不过滤
+type EventSearchQuery struct {
+	KubeConfigID  string
+	Namespace     string
+	ApplicationID string
+	Type          string
+	Reason        string
+	Text          string // 按消息内容模糊匹配
+	Since         *time.Time
+	Until         *time.Time
+	Limit         int
+}
+
+// EventArchiveSettings 集群的事件归档设置
+type EventArchiveSettings struct {
+	KubeConfigID  string    `json:"kubeConfigId" db:"kube_config_id"`
+	RetentionDays int       `json:"retentionDays" db:"retention_days"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// SaveArchivedEventToDB 保存归档事件，同一事件同一次数只保存一次
+func SaveArchivedEventToDB(event *ArchivedEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+
+	query := `
+        INSERT INTO cluster_events (id, kube_config_id, event_uid, namespace, object_kind, object_name, object_uid,
+            application_id, type, reason, message, count, source, first_timestamp, last_timestamp, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+        ON CONFLICT (kube_config_id, event_uid, count) DO NOTHING
+    `
+	_, err := DB.Exec(query,
+		event.ID, event.KubeConfigID, event.EventUID, event.Namespace, event.ObjectKind, event.ObjectName, event.ObjectUID,
+		event.ApplicationID, event.Type, event.Reason, event.Message, event.Count, event.Source,
+		event.FirstTimestamp, event.LastTimestamp, time.Now())
+	if err != nil {
+		return fmt.Errorf("保存归档事件失败: %v", err)
+	}
+	return nil
+}
+
+// SearchArchivedEventsFromDB 按条件查询归档事件，按最后发生时间倒序
+func SearchArchivedEventsFromDB(q EventSearchQuery) ([]ArchivedEvent, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if q.KubeConfigID != "" {
+		addCondition("kube_config_id = $%d", q.KubeConfigID)
+	}
+	if q.Namespace != "" {
+		addCondition("namespace = $%d", q.Namespace)
+	}
+	if q.ApplicationID != "" {
+		addCondition("application_id = $%d", q.ApplicationID)
+	}
+	if q.Type != "" {
+		addCondition("type = $%d", q.Type)
+	}
+	if q.Reason != "" {
+		addCondition("reason = $%d", q.Reason)
+	}
+	if q.Text != "" {
+		addCondition("message ILIKE $%d", "%"+escapeLikePattern(q.Text)+"%")
+	}
+	if q.Since != nil {
+		addCondition("last_timestamp >= $%d", *q.Since)
+	}
+	if q.Until != nil {
+		addCondition("last_timestamp <= $%d", *q.Until)
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultEventSearchLimit
+	}
+	if limit > maxEventSearchLimit {
+		limit = maxEventSearchLimit
+	}
+
+	query := `
+        SELECT id, kube_config_id, event_uid, namespace, object_kind, object_name, object_uid, application_id, type,
+               reason, message, count, source, first_timestamp, last_timestamp, created_at
+        FROM cluster_events`
+	if len(conditions) > 0 {
+		query += "\n        WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf("\n        ORDER BY last_timestamp DESC\n        LIMIT $%d", len(args))
+
+	events := []ArchivedEvent{}
+	if err := DB.Select(&events, query, args...); err != nil {
+		return nil, fmt.Errorf("查询归档事件失败: %v", err)
+	}
+	return events, nil
+}
+
+// 转义LIKE模式中的通配符
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetEventArchiveSettingsFromDB 获取集群的事件归档设置，未设置时返回默认值
+func GetEventArchiveSettingsFromDB(kubeConfigID string) (*EventArchiveSettings, error) {
+	var settings EventArchiveSettings
+	query := `
+        SELECT kube_config_id, retention_days, updated_at
+        FROM event_archive_settings
+        WHERE kube_config_id = $1
+    `
+	if err := DB.Get(&settings, query, kubeConfigID); err != nil {
+		if err == sql.ErrNoRows {
+			return &EventArchiveSettings{KubeConfigID: kubeConfigID, RetentionDays: defaultEventRetentionDays}, nil
+		}
+		return nil, fmt.Errorf("查询事件归档设置失败: %v", err)
+	}
+	return &settings, nil
+}
+
+// ValidateEventArchiveSettings 校验事件归档设置
+func ValidateEventArchiveSettings(settings *EventArchiveSettings) error {
+	if settings.RetentionDays < 1 || settings.RetentionDays > maxEventRetentionDays {
+		return fmt.Errorf("保留天数必须在1到%d之间", maxEventRetentionDays)
+	}
+	return nil
+}
+
+// SaveEventArchiveSettingsToDB 保存集群的事件归档设置
+func SaveEventArchiveSettingsToDB(settings *EventArchiveSettings) error {
+	settings.UpdatedAt = time.Now()
+
+	query := `
+        INSERT INTO event_archive_settings (kube_config_id, retention_days, updated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (kube_config_id) DO UPDATE SET retention_days = $2, updated_at = $3
+    `
+	if _, err := DB.Exec(query, settings.KubeConfigID, settings.RetentionDays, settings.UpdatedAt); err != nil {
+		return fmt.Errorf("保存事件归档设置失败: %v", err)
+	}
+	return nil
+}
+
+// PurgeExpiredEventsFromDB 按各集群的保留天数删除过期的归档事件
+func PurgeExpiredEventsFromDB() (int64, error) {
+	query := `
+        DELETE FROM cluster_events e
+        WHERE e.last_timestamp < NOW() - make_interval(days => COALESCE(
+            (SELECT s.retention_days FROM event_archive_settings s WHERE s.kube_config_id = e.kube_config_id), $1))
+    `
+	result, err := DB.Exec(query, defaultEventRetentionDays)
+	if err != nil {
+		return 0, fmt.Errorf("删除过期归档事件失败: %v", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows > 0 {
+		log.Printf("已删除%d条过期的归档事件", rows)
+	}
+	return rows, nil
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultGitSourceInterval = 300
+	minGitSourceInterval     = 30
+)
+
+// GitSource git源，定期拉取git仓库中的应用定义并同步到应用
+type GitSource struct {
+	ID              string            `json:"id" db:"id"`
+	Name            string            `json:"name" db:"name"`
+	RepoURL         string            `json:"repoUrl" db:"repo_url"`            // 本地绝对路径或file://地址
+	Branch          string            `json:"branch" db:"branch"`               // 为空时使用仓库默认分支
+	Path            string            `json:"path" db:"path"`                   // 仓库中存放应用定义的目录，为空表示根目录
+	KubeConfigID    string            `json:"kubeConfigId" db:"kube_config_id"` // 应用定义未指定集群时使用
+	IntervalSeconds int               `json:"intervalSeconds" db:"interval_seconds"`
+	Prune           bool              `json:"prune" db:"prune"` // 删除仓库中已不存在的应用
+	LastCommit      string            `json:"lastCommit" db:"last_commit"`
+	Status          string            `json:"status" db:"status"` // pending, syncing, synced, error
+	Message         string            `json:"message" db:"message"`
+	Applications    []SourceAppStatus `json:"applications" db:"-"`
+	LastSyncedAt    *time.Time        `json:"lastSyncedAt,omitempty" db:"last_synced_at"`
+	CreatedAt       time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time         `json:"updatedAt" db:"updated_at"`
+	DeletedAt       *time.Time        `json:"deletedAt,omitempty" db:"deleted_at"`
+
+	AppStatusJSON sql.NullString `json:"-" db:"app_status_json"`
+}
+
+// SourceAppStatus git源中单个应用定义的同步结果
+type SourceAppStatus struct {
+	File          string `json:"file"`
+	Name          string `json:"name,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	ApplicationID string `json:"applicationId,omitempty"`
+	Action        string `json:"action"` // created, updated, unchanged, deleted, orphaned, invalid
+	Status        string `json:"status"` // synced, failed, blocked
+	Message       string `json:"message,omitempty"`
+}
+
+// ValidateGitSource 校验git源配置并填充默认值
+func ValidateGitSource(source *GitSource) error {
+	if source.Name == "" {
+		return fmt.Errorf("git源名称不能为空")
+	}
+	if source.RepoURL == "" {
+		return fmt.Errorf("repoUrl不能为空")
+	}
+	// 只支持本地仓库，不允许访问网络上的地址
+	if !filepath.IsAbs(source.RepoURL) && !strings.HasPrefix(source.RepoURL, "file://") {
+		return fmt.Errorf("repoUrl只支持本地绝对路径或file://地址: %s", source.RepoURL)
+	}
+	// 以-开头的值会被git当作命令行选项
+	if strings.HasPrefix(source.Branch, "-") {
+		return fmt.Errorf("分支名称无效: %s", source.Branch)
+	}
+
+	// 目录限制在仓库内
+	source.Path = strings.Trim(filepath.ToSlash(filepath.Clean("/"+source.Path)), "/")
+
+	if source.KubeConfigID != "" {
+		if _, err := GetKubeConfigByIDFromDB(source.KubeConfigID); err != nil {
+			return fmt.Errorf("集群 %s 不存在", source.KubeConfigID)
+		}
+	}
+
+	if source.IntervalSeconds == 0 {
+		source.IntervalSeconds = defaultGitSourceInterval
+	}
+	if source.IntervalSeconds < minGitSourceInterval {
+		return fmt.Errorf("同步间隔不能小于%d秒", minGitSourceInterval)
+	}
+	return nil
+}
+
+// SaveGitSourceToDB 保存git源到数据库
+func SaveGitSourceToDB(source *GitSource) error {
+	if source.ID == "" {
+		source.ID = uuid.New().String()
+		source.CreatedAt = time.Now()
+	}
+	source.UpdatedAt = time.Now()
+	if source.Status == "" {
+		source.Status = "pending"
+	}
+
+	appStatusJSON, err := serializeJSONField(source.Applications)
+	if err != nil {
+		return fmt.Errorf("序列化应用同步结果失败: %v", err)
+	}
+
+	// 检查是否已存在
+	var exists bool
+	err = DB.Get(&exists, "SELECT EXISTS(SELECT 1 FROM git_sources WHERE id = $1)", source.ID)
+	if err != nil {
+		return fmt.Errorf("检查git源是否存在时出错: %v", err)
+	}
+
+	if exists {
+		query := `
+            UPDATE git_sources
+            SET name = $1, repo_url = $2, branch = $3, path = $4, kube_config_id = $5, interval_seconds = $6,
+                prune = $7, last_commit = $8, status = $9, message = $10, app_status_json = $11,
+                last_synced_at = $12, updated_at = $13
+            WHERE id = $14
+        `
+		_, err = DB.Exec(query,
+			source.Name, source.RepoURL, source.Branch, source.Path, source.KubeConfigID, source.IntervalSeconds,
+			source.Prune, source.LastCommit, source.Status, source.Message, appStatusJSON,
+			source.LastSyncedAt, source.UpdatedAt, source.ID)
+		if err != nil {
+			return fmt.Errorf("更新git源失败: %v", err)
+		}
+	} else {
+		query := `
+            INSERT INTO git_sources (id, name, repo_url, branch, path, kube_config_id, interval_seconds, prune,
+                last_commit, status, message, app_status_json, last_synced_at, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+        `
+		_, err = DB.Exec(query,
+			source.ID, source.Name, source.RepoURL, source.Branch, source.Path, source.KubeConfigID,
+			source.IntervalSeconds, source.Prune, source.LastCommit, source.Status, source.Message,
+			appStatusJSON, source.LastSyncedAt, source.CreatedAt, source.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("插入git源失败: %v", err)
+		}
+	}
+
+	log.Printf("成功保存git源: %s (%s)", source.Name, source.ID)
+	return nil
+}
+
+const gitSourceColumns = `id, name, repo_url, branch, path, kube_config_id, interval_seconds, prune, last_commit,
+               status, message, app_status_json, last_synced_at, created_at, updated_at, deleted_at`
+
+// GetGitSourcesFromDB 从数据库获取所有git源
+func GetGitSourcesFromDB() ([]GitSource, error) {
+	var sources []GitSource
+	query := `
+        SELECT ` + gitSourceColumns + `
+        FROM git_sources
+        WHERE deleted_at IS NULL
+        ORDER BY created_at DESC
+    `
+	if err := DB.Select(&sources, query); err != nil {
+		return nil, fmt.Errorf("查询git源失败: %v", err)
+	}
+
+	for i := range sources {
+		sources[i].decodeAppStatus()
+	}
+	return sources, nil
+}
+
+// GetGitSourceByIDFromDB 从数据库获取指定ID的git源
+func GetGitSourceByIDFromDB(id string) (*GitSource, error) {
+	var source GitSource
+	query := `
+        SELECT ` + gitSourceColumns + `
+        FROM git_sources
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	if err := DB.Get(&source, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("未找到ID为%s的git源", id)
+		}
+		return nil, fmt.Errorf("查询git源失败: %v", err)
+	}
+
+	source.decodeAppStatus()
+	return &source, nil
+}
+
+// SoftDeleteGitSourceFromDB 软删除git源，并解除其对应用的管理，应用恢复为可通过API修改
+func SoftDeleteGitSourceFromDB(id string) error {
+	now := time.Now()
+	result, err := DB.Exec("UPDATE git_sources SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL", now, id)
+	if err != nil {
+		return fmt.Errorf("删除git源失败: %v", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("未找到ID为%s的git源", id)
+	}
+
+	if _, err := DB.Exec("UPDATE applications SET source_id = '' WHERE source_id = $1", id); err != nil {
+		return fmt.Errorf("解除git源对应用的管理失败: %v", err)
+	}
+	return nil
+}
+
+// 解析数据库中的应用同步结果JSON
+func (s *GitSource) decodeAppStatus() {
+	s.Applications = []SourceAppStatus{}
+	if s.AppStatusJSON.Valid && s.AppStatusJSON.String != "" {
+		if err := json.Unmarshal([]byte(s.AppStatusJSON.String), &s.Applications); err != nil {
+			log.Printf("解析git源应用同步结果失败 (ID: %s): %v", s.ID, err)
+		}
+	}
+}
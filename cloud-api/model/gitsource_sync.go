@@ -0,0 +1,433 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// git源仓库的本地工作目录
+	gitSourceWorkDir = "data/sources"
+	// 单条git命令的超时时间
+	gitCommandTimeout = 2 * time.Minute
+)
+
+var (
+	// 同一时间只同步一个git源，避免定时任务和手动同步同时修改应用
+	gitSourceSyncMu sync.Mutex
+
+	yamlDocumentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+)
+
+// 仓库中的一个应用定义
+type sourceDefinition struct {
+	file string
+	app  *Application
+}
+
+// 执行git命令，返回去掉首尾空白的输出
+func runGit(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	// 只允许本地传输，即使仓库配置了子模块或其他地址也不会访问网络
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ALLOW_PROTOCOL=file")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s 失败: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// 拉取git源的最新提交到本地工作目录，返回工作目录和提交ID
+func fetchGitSource(source *GitSource) (string, string, error) {
+	dir, err := filepath.Abs(filepath.Join(gitSourceWorkDir, source.ID))
+	if err != nil {
+		return "", "", fmt.Errorf("获取工作目录失败: %v", err)
+	}
+
+	repoURL := source.RepoURL
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", "", fmt.Errorf("创建工作目录失败: %v", err)
+		}
+		if _, err := runGit(dir, "init", "-q"); err != nil {
+			return "", "", err
+		}
+		if _, err := runGit(dir, "remote", "add", "origin", repoURL); err != nil {
+			return "", "", err
+		}
+	} else if _, err := runGit(dir, "remote", "set-url", "origin", repoURL); err != nil {
+		return "", "", err
+	}
+
+	ref := source.Branch
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if _, err := runGit(dir, "fetch", "-q", "--prune", "origin", ref); err != nil {
+		return "", "", err
+	}
+	if _, err := runGit(dir, "checkout", "-q", "--force", "--detach", "FETCH_HEAD"); err != nil {
+		return "", "", err
+	}
+	if _, err := runGit(dir, "clean", "-q", "-f", "-d", "-x"); err != nil {
+		return "", "", err
+	}
+
+	commit, err := runGit(dir, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	return dir, commit, nil
+}
+
+// 读取目录下所有JSON/YAML文件中的应用定义，一个YAML文件可以用---分隔多个应用，JSON文件可以是应用数组
+func loadSourceDefinitions(root string) ([]sourceDefinition, []SourceAppStatus, error) {
+	var definitions []sourceDefinition
+	var invalid []SourceAppStatus
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			invalid = append(invalid, SourceAppStatus{File: rel, Action: "invalid", Status: "failed", Message: fmt.Sprintf("读取文件失败: %v", err)})
+			return nil
+		}
+
+		for _, doc := range yamlDocumentSeparator.Split(string(data), -1) {
+			doc = strings.TrimSpace(doc)
+			if doc == "" {
+				continue
+			}
+
+			var apps []Application
+			if strings.HasPrefix(doc, "[") {
+				err = yaml.UnmarshalStrict([]byte(doc), &apps)
+			} else {
+				var app Application
+				err = yaml.UnmarshalStrict([]byte(doc), &app)
+				apps = append(apps, app)
+			}
+			if err != nil {
+				invalid = append(invalid, SourceAppStatus{File: rel, Action: "invalid", Status: "failed", Message: fmt.Sprintf("解析应用定义失败: %v", err)})
+				continue
+			}
+			for i := range apps {
+				definitions = append(definitions, sourceDefinition{file: rel, app: &apps[i]})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取应用定义目录失败: %v", err)
+	}
+	return definitions, invalid, nil
+}
+
+// 填充默认值并校验git源中的应用定义，规则与通过API创建应用一致
+func prepareSourceApplication(source *GitSource, app *Application) error {
+	if app.Name == "" {
+		return fmt.Errorf("应用名称不能为空")
+	}
+	if app.KubeConfigID == "" {
+		app.KubeConfigID = source.KubeConfigID
+	}
+	if app.KubeConfigID == "" {
+		return fmt.Errorf("应用定义和git源均未指定kubeConfigId")
+	}
+	if _, err := GetKubeConfigByIDFromDB(app.KubeConfigID); err != nil {
+		return fmt.Errorf("集群 %s 不存在", app.KubeConfigID)
+	}
+
+	// 由git源维护的字段
+	app.ID = ""
+	app.Status = ""
+	app.SourceID = source.ID
+	app.CreatedAt = time.Time{}
+	app.UpdatedAt = time.Time{}
+	app.DeletedAt = nil
+
+	if app.Namespace == "" {
+		app.Namespace = "default"
+	}
+	if app.ImagePullPolicy == "" {
+		app.ImagePullPolicy = "IfNotPresent"
+	}
+	if app.Replicas <= 0 {
+		app.Replicas = 1
+	}
+	if app.Port <= 0 {
+		app.Port = 8080
+	}
+	if app.ServiceType == "" {
+		app.ServiceType = "ClusterIP"
+	}
+	if app.PortProtocol == "" {
+		app.PortProtocol = "http"
+	}
+	if app.LivenessProbe == nil {
+		app.LivenessProbe = DefaultProbeConfig(app.Port, app.PortProtocol)
+	}
+
+	for _, preset := range app.SchedulingPresets {
+		if !IsValidSchedulingPreset(preset) {
+			return fmt.Errorf("不支持的调度预设: %s", preset)
+		}
+	}
+
+	if err := ValidateServiceConfig(app.ServiceType, app.ServiceConfig); err != nil {
+		return err
+	}
+	if err := ValidateNetworkPolicyConfig(app.NetworkPolicy); err != nil {
+		return err
+	}
+	if err := ValidateDisruptionBudget(app.DisruptionBudget); err != nil {
+		return err
+	}
+	if err := ValidateServiceAccountConfig(app.ServiceAccount); err != nil {
+		return err
+	}
+	if err := ValidateLifecycleConfig(app.Lifecycle); err != nil {
+		return err
+	}
+	return ValidateResourceRequirements(app.Resources)
+}
+
+// 应用在集群中的唯一标识：集群、命名空间和名称
+func sourceAppKey(app *Application) string {
+	return app.KubeConfigID + "/" + app.Namespace + "/" + app.Name
+}
+
+// 比较两个应用的配置是否相同，忽略ID、状态、就绪副本数和时间等由系统维护的字段
+func sourceSpecEqual(a, b *Application) bool {
+	normalize := func(app *Application) string {
+		copied := *app
+		copied.ID = ""
+		copied.Status = ""
+		copied.CreatedAt = time.Time{}
+		copied.UpdatedAt = time.Time{}
+		copied.DeletedAt = nil
+		copied.ReadyReplicas = 0
+		copied.StatusChangedAt = nil
+		data, _ := json.Marshal(copied)
+		return string(data)
+	}
+	return normalize(a) == normalize(b)
+}
+
+// 将单个应用定义同步到应用记录并部署
+func (km *K8sManager) applySourceDefinition(source *GitSource, def sourceDefinition, existing *Application, reason string) SourceAppStatus {
+	app := def.app
+	status := SourceAppStatus{File: def.file, Name: app.Name, Namespace: app.Namespace}
+
+	if existing == nil {
+		status.Action = "created"
+	} else {
+		status.ApplicationID = existing.ID
+		if existing.SourceID != source.ID {
+			status.Action = "invalid"
+			status.Status = "failed"
+			status.Message = "同名应用已存在且不由该git源管理"
+			return status
+		}
+
+		// 配置未变化且运行正常时不重新部署，集群中的漂移由自动同步处理
+		if sourceSpecEqual(existing, app) && existing.Status == "running" {
+			status.Action = "unchanged"
+			status.Status = "synced"
+			return status
+		}
+		status.Action = "updated"
+		if sourceSpecEqual(existing, app) {
+			status.Action = "unchanged"
+		}
+		app.ID = existing.ID
+		app.Status = existing.Status
+		app.CreatedAt = existing.CreatedAt
+	}
+
+	if err := SaveApplicationToDB(app); err != nil {
+		status.Status = "failed"
+		status.Message = err.Error()
+		return status
+	}
+	status.ApplicationID = app.ID
+
+	attempt := km.SyncApplication(app, "git", reason)
+	status.Status = attempt.Result
+	if attempt.Result == "success" {
+		status.Status = "synced"
+	}
+	status.Message = attempt.Message
+	return status
+}
+
+// SyncGitSource 拉取git源的最新提交，按其中的应用定义创建、更新和部署应用，开启prune时删除仓库中已不存在的应用
+func (km *K8sManager) SyncGitSource(source *GitSource) error {
+	gitSourceSyncMu.Lock()
+	defer gitSourceSyncMu.Unlock()
+
+	finish := func(status, message string) error {
+		now := time.Now()
+		source.Status = status
+		source.Message = message
+		source.LastSyncedAt = &now
+		if err := SaveGitSourceToDB(source); err != nil {
+			log.Printf("保存git源同步结果失败: %v", err)
+		}
+		if status == "error" {
+			return fmt.Errorf("%s", message)
+		}
+		return nil
+	}
+
+	source.Status = "syncing"
+	if err := SaveGitSourceToDB(source); err != nil {
+		return err
+	}
+
+	dir, commit, err := fetchGitSource(source)
+	if err != nil {
+		return finish("error", fmt.Sprintf("拉取仓库失败: %v", err))
+	}
+	root := filepath.Join(dir, filepath.FromSlash(source.Path))
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return finish("error", fmt.Sprintf("仓库中不存在目录: %s", source.Path))
+	}
+
+	definitions, statuses, err := loadSourceDefinitions(root)
+	if err != nil {
+		return finish("error", err.Error())
+	}
+	// 存在无法解析的定义时不删除应用，避免文件写错导致应用被误删
+	hasInvalid := len(statuses) > 0
+
+	apps, err := GetApplicationsFromDB()
+	if err != nil {
+		return finish("error", fmt.Sprintf("获取应用列表失败: %v", err))
+	}
+	byKey := make(map[string]*Application)
+	for i := range apps {
+		byKey[sourceAppKey(&apps[i])] = &apps[i]
+	}
+
+	shortCommit := commit
+	if len(shortCommit) > 12 {
+		shortCommit = shortCommit[:12]
+	}
+	reason := fmt.Sprintf("git提交 %s", shortCommit)
+
+	defined := make(map[string]bool)
+	keys := make(map[string]string)
+	for _, def := range definitions {
+		if err := prepareSourceApplication(source, def.app); err != nil {
+			hasInvalid = true
+			statuses = append(statuses, SourceAppStatus{File: def.file, Name: def.app.Name, Namespace: def.app.Namespace, Action: "invalid", Status: "failed", Message: err.Error()})
+			continue
+		}
+		key := sourceAppKey(def.app)
+		if file, ok := keys[key]; ok {
+			hasInvalid = true
+			statuses = append(statuses, SourceAppStatus{File: def.file, Name: def.app.Name, Namespace: def.app.Namespace, Action: "invalid", Status: "failed", Message: fmt.Sprintf("与 %s 中的应用重复", file)})
+			continue
+		}
+		keys[key] = def.file
+
+		status := km.applySourceDefinition(source, def, byKey[key], reason)
+		if status.ApplicationID != "" {
+			defined[status.ApplicationID] = true
+		}
+		statuses = append(statuses, status)
+	}
+
+	for i := range apps {
+		app := &apps[i]
+		if app.SourceID != source.ID || defined[app.ID] {
+			continue
+		}
+
+		status := SourceAppStatus{Name: app.Name, Namespace: app.Namespace, ApplicationID: app.ID, Action: "orphaned", Status: "synced"}
+		if !source.Prune {
+			status.Message = "仓库中已不存在该应用，未开启prune，保留应用"
+		} else if hasInvalid {
+			status.Message = "存在无效的应用定义，本次同步不删除应用"
+		} else {
+			namespace, name := applicationTarget(app)
+			if err := km.DeleteApplicationResources(app.KubeConfigID, namespace, name); err != nil {
+				status.Status = "failed"
+				status.Message = fmt.Sprintf("删除集群资源失败: %v", err)
+			} else if err := SoftDeleteApplicationFromDB(app.ID); err != nil {
+				status.Status = "failed"
+				status.Message = fmt.Sprintf("删除应用记录失败: %v", err)
+			} else {
+				status.Action = "deleted"
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	source.LastCommit = commit
+	source.Applications = statuses
+
+	failed := 0
+	for _, status := range statuses {
+		if status.Status != "synced" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return finish("error", fmt.Sprintf("同步提交 %s 时%d个应用失败", shortCommit, failed))
+	}
+	log.Printf("git源 %s 已同步到提交 %s", source.Name, shortCommit)
+	return finish("synced", fmt.Sprintf("已同步到提交 %s", shortCommit))
+}
+
+// SyncDueGitSources 同步所有到达同步间隔的git源
+func (km *K8sManager) SyncDueGitSources() {
+	sources, err := GetGitSourcesFromDB()
+	if err != nil {
+		log.Printf("获取git源失败: %v", err)
+		return
+	}
+
+	for i := range sources {
+		source := &sources[i]
+		interval := time.Duration(source.IntervalSeconds) * time.Second
+		if source.LastSyncedAt != nil && time.Since(*source.LastSyncedAt) < interval {
+			continue
+		}
+		if err := km.SyncGitSource(source); err != nil {
+			log.Printf("同步git源 %s 失败: %v", source.Name, err)
+		}
+	}
+}
@@ -260,6 +260,14 @@ func UpsertNamespaceCache(namespace *NamespaceCache) error {
 	return nil
 }
 
+// DeleteNamespaceCache 删除命名空间缓存
+func DeleteNamespaceCache(kubeConfigID, name string) error {
+	if _, err := DB.Exec("DELETE FROM namespace_cache WHERE kube_config_id = $1 AND name = $2", kubeConfigID, name); err != nil {
+		return fmt.Errorf("删除命名空间缓存失败: %v", err)
+	}
+	return nil
+}
+
 // 添加同步状态变量和锁
 var (
 	namespaceSyncLock    sync.Mutex
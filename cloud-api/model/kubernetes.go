@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +35,10 @@ type K8sManager struct {
 	Clients   map[string]*kubernetes.Clientset
 	Configs   map[string]*KubeConfig
 	sync.RWMutex  // 嵌入 RWMutex
+
+	// 每个集群的共享informer，使用单独的锁，避免与客户端缓存的锁互相等待
+	informers  map[string]*clusterInformers
+	informerMu sync.Mutex
 }
 
 // GetK8sManager 获取单例实例
@@ -45,8 +50,9 @@ var (
 func GetK8sManager() *K8sManager {
 	once.Do(func() {
 		instance = &K8sManager{
-			Clients: make(map[string]*kubernetes.Clientset),
-			Configs: make(map[string]*KubeConfig),
+			Clients:   make(map[string]*kubernetes.Clientset),
+			Configs:   make(map[string]*KubeConfig),
+			informers: make(map[string]*clusterInformers),
 		}
 	})
 	return instance
@@ -88,20 +94,15 @@ func (km *K8sManager) AddClient(id string, configContent []byte) error {
 }
 
 // GetClient 获取指定ID的kubernetes客户端
+// 多个后台任务会并发调用，创建客户端时不持有锁，写入缓存时持有写锁
 func (m *K8sManager) GetClient(id string) (kubernetes.Interface, error) {
-	m.RLock()
-	defer m.RUnlock()
-
 	// 检查客户端是否已存在
-	if client, ok := m.Clients[id]; ok {
-		// 验证客户端是否有效
-		_, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{Limit: 1})
-		if err == nil {
-			return client, nil // 客户端有效，直接返回
-		}
-		log.Printf("缓存的Kubernetes客户端无效，将重新创建: %v", err)
-		// 客户端无效，从缓存中删除，尝试重新创建
-		delete(m.Clients, id)
+	// 客户端创建时已测试过连接，失效后可通过刷新缓存重新创建
+	m.RLock()
+	client, ok := m.Clients[id]
+	m.RUnlock()
+	if ok {
+		return client, nil
 	}
 
 	// 获取kubeconfig
@@ -126,22 +127,27 @@ func (m *K8sManager) GetClient(id string) (kubernetes.Interface, error) {
 	restConfig.Timeout = 10 * time.Second
 
 	// 创建kubernetes客户端
-	client, err := kubernetes.NewForConfig(restConfig)
+	clientset, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return nil, fmt.Errorf("创建kubernetes客户端失败: %v", err)
 	}
 
 	// 测试连接是否有效
-	_, err = client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{Limit: 1})
+	_, err = clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{Limit: 1})
 	if err != nil {
 		return nil, fmt.Errorf("Kubernetes集群连接测试失败: %v", err)
 	}
 
-	// 保存客户端以供后续使用
-	m.Clients[id] = client
+	// 保存客户端以供后续使用，其他调用者已先创建时使用已缓存的客户端
+	m.Lock()
+	defer m.Unlock()
+	if existing, ok := m.Clients[id]; ok {
+		return existing, nil
+	}
+	m.Clients[id] = clientset
 	log.Printf("成功创建Kubernetes客户端，已缓存 (ID: %s)", id)
 
-	return client, nil
+	return clientset, nil
 }
 
 // AddKubeConfig 添加新的KubeConfig
@@ -349,18 +355,8 @@ func (m *K8sManager) ValidateKubeConfig(id string) error {
 
 // GetNamespaces 获取所有命名空间
 func (km *K8sManager) GetNamespaces(id string) ([]map[string]interface{}, error) {
-	// 添加上下文超时控制
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	
-	// 使用带超时的上下文获取客户端
-	clientset, err := km.GetClient(id)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
-	// 使用超时上下文获取命名空间
-	namespaces, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	// 从informer缓存获取命名空间
+	namespaces, err := km.ListCachedNamespaces(id)
 	if err != nil {
 		log.Printf("获取命名空间列表失败 (ID: %s): %v", id, err)
 		// 如果失败，返回一个包含默认命名空间的列表而不是错误
@@ -373,7 +369,7 @@ func (km *K8sManager) GetNamespaces(id string) ([]map[string]interface{}, error)
 	}
 	
 	// 如果获取成功但列表为空，也返回默认命名空间
-	if len(namespaces.Items) == 0 {
+	if len(namespaces) == 0 {
 		log.Printf("获取到空命名空间列表 (ID: %s)，返回默认命名空间", id)
 		defaultNamespaces := []map[string]interface{}{
 			{"name": "default", "status": "Active"},
@@ -383,8 +379,8 @@ func (km *K8sManager) GetNamespaces(id string) ([]map[string]interface{}, error)
 		return defaultNamespaces, nil
 	}
 	
-	result := make([]map[string]interface{}, 0, len(namespaces.Items))
-	for _, ns := range namespaces.Items {
+	result := make([]map[string]interface{}, 0, len(namespaces))
+	for _, ns := range namespaces {
 		result = append(result, map[string]interface{}{
 			"name":      ns.Name,
 			"status":    string(ns.Status.Phase),
@@ -399,34 +395,35 @@ func (km *K8sManager) GetNamespaces(id string) ([]map[string]interface{}, error)
 
 // GetPods 获取指定命名空间的Pod列表
 func (km *K8sManager) GetPods(kubeConfigID string, namespace string) ([]map[string]interface{}, error) {
-	km.RLock()
-	defer km.RUnlock()
-	
 	// 参数验证
 	if kubeConfigID == "" {
 		return nil, fmt.Errorf("kubeConfigID不能为空")
 	}
 	
-	// 获取k8s客户端
-	client, err := km.GetClient(kubeConfigID)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
 	// 如果未指定命名空间，使用默认命名空间
 	if namespace == "" {
 		namespace = "default"
 	}
 	
-	// 获取Pod列表
-	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	// 从informer缓存获取Pod列表
+	pods, err := km.ListCachedPods(kubeConfigID, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("获取Pod列表失败: %v", err)
 	}
 	
+	// CPU和内存用量，集群未安装metrics-server时不返回
+	usageByName := make(map[string]PodUsage)
+	if usages, err := km.GetPodMetrics(kubeConfigID, namespace, ""); err == nil {
+		for _, usage := range usages {
+			usageByName[usage.Name] = usage
+		}
+	} else if err != ErrMetricsUnavailable {
+		log.Printf("获取Pod资源用量失败 (ID: %s, namespace: %s): %v", kubeConfigID, namespace, err)
+	}
+	
 	// 转换为友好的输出格式
 	var result []map[string]interface{}
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		// 计算Pod状态
 		status := string(pod.Status.Phase)
 		if pod.DeletionTimestamp != nil {
@@ -478,6 +475,10 @@ func (km *K8sManager) GetPods(kubeConfigID string, namespace string) ([]map[stri
 			"labels":     pod.Labels,
 			"createdAt":  pod.CreationTimestamp.Format("2006-01-02 15:04:05"),
 		}
+		if usage, ok := usageByName[pod.Name]; ok {
+			podInfo["cpuMillicores"] = usage.CPUMillicores
+			podInfo["memoryBytes"] = usage.MemoryBytes
+		}
 		
 		result = append(result, podInfo)
 	}
@@ -487,18 +488,13 @@ func (km *K8sManager) GetPods(kubeConfigID string, namespace string) ([]map[stri
 
 // GetDeployments 获取指定命名空间的Deployment列表
 func (km *K8sManager) GetDeployments(id string, namespace string) ([]map[string]interface{}, error) {
-	clientset, err := km.GetClient(id)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
-	deployments, err := clientset.AppsV1().Deployments(namespace).List(context.TODO(), metav1.ListOptions{})
+	deployments, err := km.ListCachedDeployments(id, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("获取Deployment列表失败: %v", err)
 	}
 	
-	result := make([]map[string]interface{}, 0, len(deployments.Items))
-	for _, deployment := range deployments.Items {
+	result := make([]map[string]interface{}, 0, len(deployments))
+	for _, deployment := range deployments {
 		result = append(result, map[string]interface{}{
 			"name":              deployment.Name,
 			"namespace":         deployment.Namespace,
@@ -516,18 +512,19 @@ func (km *K8sManager) GetDeployments(id string, namespace string) ([]map[string]
 
 // GetServices 获取指定命名空间的Service列表
 func (km *K8sManager) GetServices(id string, namespace string) ([]map[string]interface{}, error) {
-	clientset, err := km.GetClient(id)
+	services, err := km.ListCachedServices(id, namespace)
 	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+		return nil, fmt.Errorf("获取Service列表失败: %v", err)
 	}
 	
-	services, err := clientset.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	// 获取端点就绪情况
+	endpointsByName, err := km.ListCachedEndpoints(id, namespace)
 	if err != nil {
-		return nil, fmt.Errorf("获取Service列表失败: %v", err)
+		log.Printf("获取Endpoints列表失败: %v", err)
 	}
 	
-	result := make([]map[string]interface{}, 0, len(services.Items))
-	for _, service := range services.Items {
+	result := make([]map[string]interface{}, 0, len(services))
+	for _, service := range services {
 		// 提取端口信息
 		ports := make([]map[string]interface{}, 0, len(service.Spec.Ports))
 		for _, port := range service.Spec.Ports {
@@ -556,6 +553,7 @@ func (km *K8sManager) GetServices(id string, namespace string) ([]map[string]int
 			"clusterIP":  service.Spec.ClusterIP,
 			"externalIP": externalIP,
 			"ports":      ports,
+			"endpoints":  SummarizeEndpoints(endpointsByName[service.Namespace+"/"+service.Name]),
 			"age":        time.Since(service.CreationTimestamp.Time).Round(time.Second).String(),
 			"createdAt":  service.CreationTimestamp.Time,
 		})
@@ -566,18 +564,13 @@ func (km *K8sManager) GetServices(id string, namespace string) ([]map[string]int
 
 // GetStatefulSets 获取指定命名空间的StatefulSet列表
 func (km *K8sManager) GetStatefulSets(id string, namespace string) ([]map[string]interface{}, error) {
-	clientset, err := km.GetClient(id)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
-	statefulsets, err := clientset.AppsV1().StatefulSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	statefulsets, err := km.ListCachedStatefulSets(id, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("获取StatefulSet列表失败: %v", err)
 	}
 	
-	result := make([]map[string]interface{}, 0, len(statefulsets.Items))
-	for _, statefulset := range statefulsets.Items {
+	result := make([]map[string]interface{}, 0, len(statefulsets))
+	for _, statefulset := range statefulsets {
 		result = append(result, map[string]interface{}{
 			"name":       statefulset.Name,
 			"namespace":  statefulset.Namespace,
@@ -593,18 +586,13 @@ func (km *K8sManager) GetStatefulSets(id string, namespace string) ([]map[string
 
 // GetDaemonSets 获取指定命名空间的DaemonSet列表
 func (km *K8sManager) GetDaemonSets(id string, namespace string) ([]map[string]interface{}, error) {
-	clientset, err := km.GetClient(id)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
-	daemonsets, err := clientset.AppsV1().DaemonSets(namespace).List(context.TODO(), metav1.ListOptions{})
+	daemonsets, err := km.ListCachedDaemonSets(id, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("获取DaemonSet列表失败: %v", err)
 	}
 	
-	result := make([]map[string]interface{}, 0, len(daemonsets.Items))
-	for _, daemonset := range daemonsets.Items {
+	result := make([]map[string]interface{}, 0, len(daemonsets))
+	for _, daemonset := range daemonsets {
 		result = append(result, map[string]interface{}{
 			"name":       daemonset.Name,
 			"namespace":  daemonset.Namespace,
@@ -621,18 +609,13 @@ func (km *K8sManager) GetDaemonSets(id string, namespace string) ([]map[string]i
 
 // GetJobs 获取指定命名空间的Job列表
 func (km *K8sManager) GetJobs(id string, namespace string) ([]map[string]interface{}, error) {
-	clientset, err := km.GetClient(id)
-	if err != nil {
-		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
-	}
-	
-	jobs, err := clientset.BatchV1().Jobs(namespace).List(context.TODO(), metav1.ListOptions{})
+	jobs, err := km.ListCachedJobs(id, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("获取Job列表失败: %v", err)
 	}
 	
-	result := make([]map[string]interface{}, 0, len(jobs.Items))
-	for _, job := range jobs.Items {
+	result := make([]map[string]interface{}, 0, len(jobs))
+	for _, job := range jobs {
 		completions := int32(0)
 		if job.Spec.Completions != nil {
 			completions = *job.Spec.Completions
@@ -653,9 +636,6 @@ func (km *K8sManager) GetJobs(id string, namespace string) ([]map[string]interfa
 
 // GetPodLogs 获取Pod的日志
 func (km *K8sManager) GetPodLogs(kubeConfigID string, namespace string, podName string, containerName string, tailLines int) (string, error) {
-	km.RLock()
-	defer km.RUnlock()
-	
 	// 参数验证
 	if kubeConfigID == "" || podName == "" {
 		return "", fmt.Errorf("kubeConfigID和podName不能为空")
@@ -836,9 +816,6 @@ func (km *K8sManager) DeleteApplication(id string) error {
 
 // DeleteApplicationResources 删除应用程序相关的所有Kubernetes资源
 func (km *K8sManager) DeleteApplicationResources(kubeConfigId, namespace, name string) error {
-	km.Lock()
-	defer km.Unlock()
-	
 	if kubeConfigId == "" {
 		return fmt.Errorf("kubeConfigId不能为空")
 	}
@@ -931,6 +908,23 @@ func (km *K8sManager) DeleteApplicationResources(kubeConfigId, namespace, name s
 		// 不计入错误，因为NetworkPolicy是可选的
 	}
 	
+	// 删除PodDisruptionBudget，与删除应用接口使用同一实现
+	if err := km.DeletePodDisruptionBudget(kubeConfigId, namespace, name); err != nil {
+		log.Printf("删除PodDisruptionBudget失败: %v", err)
+		allErrors = append(allErrors, err)
+	}
+	
+	// 删除ServiceAccount、Role和RoleBinding（仅删除由本系统创建的）
+	log.Printf("删除ServiceAccount和RBAC: %s/%s", namespace, name)
+	if err := deleteApplicationRBAC(client, namespace, name); err != nil {
+		log.Printf("删除RBAC失败: %v", err)
+		allErrors = append(allErrors, err)
+	}
+	if err := deleteManagedServiceAccounts(client, namespace, name, ""); err != nil {
+		log.Printf("删除ServiceAccount失败: %v", err)
+		allErrors = append(allErrors, err)
+	}
+	
 	// 尝试删除Ingress，注意API版本不同
 	log.Printf("删除Ingress: %s/%s", namespace, name)
 	err = client.NetworkingV1().Ingresses(namespace).Delete(context.TODO(), name, deleteOptions)
@@ -1044,6 +1038,21 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		image = "nginx:latest" // 默认镜像
 	}
 	
+	// 镜像来自已注册的私有仓库时，自动创建或刷新镜像拉取Secret
+	var imagePullSecrets []corev1.LocalObjectReference
+	registry, err := FindRegistryForImage(image)
+	if err != nil {
+		log.Printf("查找镜像仓库失败: %v", err)
+	} else if registry != nil {
+		secretName, err := ensureRegistryPullSecret(client, namespace, registry)
+		if err != nil {
+			log.Printf("警告: 为镜像仓库 %s 配置镜像拉取Secret失败: %v", registry.Name, err)
+		} else {
+			imagePullSecrets = append(imagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+			log.Printf("使用镜像拉取Secret: %s/%s", namespace, secretName)
+		}
+	}
+	
 	// 设置应用名称
 	appName := app.Name
 	if appName == "" {
@@ -1080,16 +1089,7 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 				Name:          fmt.Sprintf("tcp-%d", containerPort),
 			},
 		},
-		Resources: corev1.ResourceRequirements{
-			Requests: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("100m"),
-				corev1.ResourceMemory: resource.MustParse("128Mi"),
-			},
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse("500m"),
-				corev1.ResourceMemory: resource.MustParse("512Mi"),
-			},
-		},
+		Resources:       convertResourceRequirements(app.Resources),
 		ImagePullPolicy: pullPolicy,
 	}
 	
@@ -1148,6 +1148,8 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 	// 设置就绪探针
 	if app.ReadinessProbe != nil {
 		container.ReadinessProbe = convertProbeConfig(app.ReadinessProbe)
+		// 就绪探针不允许设置terminationGracePeriodSeconds
+		container.ReadinessProbe.TerminationGracePeriodSeconds = nil
 	}
 	
 	// 设置启动探针
@@ -1155,7 +1157,7 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		// 验证探针配置是否完整
 		probe := convertProbeConfig(app.StartupProbe)
 		// 只有当探针有至少一个handler时才设置
-		if probe.HTTPGet != nil || probe.TCPSocket != nil || probe.Exec != nil {
+		if probe.HTTPGet != nil || probe.TCPSocket != nil || probe.Exec != nil || probe.GRPC != nil {
 			container.StartupProbe = probe
 		}
 	}
@@ -1189,6 +1191,17 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		if lifecycleConfigured {
 			container.Lifecycle = lifecycle
 		}
+		
+		// preStop等待时间超过优雅终止时间时，应用将直接被SIGKILL终止
+		if app.Lifecycle.PreStop != nil && app.Lifecycle.PreStop.SleepSeconds > 0 {
+			gracePeriod := int64(defaultTerminationGracePeriodSeconds)
+			if app.TerminationGracePeriodSeconds != nil {
+				gracePeriod = *app.TerminationGracePeriodSeconds
+			}
+			if int64(app.Lifecycle.PreStop.SleepSeconds) >= gracePeriod {
+				log.Printf("警告: 应用 %s 的preStop等待时间(%ds)不小于优雅终止时间(%ds)", appName, app.Lifecycle.PreStop.SleepSeconds, gracePeriod)
+			}
+		}
 	}
 	
 	// 设置安全上下文
@@ -1213,6 +1226,12 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		if app.SecurityContext.AllowPrivilegeEscalation != nil {
 			securityContext.AllowPrivilegeEscalation = app.SecurityContext.AllowPrivilegeEscalation
 		}
+		if app.SecurityContext.Capabilities != nil {
+			securityContext.Capabilities = convertCapabilities(app.SecurityContext.Capabilities)
+		}
+		if app.SecurityContext.SeccompProfile != nil {
+			securityContext.SeccompProfile = convertSeccompProfile(app.SecurityContext.SeccompProfile)
+		}
 		
 		container.SecurityContext = securityContext
 	}
@@ -1300,28 +1319,52 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 	var podAntiAffinityTerms []corev1.PodAffinityTerm
 	var nodeAffinityPreferred []corev1.PreferredSchedulingTerm
 	var nodeAffinityRequired *corev1.NodeSelector
+	var podAffinityPreferred []corev1.WeightedPodAffinityTerm
+	var podAntiAffinityPreferred []corev1.WeightedPodAffinityTerm
 	
 	if app.Affinity != nil {
 		// 设置节点亲和性
-		if app.Affinity.NodeAffinity != nil && app.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
-			nodeAffinityRequired = convertNodeSelector(app.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+		if app.Affinity.NodeAffinity != nil {
+			if app.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+				nodeAffinityRequired = convertNodeSelector(app.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+			}
+			nodeAffinityPreferred = convertPreferredSchedulingTerms(app.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
 		}
 		
 		// 设置Pod亲和性
-		if app.Affinity.PodAffinity != nil && len(app.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+		if app.Affinity.PodAffinity != nil {
 			for _, term := range app.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
 				podAffinityTerms = append(podAffinityTerms, convertPodAffinityTerm(term))
 			}
+			podAffinityPreferred = convertWeightedPodAffinityTerms(app.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
 		}
 		
 		// 设置Pod反亲和性
-		if app.Affinity.PodAntiAffinity != nil && len(app.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0 {
+		if app.Affinity.PodAntiAffinity != nil {
 			for _, term := range app.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
 				podAntiAffinityTerms = append(podAntiAffinityTerms, convertPodAffinityTerm(term))
 			}
+			podAntiAffinityPreferred = convertWeightedPodAffinityTerms(app.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
 		}
 	}
 	
+	// 拓扑分布约束：显式配置 + 调度预设展开的规则
+	topologySpreadConstraints := convertTopologySpreadConstraints(app.TopologySpreadConstraints, appName)
+	if len(app.SchedulingPresets) > 0 {
+		presetRules := expandSchedulingPresets(app.SchedulingPresets, appName)
+		topologySpreadConstraints = append(topologySpreadConstraints, presetRules.TopologySpreadConstraints...)
+		podAntiAffinityTerms = append(podAntiAffinityTerms, presetRules.PodAntiAffinityRequired...)
+		podAntiAffinityPreferred = append(podAntiAffinityPreferred, presetRules.PodAntiAffinityPreferred...)
+		log.Printf("应用调度预设: %v", app.SchedulingPresets)
+	}
+	
+	// 创建ServiceAccount和RBAC
+	serviceAccountName, err := applyApplicationServiceAccount(client, app, namespace, appName)
+	if err != nil {
+		log.Printf("配置ServiceAccount失败: %v", err)
+		return err
+	}
+	
 	// 创建或更新 Deployment
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1352,8 +1395,12 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 					},
 				},
 				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{container},
-					Volumes:    volumes,
+					Containers:                    []corev1.Container{container},
+					Volumes:                       volumes,
+					TerminationGracePeriodSeconds: app.TerminationGracePeriodSeconds,
+					SecurityContext:               convertPodSecurityContext(app.PodSecurityContext),
+					ImagePullSecrets:              imagePullSecrets,
+					ServiceAccountName:            serviceAccountName,
 				},
 			},
 		},
@@ -1431,7 +1478,8 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 	}
 	
 	// 设置亲和性
-	if nodeAffinityRequired != nil || len(nodeAffinityPreferred) > 0 || len(podAffinityTerms) > 0 || len(podAntiAffinityTerms) > 0 {
+	if nodeAffinityRequired != nil || len(nodeAffinityPreferred) > 0 || len(podAffinityTerms) > 0 || len(podAntiAffinityTerms) > 0 ||
+		len(podAffinityPreferred) > 0 || len(podAntiAffinityPreferred) > 0 {
 		affinity := &corev1.Affinity{}
 		
 		// 节点亲和性
@@ -1443,22 +1491,40 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		}
 		
 		// Pod亲和性
-		if len(podAffinityTerms) > 0 {
+		if len(podAffinityTerms) > 0 || len(podAffinityPreferred) > 0 {
 			affinity.PodAffinity = &corev1.PodAffinity{
 				RequiredDuringSchedulingIgnoredDuringExecution: podAffinityTerms,
+				PreferredDuringSchedulingIgnoredDuringExecution: podAffinityPreferred,
 			}
 		}
 		
 		// Pod反亲和性
-		if len(podAntiAffinityTerms) > 0 {
+		if len(podAntiAffinityTerms) > 0 || len(podAntiAffinityPreferred) > 0 {
 			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
 				RequiredDuringSchedulingIgnoredDuringExecution: podAntiAffinityTerms,
+				PreferredDuringSchedulingIgnoredDuringExecution: podAntiAffinityPreferred,
 			}
 		}
 		
 		deployment.Spec.Template.Spec.Affinity = affinity
 	}
 	
+	// 设置拓扑分布约束
+	if len(topologySpreadConstraints) > 0 {
+		deployment.Spec.Template.Spec.TopologySpreadConstraints = topologySpreadConstraints
+	}
+	
+	// 是否自动挂载ServiceAccount令牌（Pod级别设置优先于ServiceAccount）
+	if app.ServiceAccount != nil && app.ServiceAccount.AutomountServiceAccountToken != nil {
+		automount := *app.ServiceAccount.AutomountServiceAccountToken
+		deployment.Spec.Template.Spec.AutomountServiceAccountToken = &automount
+	}
+	
+	// 设置优先级类
+	if app.PriorityClassName != "" {
+		deployment.Spec.Template.Spec.PriorityClassName = app.PriorityClassName
+	}
+	
 	log.Printf("创建Deployment: %s/%s", namespace, appName)
 	
 	// 尝试创建Deployment
@@ -1481,62 +1547,121 @@ func (km *K8sManager) DeployApplication(app *Application) error {
 		log.Printf("创建Deployment成功: %s/%s", namespace, appName)
 	}
 	
-	// 创建Service
-	serviceType := corev1.ServiceTypeClusterIP
-	if app.ServiceType == "NodePort" {
-		serviceType = corev1.ServiceTypeNodePort
-	} else if app.ServiceType == "LoadBalancer" {
-		serviceType = corev1.ServiceTypeLoadBalancer
+	// 保存期望清单用于漂移检测
+	deployment.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+	if err := recordDesiredManifest(app, "deployments", namespace, appName, deployment); err != nil {
+		log.Printf("保存Deployment期望清单失败: %v", err)
 	}
 	
-	service := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      appName,
-			Namespace: namespace,
-			Labels: map[string]string{
-				"app":        appName,
-				"managed-by": "cloud-deployment-api",
-				"app-id":     app.ID,
-			},
-		},
-		Spec: corev1.ServiceSpec{
-			Type: serviceType,
-			Ports: []corev1.ServicePort{
-				{
-					Port:       containerPort,
-					TargetPort: intstr.FromInt(int(containerPort)),
-				},
-			},
-			Selector: map[string]string{
-				"app": appName,
-			},
-		},
+	// 创建或更新Service
+	service := buildApplicationService(app, namespace, appName, containerPort)
+	if err := applyApplicationService(client, service); err != nil {
+		return err
+	}
+	service.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+	if err := recordDesiredManifest(app, "services", namespace, appName, service); err != nil {
+		log.Printf("保存Service期望清单失败: %v", err)
 	}
 	
-	// 尝试创建Service
-	log.Printf("创建Service: %s/%s", namespace, appName)
-	_, err = client.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{})
-	if err != nil {
-		if k8serrors.IsAlreadyExists(err) {
-			// 如果已存在，则更新
-			log.Printf("Service已存在，尝试更新: %s/%s", namespace, appName)
-			_, err = client.CoreV1().Services(namespace).Update(context.TODO(), service, metav1.UpdateOptions{})
-			if err != nil {
-				log.Printf("更新Service失败: %v", err)
-				return fmt.Errorf("更新Service失败: %v", err)
-			}
-			log.Printf("更新Service成功: %s/%s", namespace, appName)
-		} else {
-			log.Printf("创建Service失败: %v", err)
-			return fmt.Errorf("创建Service失败: %v", err)
-		}
-	} else {
-		log.Printf("创建Service成功: %s/%s", namespace, appName)
+	// 根据声明的依赖关系创建NetworkPolicy
+	if err := applyApplicationNetworkPolicy(client, app, namespace, appName); err != nil {
+		log.Printf("配置NetworkPolicy失败: %v", err)
+		return err
+	}
+	
+	// 创建PodDisruptionBudget
+	if err := applyApplicationPDB(client, app, namespace, appName); err != nil {
+		log.Printf("配置PodDisruptionBudget失败: %v", err)
+		return err
 	}
 	
 	return nil
 }
 
+// 容器默认资源请求和限制
+const (
+	defaultCPURequest    = "100m"
+	defaultMemoryRequest = "128Mi"
+	defaultCPULimit      = "500m"
+	defaultMemoryLimit   = "512Mi"
+)
+
+// ValidateResourceRequirements 校验资源数量格式，且请求不能大于限制
+func ValidateResourceRequirements(resources *ResourceRequirements) error {
+	if resources == nil {
+		return nil
+	}
+
+	quantities := map[string]string{
+		"requests.cpu":    resources.Requests.CPU,
+		"requests.memory": resources.Requests.Memory,
+		"limits.cpu":      resources.Limits.CPU,
+		"limits.memory":   resources.Limits.Memory,
+	}
+	parsed := make(map[string]resource.Quantity)
+	for field, value := range quantities {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("无效的资源数量 %s: %s", field, value)
+		}
+		parsed[field] = q
+	}
+
+	for _, kind := range []string{"cpu", "memory"} {
+		request, hasRequest := parsed["requests."+kind]
+		limit, hasLimit := parsed["limits."+kind]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s请求不能大于限制", kind)
+		}
+	}
+	return nil
+}
+
+// 解析资源数量，为空或无效时使用默认值
+func parseQuantityOrDefault(value string, defaultValue string) resource.Quantity {
+	if value != "" {
+		q, err := resource.ParseQuantity(value)
+		if err == nil {
+			return q
+		}
+		log.Printf("无效的资源数量 %s，使用默认值 %s", value, defaultValue)
+	}
+	return resource.MustParse(defaultValue)
+}
+
+// 将资源配置转换为Kubernetes ResourceRequirements，未设置的项使用默认值
+func convertResourceRequirements(resources *ResourceRequirements) corev1.ResourceRequirements {
+	if resources == nil {
+		resources = &ResourceRequirements{}
+	}
+
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    parseQuantityOrDefault(resources.Requests.CPU, defaultCPURequest),
+		corev1.ResourceMemory: parseQuantityOrDefault(resources.Requests.Memory, defaultMemoryRequest),
+	}
+	limits := corev1.ResourceList{
+		corev1.ResourceCPU:    parseQuantityOrDefault(resources.Limits.CPU, defaultCPULimit),
+		corev1.ResourceMemory: parseQuantityOrDefault(resources.Limits.Memory, defaultMemoryLimit),
+	}
+
+	// 只设置了请求且大于默认限制时，限制与请求保持一致
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request := requests[name]
+		limit := limits[name]
+		if request.Cmp(limit) > 0 {
+			limits[name] = request.DeepCopy()
+		}
+	}
+
+	return corev1.ResourceRequirements{
+		Requests: requests,
+		Limits:   limits,
+	}
+}
+
 // 将ProbeConfig转换为Kubernetes Probe
 func convertProbeConfig(probeConfig *ProbeConfig) *corev1.Probe {
 	probe := &corev1.Probe{
@@ -1561,23 +1686,46 @@ func convertProbeConfig(probeConfig *ProbeConfig) *corev1.Probe {
 		probe.SuccessThreshold = 1
 	}
 	
+	// 探针失败触发重启时的优雅终止时间
+	if probeConfig.TerminationGracePeriodSeconds != nil {
+		probe.TerminationGracePeriodSeconds = probeConfig.TerminationGracePeriodSeconds
+	}
+	
 	switch probeConfig.ProbeType {
 	case "http":
+		// 设置HTTP协议，默认为HTTP
+		scheme := corev1.URISchemeHTTP
+		if strings.EqualFold(probeConfig.Scheme, "HTTPS") {
+			scheme = corev1.URISchemeHTTPS
+		}
+		
 		probe.HTTPGet = &corev1.HTTPGetAction{
-			Path:   probeConfig.Path,
-			Port:   intstr.FromInt(probeConfig.Port),
-			Scheme: corev1.URISchemeHTTP,
+			Path:        probeConfig.Path,
+			Port:        intstr.FromInt(probeConfig.Port),
+			Host:        probeConfig.Host,
+			Scheme:      scheme,
+			HTTPHeaders: convertHTTPHeaders(probeConfig.HTTPHeaders),
 		}
 	case "tcp":
 		probe.TCPSocket = &corev1.TCPSocketAction{
 			Port: intstr.FromInt(probeConfig.Port),
 		}
+	case "grpc":
+		probe.GRPC = &corev1.GRPCAction{
+			Port: int32(probeConfig.Port),
+		}
+		if probeConfig.GRPCService != "" {
+			service := probeConfig.GRPCService
+			probe.GRPC.Service = &service
+		}
 	case "command":
 		// 解析命令字符串
 		var cmdArray []string
 		
-		// 尝试解析JSON数组
-		if strings.HasPrefix(probeConfig.Command, "[") && strings.HasSuffix(probeConfig.Command, "]") {
+		// 优先使用命令参数数组
+		if len(probeConfig.ExecCommand) > 0 {
+			cmdArray = probeConfig.ExecCommand
+		} else if strings.HasPrefix(probeConfig.Command, "[") && strings.HasSuffix(probeConfig.Command, "]") {
 			err := json.Unmarshal([]byte(probeConfig.Command), &cmdArray)
 			if err != nil {
 				// JSON解析失败，尝试以逗号分隔
@@ -1612,6 +1760,26 @@ func convertProbeConfig(probeConfig *ProbeConfig) *corev1.Probe {
 	return probe
 }
 
+// 将HTTPHeader转换为Kubernetes HTTPHeader
+func convertHTTPHeaders(headers []HTTPHeader) []corev1.HTTPHeader {
+	if len(headers) == 0 {
+		return nil
+	}
+	
+	k8sHeaders := make([]corev1.HTTPHeader, 0, len(headers))
+	for _, header := range headers {
+		if header.Name == "" {
+			continue
+		}
+		k8sHeaders = append(k8sHeaders, corev1.HTTPHeader{
+			Name:  header.Name,
+			Value: header.Value,
+		})
+	}
+	
+	return k8sHeaders
+}
+
 // 将Handler转换为Kubernetes LifecycleHandler
 func convertLifecycleHandler(handler *Handler) *corev1.LifecycleHandler {
 	lifecycleHandler := &corev1.LifecycleHandler{}
@@ -1620,17 +1788,175 @@ func convertLifecycleHandler(handler *Handler) *corev1.LifecycleHandler {
 		lifecycleHandler.Exec = &corev1.ExecAction{
 			Command: handler.Command,
 		}
+	} else if handler.SleepSeconds > 0 {
+		// 当前客户端版本不支持原生sleep动作，使用exec执行sleep命令
+		lifecycleHandler.Exec = &corev1.ExecAction{
+			Command: sleepHookCommand(handler.SleepSeconds),
+		}
 	} else if handler.Path != "" && handler.Port != 0 {
+		// 设置HTTP协议，默认为HTTP
+		scheme := corev1.URISchemeHTTP
+		if strings.EqualFold(handler.Scheme, "HTTPS") {
+			scheme = corev1.URISchemeHTTPS
+		}
+		
 		lifecycleHandler.HTTPGet = &corev1.HTTPGetAction{
-			Path:   handler.Path,
-			Port:   intstr.FromInt(handler.Port),
-			Scheme: corev1.URISchemeHTTP,
+			Path:        handler.Path,
+			Port:        intstr.FromInt(handler.Port),
+			Host:        handler.Host,
+			Scheme:      scheme,
+			HTTPHeaders: convertHTTPHeaders(handler.HTTPHeaders),
 		}
 	}
 	
 	return lifecycleHandler
 }
 
+// 由sleepSeconds生成的exec命令，依赖镜像中的/bin/sh
+func sleepHookCommand(seconds int) []string {
+	return []string{"/bin/sh", "-c", fmt.Sprintf("sleep %d", seconds)}
+}
+
+// 识别由sleepSeconds生成的钩子，只接受与sleepHookCommand完全一致的命令，不解析用户自定义的命令
+func sleepHookSeconds(command []string) (int, bool) {
+	if len(command) != 3 || !strings.HasPrefix(command[2], "sleep ") {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(strings.TrimPrefix(command[2], "sleep "))
+	if err != nil || seconds <= 0 {
+		return 0, false
+	}
+	for i, arg := range sleepHookCommand(seconds) {
+		if command[i] != arg {
+			return 0, false
+		}
+	}
+	return seconds, true
+}
+
+// defaultTerminationGracePeriodSeconds Kubernetes默认的Pod优雅终止时间
+const defaultTerminationGracePeriodSeconds = 30
+
+// buildShutdownTimeline 根据Pod模板计算应用停止时的有效时间线
+func buildShutdownTimeline(podSpec corev1.PodSpec) map[string]interface{} {
+	gracePeriod := int64(defaultTerminationGracePeriodSeconds)
+	if podSpec.TerminationGracePeriodSeconds != nil {
+		gracePeriod = *podSpec.TerminationGracePeriodSeconds
+	}
+	
+	preStop := "none"
+	preStopSeconds := int64(0)
+	if len(podSpec.Containers) > 0 && podSpec.Containers[0].Lifecycle != nil && podSpec.Containers[0].Lifecycle.PreStop != nil {
+		hook := podSpec.Containers[0].Lifecycle.PreStop
+		if hook.Exec != nil {
+			preStop = "exec: " + strings.Join(hook.Exec.Command, " ")
+			if sleepSeconds, ok := sleepHookSeconds(hook.Exec.Command); ok {
+				preStop = fmt.Sprintf("sleep %ds", sleepSeconds)
+				preStopSeconds = int64(sleepSeconds)
+			}
+		} else if hook.HTTPGet != nil {
+			preStop = fmt.Sprintf("httpGet: %s://:%s%s", strings.ToLower(string(hook.HTTPGet.Scheme)), hook.HTTPGet.Port.String(), hook.HTTPGet.Path)
+		}
+	}
+	
+	// SIGTERM在preStop钩子执行完成后发送，但不会晚于优雅终止时间
+	sigtermAt := preStopSeconds
+	if sigtermAt > gracePeriod {
+		sigtermAt = gracePeriod
+	}
+	
+	steps := []map[string]interface{}{
+		{"atSeconds": 0, "event": "Pod进入Terminating状态，从Service端点中移除"},
+	}
+	if preStop != "none" {
+		steps = append(steps, map[string]interface{}{"atSeconds": 0, "event": "执行preStop钩子: " + preStop})
+	}
+	steps = append(steps,
+		map[string]interface{}{"atSeconds": sigtermAt, "event": "向容器发送SIGTERM"},
+		map[string]interface{}{"atSeconds": gracePeriod, "event": "容器仍未退出时发送SIGKILL强制终止"},
+	)
+	
+	var warnings []string
+	if preStopSeconds >= gracePeriod {
+		warnings = append(warnings, fmt.Sprintf("preStop等待时间(%ds)不小于优雅终止时间(%ds)，应用将没有时间处理SIGTERM", preStopSeconds, gracePeriod))
+	}
+	
+	return map[string]interface{}{
+		"terminationGracePeriodSeconds": gracePeriod,
+		"preStop":                       preStop,
+		"sigtermAfterSeconds":           sigtermAt,
+		"sigkillAfterSeconds":           gracePeriod,
+		"steps":                         steps,
+		"warnings":                      warnings,
+	}
+}
+
+// 将Linux能力配置转换为Kubernetes Capabilities
+func convertCapabilities(capabilities *Capabilities) *corev1.Capabilities {
+	if capabilities == nil {
+		return nil
+	}
+	
+	k8sCapabilities := &corev1.Capabilities{}
+	for _, c := range capabilities.Add {
+		k8sCapabilities.Add = append(k8sCapabilities.Add, corev1.Capability(strings.ToUpper(c)))
+	}
+	for _, c := range capabilities.Drop {
+		k8sCapabilities.Drop = append(k8sCapabilities.Drop, corev1.Capability(strings.ToUpper(c)))
+	}
+	return k8sCapabilities
+}
+
+// 将Seccomp配置转换为Kubernetes SeccompProfile
+func convertSeccompProfile(profile *SeccompProfile) *corev1.SeccompProfile {
+	if profile == nil || profile.Type == "" {
+		return nil
+	}
+	
+	k8sProfile := &corev1.SeccompProfile{
+		Type: corev1.SeccompProfileType(profile.Type),
+	}
+	// localhostProfile仅在Localhost类型下有效
+	if profile.Type == string(corev1.SeccompProfileTypeLocalhost) && profile.LocalhostProfile != "" {
+		localhostProfile := profile.LocalhostProfile
+		k8sProfile.LocalhostProfile = &localhostProfile
+	}
+	return k8sProfile
+}
+
+// 将Pod安全上下文转换为Kubernetes PodSecurityContext
+func convertPodSecurityContext(podSecurityContext *PodSecurityContext) *corev1.PodSecurityContext {
+	if podSecurityContext == nil {
+		return nil
+	}
+	
+	k8sContext := &corev1.PodSecurityContext{
+		RunAsUser:          podSecurityContext.RunAsUser,
+		RunAsGroup:         podSecurityContext.RunAsGroup,
+		RunAsNonRoot:       podSecurityContext.RunAsNonRoot,
+		FSGroup:            podSecurityContext.FSGroup,
+		SupplementalGroups: podSecurityContext.SupplementalGroups,
+		SeccompProfile:     convertSeccompProfile(podSecurityContext.SeccompProfile),
+	}
+	
+	if podSecurityContext.FSGroupChangePolicy != "" {
+		policy := corev1.PodFSGroupChangePolicy(podSecurityContext.FSGroupChangePolicy)
+		k8sContext.FSGroupChangePolicy = &policy
+	}
+	
+	for _, sysctl := range podSecurityContext.Sysctls {
+		if sysctl.Name == "" {
+			continue
+		}
+		k8sContext.Sysctls = append(k8sContext.Sysctls, corev1.Sysctl{
+			Name:  sysctl.Name,
+			Value: sysctl.Value,
+		})
+	}
+	
+	return k8sContext
+}
+
 // 将NodeSelector转换为Kubernetes NodeSelector
 func convertNodeSelector(nodeSelector *NodeSelector) *corev1.NodeSelector {
 	if nodeSelector == nil {
@@ -1641,53 +1967,214 @@ func convertNodeSelector(nodeSelector *NodeSelector) *corev1.NodeSelector {
 	var nodeSelectorTerms []corev1.NodeSelectorTerm
 	
 	for _, term := range nodeSelector.NodeSelectorTerms {
-		nodeSelectorTerm := corev1.NodeSelectorTerm{}
-		
-		for _, expr := range term.MatchExpressions {
-			nodeSelectorTerm.MatchExpressions = append(nodeSelectorTerm.MatchExpressions, corev1.NodeSelectorRequirement{
-				Key:      expr.Key,
-				Operator: corev1.NodeSelectorOperator(expr.Operator),
-				Values:   expr.Values,
-			})
-		}
-		
-		nodeSelectorTerms = append(nodeSelectorTerms, nodeSelectorTerm)
+		nodeSelectorTerms = append(nodeSelectorTerms, convertNodeSelectorTerm(term))
 	}
 	
 	k8sNodeSelector.NodeSelectorTerms = nodeSelectorTerms
 	return k8sNodeSelector
 }
 
+// 将NodeSelectorTerm转换为Kubernetes NodeSelectorTerm
+func convertNodeSelectorTerm(term NodeSelectorTerm) corev1.NodeSelectorTerm {
+	nodeSelectorTerm := corev1.NodeSelectorTerm{}
+	
+	for _, expr := range term.MatchExpressions {
+		nodeSelectorTerm.MatchExpressions = append(nodeSelectorTerm.MatchExpressions, corev1.NodeSelectorRequirement{
+			Key:      expr.Key,
+			Operator: corev1.NodeSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
+	}
+	
+	return nodeSelectorTerm
+}
+
+// 将节点偏好调度条件转换为Kubernetes PreferredSchedulingTerm
+func convertPreferredSchedulingTerms(terms []PreferredSchedulingTerm) []corev1.PreferredSchedulingTerm {
+	var k8sTerms []corev1.PreferredSchedulingTerm
+	for _, term := range terms {
+		k8sTerms = append(k8sTerms, corev1.PreferredSchedulingTerm{
+			Weight:     clampSchedulingWeight(term.Weight),
+			Preference: convertNodeSelectorTerm(term.Preference),
+		})
+	}
+	return k8sTerms
+}
+
 // 将PodAffinityTerm转换为Kubernetes PodAffinityTerm
 func convertPodAffinityTerm(term PodAffinityTerm) corev1.PodAffinityTerm {
-	k8sTerm := corev1.PodAffinityTerm{
-		TopologyKey: term.TopologyKey,
+	return corev1.PodAffinityTerm{
+		TopologyKey:   term.TopologyKey,
+		LabelSelector: convertPodLabelSelector(term.LabelSelector),
+	}
+}
+
+// 将带权重的Pod亲和性条件转换为Kubernetes WeightedPodAffinityTerm
+func convertWeightedPodAffinityTerms(terms []WeightedPodAffinityTerm) []corev1.WeightedPodAffinityTerm {
+	var k8sTerms []corev1.WeightedPodAffinityTerm
+	for _, term := range terms {
+		k8sTerms = append(k8sTerms, corev1.WeightedPodAffinityTerm{
+			Weight:          clampSchedulingWeight(term.Weight),
+			PodAffinityTerm: convertPodAffinityTerm(term.PodAffinityTerm),
+		})
+	}
+	return k8sTerms
+}
+
+// 将Pod标签选择器转换为Kubernetes LabelSelector
+func convertPodLabelSelector(selector *PodLabelSelector) *metav1.LabelSelector {
+	if selector == nil {
+		return nil
+	}
+	
+	labelSelector := &metav1.LabelSelector{
+		MatchLabels: selector.MatchLabels,
+	}
+	
+	for _, expr := range selector.MatchExpressions {
+		labelSelector.MatchExpressions = append(labelSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      expr.Key,
+			Operator: metav1.LabelSelectorOperator(expr.Operator),
+			Values:   expr.Values,
+		})
 	}
 	
-	if term.LabelSelector != nil {
-		labelSelector := &metav1.LabelSelector{
-			MatchLabels: term.LabelSelector.MatchLabels,
+	return labelSelector
+}
+
+// 偏好调度权重必须在1-100之间
+func clampSchedulingWeight(weight int32) int32 {
+	if weight < 1 {
+		return 1
+	}
+	if weight > 100 {
+		return 100
+	}
+	return weight
+}
+
+// 将拓扑分布约束转换为Kubernetes TopologySpreadConstraint，未指定标签选择器时默认选择当前应用的Pod
+func convertTopologySpreadConstraints(constraints []TopologySpreadConstraint, appName string) []corev1.TopologySpreadConstraint {
+	var k8sConstraints []corev1.TopologySpreadConstraint
+	for _, c := range constraints {
+		if c.TopologyKey == "" {
+			log.Printf("忽略缺少topologyKey的拓扑分布约束")
+			continue
 		}
 		
-		for _, expr := range term.LabelSelector.MatchExpressions {
-			labelSelector.MatchExpressions = append(labelSelector.MatchExpressions, metav1.LabelSelectorRequirement{
-				Key:      expr.Key,
-				Operator: metav1.LabelSelectorOperator(expr.Operator),
-				Values:   expr.Values,
-			})
+		maxSkew := c.MaxSkew
+		if maxSkew < 1 {
+			maxSkew = 1
+		}
+		
+		whenUnsatisfiable := corev1.DoNotSchedule
+		if c.WhenUnsatisfiable == string(corev1.ScheduleAnyway) {
+			whenUnsatisfiable = corev1.ScheduleAnyway
+		}
+		
+		labelSelector := convertPodLabelSelector(c.LabelSelector)
+		if labelSelector == nil {
+			labelSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": appName},
+			}
 		}
 		
-		k8sTerm.LabelSelector = labelSelector
+		k8sConstraint := corev1.TopologySpreadConstraint{
+			MaxSkew:           maxSkew,
+			TopologyKey:       c.TopologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     labelSelector,
+		}
+		// minDomains仅在DoNotSchedule时有效
+		if c.MinDomains != nil && whenUnsatisfiable == corev1.DoNotSchedule {
+			k8sConstraint.MinDomains = c.MinDomains
+		}
+		
+		k8sConstraints = append(k8sConstraints, k8sConstraint)
+	}
+	return k8sConstraints
+}
+
+// 调度预设
+const (
+	SchedulingPresetSpreadAcrossNodes = "spread-across-nodes"
+	SchedulingPresetSpreadAcrossZones = "spread-across-zones"
+	SchedulingPresetOnePerNode        = "one-per-node"
+)
+
+// schedulingRules 调度预设展开后的调度规则
+type schedulingRules struct {
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint
+	PodAntiAffinityRequired   []corev1.PodAffinityTerm
+	PodAntiAffinityPreferred  []corev1.WeightedPodAffinityTerm
+}
+
+// 将调度预设展开为拓扑分布约束和Pod反亲和性规则
+func expandSchedulingPresets(presets []string, appName string) schedulingRules {
+	var rules schedulingRules
+	appSelector := func() *metav1.LabelSelector {
+		return &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": appName},
+		}
+	}
+	
+	for _, preset := range presets {
+		switch preset {
+		case SchedulingPresetSpreadAcrossNodes:
+			rules.TopologySpreadConstraints = append(rules.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       corev1.LabelHostname,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector:     appSelector(),
+			})
+			rules.PodAntiAffinityPreferred = append(rules.PodAntiAffinityPreferred, corev1.WeightedPodAffinityTerm{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					TopologyKey:   corev1.LabelHostname,
+					LabelSelector: appSelector(),
+				},
+			})
+		case SchedulingPresetSpreadAcrossZones:
+			rules.TopologySpreadConstraints = append(rules.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       corev1.LabelTopologyZone,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector:     appSelector(),
+			})
+		case SchedulingPresetOnePerNode:
+			rules.PodAntiAffinityRequired = append(rules.PodAntiAffinityRequired, corev1.PodAffinityTerm{
+				TopologyKey:   corev1.LabelHostname,
+				LabelSelector: appSelector(),
+			})
+		default:
+			log.Printf("未知的调度预设: %s，已忽略", preset)
+		}
 	}
 	
-	return k8sTerm
+	return rules
+}
+
+// IsValidSchedulingPreset 检查调度预设名称是否受支持
+func IsValidSchedulingPreset(preset string) bool {
+	switch preset {
+	case SchedulingPresetSpreadAcrossNodes, SchedulingPresetSpreadAcrossZones, SchedulingPresetOnePerNode:
+		return true
+	}
+	return false
+}
+
+// 根据Deployment的副本状态计算应用状态: running, stopped 或 deploying
+func deploymentPhase(deployment *appsv1.Deployment) string {
+	if deployment.Status.AvailableReplicas > 0 && deployment.Status.AvailableReplicas == deployment.Status.Replicas {
+		return "running"
+	} else if deployment.Status.Replicas == 0 {
+		return "stopped"
+	}
+	return "deploying"
 }
 
 // GetDeploymentStatus 获取Deployment状态
 func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name string) (map[string]interface{}, error) {
-	km.RLock()
-	defer km.RUnlock()
-	
 	// 参数验证
 	if id == "" {
 		log.Println("GetDeploymentStatus: kubeConfigId为空")
@@ -1697,14 +2184,19 @@ func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name stri
 		}, nil
 	}
 	
-	// 获取客户端
-	client, err := km.GetClient(id)
-	if err != nil {
-		log.Printf("GetDeploymentStatus: 获取客户端失败 (id: %s): %v", id, err)
-		return map[string]interface{}{
-			"status": "error",
-			"message": fmt.Sprintf("连接Kubernetes集群失败: %v", err),
-		}, nil
+	// informer缓存已同步时直接从缓存读取，否则请求API Server
+	cached, useCache := km.readyInformers(id)
+	var client kubernetes.Interface
+	var err error
+	if !useCache {
+		client, err = km.GetClient(id)
+		if err != nil {
+			log.Printf("GetDeploymentStatus: 获取客户端失败 (id: %s): %v", id, err)
+			return map[string]interface{}{
+				"status": "error",
+				"message": fmt.Sprintf("连接Kubernetes集群失败: %v", err),
+			}, nil
+		}
 	}
 	
 	// 参数检查
@@ -1753,7 +2245,12 @@ func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name stri
 	}
 	
 	// 获取Deployment
-	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	var deployment *appsv1.Deployment
+	if useCache {
+		deployment, err = cached.deploymentLister().Deployments(namespace).Get(name)
+	} else {
+		deployment, err = client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Printf("GetDeploymentStatus: 部署不存在 (namespace: %s, name: %s)", namespace, name)
@@ -1795,14 +2292,7 @@ func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name stri
 	
 	// 部署存在，计算当前状态
 	log.Printf("GetDeploymentStatus: 成功获取部署状态 (namespace: %s, name: %s)", namespace, name)
-	var currentStatus string
-	if deployment.Status.AvailableReplicas > 0 && deployment.Status.AvailableReplicas == deployment.Status.Replicas {
-		currentStatus = "running"
-	} else if deployment.Status.Replicas == 0 {
-		currentStatus = "stopped"
-	} else {
-		currentStatus = "deploying"
-	}
+	currentStatus := deploymentPhase(deployment)
 	
 	// 只有当应用状态发生变化时才更新应用状态和更新时间
 	if app != nil && app.Status != currentStatus {
@@ -1837,6 +2327,23 @@ func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name stri
 		}
 	}
 	
+	var pdbStatus map[string]interface{}
+	if useCache {
+		if pdb, err := cached.pdbLister().PodDisruptionBudgets(namespace).Get(name); err == nil {
+			pdbStatus = pdbStatusMap(pdb)
+		}
+	} else {
+		pdbStatus = getPDBStatus(client, namespace, name)
+	}
+	
+	// 最近的告警事件，帮助定位部署失败的原因
+	var warnings []EventInfo
+	if useCache {
+		warnings = cached.cachedApplicationWarnings(namespace, name, deployment.Labels["app-id"])
+	} else {
+		warnings = recentApplicationWarnings(client, namespace, name, deployment.Labels["app-id"])
+	}
+	
 	return map[string]interface{}{
 		"status": currentStatus,
 		"replicas": deployment.Status.Replicas,
@@ -1848,6 +2355,9 @@ func (km *K8sManager) GetDeploymentStatus(id string, namespace string, name stri
 		"lastDeployedAt": updatedAt.Format("2006-01-02 15:04:05"),
 		"containerName": name,
 		"containerPort": containerPort,
+		"shutdownTimeline": buildShutdownTimeline(deployment.Spec.Template.Spec),
+		"podDisruptionBudget": pdbStatus,
+		"warnings": warnings,
 	}, nil
 }
 
@@ -1863,9 +2373,6 @@ func getContainerPort(app *Application) int {
 
 // IsDeployed 检查应用是否已经部署
 func (km *K8sManager) IsDeployed(id string, namespace string, name string) (bool, error) {
-	km.RLock() // 添加读锁保证线程安全
-	defer km.RUnlock()
-	
 	// 查找应用
 	app, err := GetApplicationByIDFromDB(id)
 	if err != nil {
@@ -1878,11 +2385,16 @@ func (km *K8sManager) IsDeployed(id string, namespace string, name string) (bool
 		return false, nil
 	}
 	
-	// 获取k8s客户端
-	client, err := km.GetClient(app.KubeConfigID)
-	if err != nil {
-		log.Printf("获取客户端失败 (KubeConfigID: %s): %v", app.KubeConfigID, err)
-		return false, nil // 客户端连接错误，视为未部署但不返回错误
+	// informer缓存已同步时直接从缓存判断
+	cached, useCache := km.readyInformers(app.KubeConfigID)
+	var client kubernetes.Interface
+	if !useCache {
+		// 获取k8s客户端
+		client, err = km.GetClient(app.KubeConfigID)
+		if err != nil {
+			log.Printf("获取客户端失败 (KubeConfigID: %s): %v", app.KubeConfigID, err)
+			return false, nil // 客户端连接错误，视为未部署但不返回错误
+		}
 	}
 	
 	// 确保命名空间和名称有值
@@ -1901,7 +2413,11 @@ func (km *K8sManager) IsDeployed(id string, namespace string, name string) (bool
 	}
 	
 	// 检查Deployment是否存在
-	_, err = client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if useCache {
+		_, err = cached.deploymentLister().Deployments(namespace).Get(name)
+	} else {
+		_, err = client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			log.Printf("部署不存在 (namespace: %s, name: %s)", namespace, name)
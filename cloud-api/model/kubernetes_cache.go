@@ -0,0 +1,404 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// informer重新同步的周期，重新同步时会再次计算所有应用的状态
+	informerResyncPeriod = 10 * time.Minute
+	// 首次列出某类资源时等待缓存同步的最长时间
+	cacheSyncTimeout = 30 * time.Second
+)
+
+// CachedResourceTypes 缓存支持的资源类型
+var CachedResourceTypes = []string{
+	"namespaces", "pods", "deployments", "services", "endpoints",
+	"statefulsets", "daemonsets", "jobs", "poddisruptionbudgets", "events", "nodes",
+}
+
+// 单个集群的共享informer缓存，每类资源在第一次使用时才开始监听
+type clusterInformers struct {
+	kubeConfigID string
+	factory      informers.SharedInformerFactory
+	stopCh       chan struct{}
+	createdAt    time.Time
+
+	mu              sync.Mutex
+	started         map[string]time.Time // 资源类型 -> 开始监听的时间
+	watchingStatus  bool                 // 是否已注册应用状态的事件处理器
+	archivingEvents bool                 // 是否已注册事件归档的处理器
+	ready           atomic.Bool          // 应用状态相关的缓存是否已同步
+}
+
+// ResourceCacheStatus 某类资源的缓存状态
+type ResourceCacheStatus struct {
+	Resource  string    `json:"resource"`
+	Synced    bool      `json:"synced"`
+	Items     int       `json:"items"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// ClusterCacheStatus 集群缓存的同步状态
+type ClusterCacheStatus struct {
+	KubeConfigID              string                `json:"kubeConfigId"`
+	Started                   bool                  `json:"started"`
+	WatchingApplicationStatus bool                  `json:"watchingApplicationStatus"`
+	ArchivingEvents           bool                  `json:"archivingEvents"`
+	CreatedAt                 *time.Time            `json:"createdAt,omitempty"`
+	Resources                 []ResourceCacheStatus `json:"resources"`
+}
+
+// 缓存中不保存managedFields，减少内存占用
+func stripManagedFields(obj interface{}) (interface{}, error) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return obj, nil
+}
+
+// 获取集群的informer缓存，不存在时创建（此时还不会监听任何资源）
+func (km *K8sManager) clusterCache(kubeConfigID string) (*clusterInformers, error) {
+	km.informerMu.Lock()
+	defer km.informerMu.Unlock()
+
+	if ci, ok := km.informers[kubeConfigID]; ok {
+		return ci, nil
+	}
+
+	restConfig, err := km.GetCurrentRestConfig(kubeConfigID)
+	if err != nil {
+		return nil, err
+	}
+	// watch是长连接，不能使用请求超时
+	restConfig.Timeout = 0
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("创建kubernetes客户端失败: %v", err)
+	}
+
+	ci := &clusterInformers{
+		kubeConfigID: kubeConfigID,
+		factory:      informers.NewSharedInformerFactory(client, informerResyncPeriod),
+		stopCh:       make(chan struct{}),
+		createdAt:    time.Now(),
+		started:      make(map[string]time.Time),
+	}
+	km.informers[kubeConfigID] = ci
+	log.Printf("已创建集群 %s 的informer缓存", kubeConfigID)
+	return ci, nil
+}
+
+// 获取资源类型对应的共享informer，第一次使用时开始监听
+func (ci *clusterInformers) informer(resource string) (cache.SharedIndexInformer, error) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	var informer cache.SharedIndexInformer
+	switch resource {
+	case "namespaces":
+		informer = ci.factory.Core().V1().Namespaces().Informer()
+	case "pods":
+		informer = ci.factory.Core().V1().Pods().Informer()
+	case "deployments":
+		informer = ci.factory.Apps().V1().Deployments().Informer()
+	case "services":
+		informer = ci.factory.Core().V1().Services().Informer()
+	case "endpoints":
+		informer = ci.factory.Core().V1().Endpoints().Informer()
+	case "statefulsets":
+		informer = ci.factory.Apps().V1().StatefulSets().Informer()
+	case "daemonsets":
+		informer = ci.factory.Apps().V1().DaemonSets().Informer()
+	case "jobs":
+		informer = ci.factory.Batch().V1().Jobs().Informer()
+	case "poddisruptionbudgets":
+		informer = ci.factory.Policy().V1().PodDisruptionBudgets().Informer()
+	case "events":
+		informer = ci.factory.Core().V1().Events().Informer()
+	case "nodes":
+		informer = ci.factory.Core().V1().Nodes().Informer()
+	default:
+		return nil, fmt.Errorf("不支持缓存的资源类型: %s", resource)
+	}
+
+	if _, ok := ci.started[resource]; !ok {
+		if err := informer.SetTransform(stripManagedFields); err != nil {
+			log.Printf("设置%s缓存的转换函数失败: %v", resource, err)
+		}
+		ci.started[resource] = time.Now()
+		ci.factory.Start(ci.stopCh)
+		log.Printf("集群 %s 开始缓存%s", ci.kubeConfigID, resource)
+	}
+	return informer, nil
+}
+
+// 获取已完成首次同步的informer，同步超时或缓存已停止时返回错误
+func (ci *clusterInformers) syncedInformer(resource string) (cache.SharedIndexInformer, error) {
+	informer, err := ci.informer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(cacheSyncTimeout)
+	for !informer.HasSynced() {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s缓存同步超时", resource)
+		}
+		select {
+		case <-ci.stopCh:
+			return nil, fmt.Errorf("集群 %s 的缓存已停止", ci.kubeConfigID)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	return informer, nil
+}
+
+// 从缓存列出资源，namespace为空时返回所有命名空间的资源，结果按命名空间和名称排序
+func (km *K8sManager) listCached(kubeConfigID, resource, namespace string) ([]interface{}, error) {
+	if kubeConfigID == "" {
+		return nil, fmt.Errorf("kubeConfigID不能为空")
+	}
+	ci, err := km.clusterCache(kubeConfigID)
+	if err != nil {
+		return nil, err
+	}
+	informer, err := ci.syncedInformer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []interface{}
+	if namespace == "" || resource == "namespaces" || resource == "nodes" {
+		objs = informer.GetStore().List()
+	} else {
+		objs, err = informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("查询%s缓存失败: %v", resource, err)
+		}
+	}
+
+	keys := make(map[interface{}]string, len(objs))
+	for _, obj := range objs {
+		key, _ := cache.MetaNamespaceKeyFunc(obj)
+		keys[obj] = key
+	}
+	sort.Slice(objs, func(i, j int) bool { return keys[objs[i]] < keys[objs[j]] })
+	return objs, nil
+}
+
+// ListCachedNamespaces 从缓存获取命名空间列表
+func (km *K8sManager) ListCachedNamespaces(kubeConfigID string) ([]corev1.Namespace, error) {
+	objs, err := km.listCached(kubeConfigID, "namespaces", "")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Namespace, 0, len(objs))
+	for _, obj := range objs {
+		if namespace, ok := obj.(*corev1.Namespace); ok {
+			items = append(items, *namespace)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedPods 从缓存获取Pod列表
+func (km *K8sManager) ListCachedPods(kubeConfigID, namespace string) ([]corev1.Pod, error) {
+	objs, err := km.listCached(kubeConfigID, "pods", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Pod, 0, len(objs))
+	for _, obj := range objs {
+		if pod, ok := obj.(*corev1.Pod); ok {
+			items = append(items, *pod)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedDeployments 从缓存获取Deployment列表
+func (km *K8sManager) ListCachedDeployments(kubeConfigID, namespace string) ([]appsv1.Deployment, error) {
+	objs, err := km.listCached(kubeConfigID, "deployments", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]appsv1.Deployment, 0, len(objs))
+	for _, obj := range objs {
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			items = append(items, *deployment)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedServices 从缓存获取Service列表
+func (km *K8sManager) ListCachedServices(kubeConfigID, namespace string) ([]corev1.Service, error) {
+	objs, err := km.listCached(kubeConfigID, "services", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Service, 0, len(objs))
+	for _, obj := range objs {
+		if service, ok := obj.(*corev1.Service); ok {
+			items = append(items, *service)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedEndpoints 从缓存获取Endpoints，按"命名空间/名称"索引
+func (km *K8sManager) ListCachedEndpoints(kubeConfigID, namespace string) (map[string]*corev1.Endpoints, error) {
+	objs, err := km.listCached(kubeConfigID, "endpoints", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]*corev1.Endpoints, len(objs))
+	for _, obj := range objs {
+		if endpoints, ok := obj.(*corev1.Endpoints); ok {
+			items[endpoints.Namespace+"/"+endpoints.Name] = endpoints
+		}
+	}
+	return items, nil
+}
+
+// ListCachedStatefulSets 从缓存获取StatefulSet列表
+func (km *K8sManager) ListCachedStatefulSets(kubeConfigID, namespace string) ([]appsv1.StatefulSet, error) {
+	objs, err := km.listCached(kubeConfigID, "statefulsets", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]appsv1.StatefulSet, 0, len(objs))
+	for _, obj := range objs {
+		if statefulSet, ok := obj.(*appsv1.StatefulSet); ok {
+			items = append(items, *statefulSet)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedDaemonSets 从缓存获取DaemonSet列表
+func (km *K8sManager) ListCachedDaemonSets(kubeConfigID, namespace string) ([]appsv1.DaemonSet, error) {
+	objs, err := km.listCached(kubeConfigID, "daemonsets", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]appsv1.DaemonSet, 0, len(objs))
+	for _, obj := range objs {
+		if daemonSet, ok := obj.(*appsv1.DaemonSet); ok {
+			items = append(items, *daemonSet)
+		}
+	}
+	return items, nil
+}
+
+// ListCachedJobs 从缓存获取Job列表
+func (km *K8sManager) ListCachedJobs(kubeConfigID, namespace string) ([]batchv1.Job, error) {
+	objs, err := km.listCached(kubeConfigID, "jobs", namespace)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]batchv1.Job, 0, len(objs))
+	for _, obj := range objs {
+		if job, ok := obj.(*batchv1.Job); ok {
+			items = append(items, *job)
+		}
+	}
+	return items, nil
+}
+
+// GetCacheStatus 获取集群缓存的同步状态
+func (km *K8sManager) GetCacheStatus(kubeConfigID string) ClusterCacheStatus {
+	status := ClusterCacheStatus{KubeConfigID: kubeConfigID, Resources: []ResourceCacheStatus{}}
+
+	km.informerMu.Lock()
+	ci, ok := km.informers[kubeConfigID]
+	km.informerMu.Unlock()
+	if !ok {
+		return status
+	}
+
+	status.Started = true
+	createdAt := ci.createdAt
+	status.CreatedAt = &createdAt
+
+	ci.mu.Lock()
+	status.WatchingApplicationStatus = ci.watchingStatus
+	status.ArchivingEvents = ci.archivingEvents
+	started := make(map[string]time.Time, len(ci.started))
+	for resource, startedAt := range ci.started {
+		started[resource] = startedAt
+	}
+	ci.mu.Unlock()
+
+	for _, resource := range CachedResourceTypes {
+		startedAt, ok := started[resource]
+		if !ok {
+			continue
+		}
+		informer, err := ci.informer(resource)
+		if err != nil {
+			continue
+		}
+		status.Resources = append(status.Resources, ResourceCacheStatus{
+			Resource:  resource,
+			Synced:    informer.HasSynced(),
+			Items:     len(informer.GetStore().ListKeys()),
+			StartedAt: startedAt,
+		})
+	}
+	return status
+}
+
+// RefreshCache 丢弃集群的缓存和客户端并重新建立连接；应用状态监听和事件归档会立即恢复，其他资源在下次使用时重新列出
+func (km *K8sManager) RefreshCache(kubeConfigID string) error {
+	km.informerMu.Lock()
+	ci, ok := km.informers[kubeConfigID]
+	km.informerMu.Unlock()
+
+	watchingStatus := false
+	if ok {
+		ci.mu.Lock()
+		watchingStatus = ci.watchingStatus
+		ci.mu.Unlock()
+	}
+
+	km.StopInformers(kubeConfigID)
+
+	km.Lock()
+	delete(km.Clients, kubeConfigID)
+	km.Unlock()
+
+	if watchingStatus {
+		return km.StartInformers(kubeConfigID)
+	}
+	return nil
+}
+
+// StopInformers 停止集群的所有informer并丢弃缓存
+func (km *K8sManager) StopInformers(kubeConfigID string) {
+	km.informerMu.Lock()
+	ci, ok := km.informers[kubeConfigID]
+	delete(km.informers, kubeConfigID)
+	km.informerMu.Unlock()
+
+	if !ok {
+		return
+	}
+	close(ci.stopCh)
+	ci.factory.Shutdown()
+	log.Printf("已停止集群 %s 的informer", kubeConfigID)
+}
@@ -0,0 +1,323 @@
+package model
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// 无法归属到应用的资源使用的应用名
+const unassignedApplication = "未归属"
+
+// ChargebackLine 费用报表中的一行，可以是应用、命名空间或集群级别的汇总
+type ChargebackLine struct {
+	KubeConfigID  string          `json:"kubeConfigId"`
+	Cluster       string          `json:"cluster"`
+	Namespace     string          `json:"namespace,omitempty"`
+	Application   string          `json:"application,omitempty"`
+	ApplicationID string          `json:"applicationId,omitempty"`
+	Pods          int             `json:"pods"`
+	CPUCores      float64         `json:"cpuCores"`
+	MemoryGiB     float64         `json:"memoryGiB"`
+	StorageGiB    float64         `json:"storageGiB"`
+	LoadBalancers int             `json:"loadBalancers"`
+	Cost          PriceEstimation `json:"cost"`
+}
+
+// ChargebackReport 按应用、命名空间和集群汇总的每月费用报表
+type ChargebackReport struct {
+	GeneratedAt  time.Time          `json:"generatedAt"`
+	Namespace    string             `json:"namespace,omitempty"`
+	Applications []ChargebackLine   `json:"applications"`
+	Namespaces   []ChargebackLine   `json:"namespaces"`
+	Clusters     []ChargebackLine   `json:"clusters"`
+	Totals       map[string]float64 `json:"totals"` // 按币种汇总的总费用
+	PriceBooks   []PriceBook        `json:"priceBooks"`
+	Errors       []string           `json:"errors,omitempty"`
+}
+
+// 累加资源用量
+func (line *ChargebackLine) add(other *ChargebackLine) {
+	line.Pods += other.Pods
+	line.CPUCores += other.CPUCores
+	line.MemoryGiB += other.MemoryGiB
+	line.StorageGiB += other.StorageGiB
+	line.LoadBalancers += other.LoadBalancers
+}
+
+// 数量转换为GiB
+func quantityToGiB(q resource.Quantity) float64 {
+	return float64(q.Value()) / (1024 * 1024 * 1024)
+}
+
+// 计算Pod的有效资源请求：max(所有容器请求之和, 单个init容器的请求)，未设置请求时使用限制
+func podEffectiveRequests(pod *corev1.Pod) (float64, float64) {
+	containerRequest := func(container corev1.Container, name corev1.ResourceName) resource.Quantity {
+		if q, ok := container.Resources.Requests[name]; ok {
+			return q
+		}
+		return container.Resources.Limits[name]
+	}
+
+	var cpu, memory float64
+	for _, container := range pod.Spec.Containers {
+		q := containerRequest(container, corev1.ResourceCPU)
+		cpu += float64(q.MilliValue()) / 1000
+		memory += quantityToGiB(containerRequest(container, corev1.ResourceMemory))
+	}
+	for _, container := range pod.Spec.InitContainers {
+		q := containerRequest(container, corev1.ResourceCPU)
+		cpu = math.Max(cpu, float64(q.MilliValue())/1000)
+		memory = math.Max(memory, quantityToGiB(containerRequest(container, corev1.ResourceMemory)))
+	}
+	return cpu, memory
+}
+
+// collectChargebackUsage 统计集群中每个应用实际请求的资源，namespace为空时统计所有命名空间
+func (km *K8sManager) collectChargebackUsage(config *KubeConfig, namespace string) ([]ChargebackLine, error) {
+	client, err := km.GetClient(config.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %v", err)
+	}
+	pvcs, err := client.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取PVC列表失败: %v", err)
+	}
+	services, err := client.CoreV1().Services(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Service列表失败: %v", err)
+	}
+
+	lines := make(map[string]*ChargebackLine)
+	appNames := make(map[string]string)
+	lineFor := func(ns string, labels map[string]string) *ChargebackLine {
+		appName := labels["app"]
+		appID := labels["app-id"]
+		if appID != "" {
+			if name, ok := appNames[appID]; ok {
+				appName = name
+			} else if app, err := GetApplicationByIDFromDB(appID); err == nil {
+				appNames[appID] = app.Name
+				appName = app.Name
+			}
+		}
+		if appName == "" {
+			appName = labels["app.kubernetes.io/name"]
+		}
+		if appName == "" {
+			appName = unassignedApplication
+		}
+
+		key := ns + "/" + appName
+		line, ok := lines[key]
+		if !ok {
+			line = &ChargebackLine{
+				KubeConfigID: config.ID,
+				Cluster:      config.Name,
+				Namespace:    ns,
+				Application:  appName,
+			}
+			lines[key] = line
+		}
+		if line.ApplicationID == "" {
+			line.ApplicationID = appID
+		}
+		return line
+	}
+
+	// PVC通过挂载它的Pod归属到应用
+	claimOwners := make(map[string]map[string]string)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		// 已结束的Pod不再占用资源
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		cpu, memory := podEffectiveRequests(pod)
+		line := lineFor(pod.Namespace, pod.Labels)
+		line.Pods++
+		line.CPUCores += cpu
+		line.MemoryGiB += memory
+
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claimOwners[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName] = pod.Labels
+			}
+		}
+	}
+
+	for _, pvc := range pvcs.Items {
+		size, ok := pvc.Status.Capacity[corev1.ResourceStorage]
+		if !ok {
+			size = pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		}
+		labels := pvc.Labels
+		if owner, ok := claimOwners[pvc.Namespace+"/"+pvc.Name]; ok {
+			labels = owner
+		}
+		lineFor(pvc.Namespace, labels).StorageGiB += quantityToGiB(size)
+	}
+
+	for _, svc := range services.Items {
+		if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		labels := svc.Labels
+		if labels["app"] == "" && svc.Spec.Selector["app"] != "" {
+			labels = svc.Spec.Selector
+		}
+		lineFor(svc.Namespace, labels).LoadBalancers++
+	}
+
+	result := make([]ChargebackLine, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, *line)
+	}
+	return result, nil
+}
+
+// GenerateChargebackReport 根据Pod和PVC的实际请求生成每月费用报表
+// kubeConfigID为空时统计所有集群，namespace为空时统计所有命名空间
+func (km *K8sManager) GenerateChargebackReport(kubeConfigID, namespace string) (*ChargebackReport, error) {
+	var configs []KubeConfig
+	if kubeConfigID != "" {
+		config, err := GetKubeConfigByIDFromDB(kubeConfigID)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, *config)
+	} else {
+		all, err := GetKubeConfigsFromDB()
+		if err != nil {
+			return nil, err
+		}
+		configs = all
+	}
+
+	report := &ChargebackReport{
+		GeneratedAt:  time.Now(),
+		Namespace:    namespace,
+		Applications: []ChargebackLine{},
+		Namespaces:   []ChargebackLine{},
+		Clusters:     []ChargebackLine{},
+		Totals:       make(map[string]float64),
+		PriceBooks:   []PriceBook{},
+	}
+
+	for i := range configs {
+		config := &configs[i]
+		book, err := GetEffectivePriceBook(config.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		usage, err := km.collectChargebackUsage(config, namespace)
+		if err != nil {
+			// 单个集群不可用时不影响其他集群的报表
+			if kubeConfigID != "" {
+				return nil, err
+			}
+			log.Printf("统计集群 %s 的资源用量失败: %v", config.Name, err)
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", config.Name, err))
+			continue
+		}
+		report.PriceBooks = append(report.PriceBooks, *book)
+
+		sort.Slice(usage, func(a, b int) bool {
+			if usage[a].Namespace != usage[b].Namespace {
+				return usage[a].Namespace < usage[b].Namespace
+			}
+			return usage[a].Application < usage[b].Application
+		})
+
+		cluster := ChargebackLine{KubeConfigID: config.ID, Cluster: config.Name}
+		var namespaceLine *ChargebackLine
+		for j := range usage {
+			line := &usage[j]
+			line.Cost = book.MonthlyCost(line.CPUCores, line.MemoryGiB, line.StorageGiB, line.LoadBalancers)
+			report.Applications = append(report.Applications, *line)
+
+			if namespaceLine == nil || namespaceLine.Namespace != line.Namespace {
+				if namespaceLine != nil {
+					namespaceLine.Cost = book.MonthlyCost(namespaceLine.CPUCores, namespaceLine.MemoryGiB, namespaceLine.StorageGiB, namespaceLine.LoadBalancers)
+					report.Namespaces = append(report.Namespaces, *namespaceLine)
+				}
+				namespaceLine = &ChargebackLine{KubeConfigID: config.ID, Cluster: config.Name, Namespace: line.Namespace}
+			}
+			namespaceLine.add(line)
+			cluster.add(line)
+		}
+		if namespaceLine != nil {
+			namespaceLine.Cost = book.MonthlyCost(namespaceLine.CPUCores, namespaceLine.MemoryGiB, namespaceLine.StorageGiB, namespaceLine.LoadBalancers)
+			report.Namespaces = append(report.Namespaces, *namespaceLine)
+		}
+
+		cluster.Cost = book.MonthlyCost(cluster.CPUCores, cluster.MemoryGiB, cluster.StorageGiB, cluster.LoadBalancers)
+		report.Clusters = append(report.Clusters, cluster)
+		report.Totals[book.Currency] += cluster.Cost.Total
+	}
+
+	return report, nil
+}
+
+// WriteChargebackCSV 将费用报表导出为CSV，每行的level列标明是应用、命名空间还是集群汇总
+func WriteChargebackCSV(w io.Writer, report *ChargebackReport) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"level", "cluster", "kube_config_id", "namespace", "application", "application_id",
+		"pods", "cpu_cores", "memory_gib", "storage_gib", "load_balancers",
+		"cpu_cost", "memory_cost", "storage_cost", "load_balancer_cost", "total_cost", "currency",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+
+	formatFloat := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 4, 64)
+	}
+	formatCost := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	sections := []struct {
+		level string
+		lines []ChargebackLine
+	}{
+		{"application", report.Applications},
+		{"namespace", report.Namespaces},
+		{"cluster", report.Clusters},
+	}
+	for _, section := range sections {
+		for _, line := range section.lines {
+			record := []string{
+				section.level, line.Cluster, line.KubeConfigID, line.Namespace, line.Application, line.ApplicationID,
+				strconv.Itoa(line.Pods), formatFloat(line.CPUCores), formatFloat(line.MemoryGiB),
+				formatFloat(line.StorageGiB), strconv.Itoa(line.LoadBalancers),
+				formatCost(line.Cost.CPU), formatCost(line.Cost.Memory), formatCost(line.Cost.Storage),
+				formatCost(line.Cost.LoadBalancer), formatCost(line.Cost.Total), line.Cost.Currency,
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("写入CSV失败: %v", err)
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
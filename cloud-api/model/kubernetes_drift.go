@@ -0,0 +1,409 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// 比较时忽略的字段：状态和由集群分配或维护的字段
+var driftIgnoredPaths = map[string]bool{
+	"apiVersion":                 true,
+	"kind":                       true,
+	"status":                     true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"metadata.generation":        true,
+	"metadata.creationTimestamp": true,
+	"metadata.managedFields":     true,
+	"metadata.selfLink":          true,
+	"spec.clusterIP":             true,
+	"spec.clusterIPs":            true,
+}
+
+// DriftDifference 单个字段的差异
+type DriftDifference struct {
+	Path    string      `json:"path"`
+	Desired interface{} `json:"desired"`
+	Live    interface{} `json:"live"`
+}
+
+// ResourceDrift 单个资源的漂移情况
+type ResourceDrift struct {
+	ResourceType string            `json:"resourceType"`
+	Name         string            `json:"name"`
+	Namespace    string            `json:"namespace"`
+	Missing      bool              `json:"missing,omitempty"` // 集群中已不存在
+	Differences  []DriftDifference `json:"differences,omitempty"`
+}
+
+// ApplicationDrift 应用的配置漂移检测结果
+type ApplicationDrift struct {
+	ApplicationID   string          `json:"applicationId"`
+	ApplicationName string          `json:"applicationName"`
+	Namespace       string          `json:"namespace"`
+	KubeConfigID    string          `json:"kubeConfigId"`
+	Drifted         bool            `json:"drifted"`
+	Resources       []ResourceDrift `json:"resources"`
+	Error           string          `json:"error,omitempty"`
+	CheckedAt       time.Time       `json:"checkedAt"`
+}
+
+// 将对象转换为通用的map结构，数字统一为float64
+func toManifestMap(obj interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 去掉状态和集群维护的元数据，得到可作为期望状态保存的清单
+func cleanManifest(manifest map[string]interface{}) {
+	delete(manifest, "status")
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "uid", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "deployment.kubernetes.io/revision")
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		}
+	}
+}
+
+// recordDesiredManifest 保存部署时渲染的期望清单，用于之后的漂移检测
+func recordDesiredManifest(app *Application, resourceType string, namespace string, name string, obj interface{}) error {
+	manifest, err := toManifestMap(obj)
+	if err != nil {
+		return fmt.Errorf("序列化%s清单失败: %v", resourceType, err)
+	}
+	cleanManifest(manifest)
+
+	return saveDesiredManifest(app.ID, resourceType, namespace, name, manifest)
+}
+
+// 将期望清单写入kubernetes_resources表
+func saveDesiredManifest(appID, resourceType, namespace, name string, manifest map[string]interface{}) error {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return fmt.Errorf("生成%s清单YAML失败: %v", resourceType, err)
+	}
+
+	record, err := GetK8sResourceByDetailsFromDB(appID, resourceType, name, namespace)
+	if err != nil {
+		record = &KubernetesResource{
+			ApplicationID: appID,
+			ResourceType:  resourceType,
+			ResourceName:  name,
+			Namespace:     namespace,
+		}
+	}
+	record.ResourceYAML = string(data)
+	record.IsActive = true
+	return SaveK8sResourceToDB(record)
+}
+
+// 获取集群中资源的当前状态，不存在时返回nil
+func getLiveManifest(client kubernetes.Interface, resourceType, namespace, name string) (map[string]interface{}, error) {
+	var obj interface{}
+	var err error
+	switch resourceType {
+	case "deployments":
+		var deployment *appsv1.Deployment
+		deployment, err = client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		obj = deployment
+	case "services":
+		var service *corev1.Service
+		service, err = client.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		obj = service
+	default:
+		return nil, fmt.Errorf("不支持检测漂移的资源类型: %s", resourceType)
+	}
+
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取%s %s/%s失败: %v", resourceType, namespace, name, err)
+	}
+	return toManifestMap(obj)
+}
+
+// 判断期望值是否为空（nil、空map或空数组），空值视为未指定
+func isEmptyManifestValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// 比较期望清单与集群状态，只检查期望清单中出现的字段，集群默认填充的字段不视为漂移
+func compareManifest(path string, desired, live interface{}, diffs *[]DriftDifference) {
+	if driftIgnoredPaths[path] {
+		return
+	}
+	if isEmptyManifestValue(desired) {
+		return
+	}
+
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			*diffs = append(*diffs, DriftDifference{Path: path, Desired: desired, Live: live})
+			return
+		}
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			compareManifest(childPath, d[k], l[k], diffs)
+		}
+	case []interface{}:
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			*diffs = append(*diffs, DriftDifference{Path: path, Desired: desired, Live: live})
+			return
+		}
+		for i := range d {
+			compareManifest(fmt.Sprintf("%s[%d]", path, i), d[i], l[i], diffs)
+		}
+	default:
+		if !reflect.DeepEqual(desired, live) {
+			*diffs = append(*diffs, DriftDifference{Path: path, Desired: desired, Live: live})
+		}
+	}
+}
+
+// CheckApplicationDrift 将应用最近一次部署的期望清单与集群中的对象比较，并保存检测结果
+func (km *K8sManager) CheckApplicationDrift(app *Application) (*ApplicationDrift, error) {
+	namespace, _ := applicationTarget(app)
+	drift := &ApplicationDrift{
+		ApplicationID:   app.ID,
+		ApplicationName: app.Name,
+		Namespace:       namespace,
+		KubeConfigID:    app.KubeConfigID,
+		Resources:       []ResourceDrift{},
+		CheckedAt:       time.Now(),
+	}
+
+	records, err := GetK8sResourcesByAppIDFromDB(app.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := km.GetClient(app.KubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	for _, record := range records {
+		// 尚未部署过的资源没有期望清单
+		if record.ResourceYAML == "" {
+			continue
+		}
+
+		var desired map[string]interface{}
+		if err := yaml.Unmarshal([]byte(record.ResourceYAML), &desired); err != nil {
+			log.Printf("解析%s %s的期望清单失败: %v", record.ResourceType, record.ResourceName, err)
+			continue
+		}
+
+		result := ResourceDrift{
+			ResourceType: record.ResourceType,
+			Name:         record.ResourceName,
+			Namespace:    record.Namespace,
+		}
+		live, err := getLiveManifest(client, record.ResourceType, record.Namespace, record.ResourceName)
+		if err != nil {
+			drift.Error = err.Error()
+			continue
+		}
+		if live == nil {
+			result.Missing = true
+		} else {
+			compareManifest("", desired, live, &result.Differences)
+		}
+
+		if result.Missing || len(result.Differences) > 0 {
+			drift.Drifted = true
+		}
+		drift.Resources = append(drift.Resources, result)
+	}
+
+	if err := SaveApplicationDriftToDB(drift); err != nil {
+		log.Printf("保存漂移检测结果失败: %v", err)
+	}
+	return drift, nil
+}
+
+// DetectDriftForAllApplications 检测所有运行中应用的配置漂移
+func (km *K8sManager) DetectDriftForAllApplications() {
+	apps, err := GetApplicationsFromDB()
+	if err != nil {
+		log.Printf("获取应用列表失败: %v", err)
+		return
+	}
+
+	drifted := 0
+	for i := range apps {
+		app := &apps[i]
+		if (app.Status != "running" && app.Status != "unavailable") || app.KubeConfigID == "" {
+			continue
+		}
+
+		drift, err := km.CheckApplicationDrift(app)
+		if err != nil {
+			log.Printf("检测应用 %s 的配置漂移失败: %v", app.Name, err)
+			continue
+		}
+		if drift.Drifted {
+			drifted++
+			log.Printf("应用 %s/%s 的配置与集群状态不一致", drift.Namespace, app.Name)
+		}
+	}
+	log.Printf("配置漂移检测完成，%d个应用存在漂移", drifted)
+}
+
+// AcceptLiveState 接受集群中的当前状态：将副本数、镜像和资源配置同步回应用，并以集群对象作为新的期望清单
+func (km *K8sManager) AcceptLiveState(app *Application) ([]string, error) {
+	namespace, name := applicationTarget(app)
+	client, err := km.GetClient(app.KubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	var synced []string
+	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Deployment失败: %v", err)
+	}
+
+	if deployment.Spec.Replicas != nil && int(*deployment.Spec.Replicas) != app.Replicas {
+		app.Replicas = int(*deployment.Spec.Replicas)
+		synced = append(synced, "replicas")
+	}
+	if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+		container := containers[0]
+		// 部署时使用ImageURL作为容器镜像
+		if container.Image != app.ImageURL {
+			app.ImageURL = container.Image
+			synced = append(synced, "image")
+		}
+		live := &ResourceRequirements{}
+		if q, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+			live.Requests.CPU = q.String()
+		}
+		if q, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			live.Requests.Memory = q.String()
+		}
+		if q, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+			live.Limits.CPU = q.String()
+		}
+		if q, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			live.Limits.Memory = q.String()
+		}
+		if !reflect.DeepEqual(convertResourceRequirements(live), convertResourceRequirements(app.Resources)) {
+			app.Resources = live
+			synced = append(synced, "resources")
+		}
+	}
+
+	if len(synced) > 0 {
+		if err := SaveApplicationToDB(app); err != nil {
+			return nil, err
+		}
+	}
+
+	// 以集群对象作为新的期望清单
+	records, err := GetK8sResourcesByAppIDFromDB(app.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		live, err := getLiveManifest(client, record.ResourceType, record.Namespace, record.ResourceName)
+		if err != nil || live == nil {
+			continue
+		}
+		cleanManifest(live)
+		if err := saveDesiredManifest(app.ID, record.ResourceType, record.Namespace, record.ResourceName, live); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("已接受应用 %s 的集群状态，同步字段: %s", app.Name, strings.Join(synced, ", "))
+	return synced, nil
+}
+
+// SaveApplicationDriftToDB 保存应用的漂移检测结果
+func SaveApplicationDriftToDB(drift *ApplicationDrift) error {
+	resultJSON, err := json.Marshal(drift)
+	if err != nil {
+		return fmt.Errorf("序列化漂移检测结果失败: %v", err)
+	}
+
+	query := `
+        INSERT INTO application_drift (application_id, drifted, result_json, checked_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (application_id)
+        DO UPDATE SET drifted = $2, result_json = $3, checked_at = $4
+    `
+	if _, err := DB.Exec(query, drift.ApplicationID, drift.Drifted, string(resultJSON), drift.CheckedAt); err != nil {
+		return fmt.Errorf("保存漂移检测结果失败: %v", err)
+	}
+	return nil
+}
+
+// GetApplicationDriftsFromDB 获取最近一次漂移检测结果，driftedOnly为true时只返回存在漂移的应用
+func GetApplicationDriftsFromDB(driftedOnly bool) ([]ApplicationDrift, error) {
+	query := `
+        SELECT d.result_json
+        FROM application_drift d
+        JOIN applications a ON a.id = d.application_id
+        WHERE a.deleted_at IS NULL AND ($1 = false OR d.drifted = true)
+        ORDER BY d.checked_at DESC
+    `
+	var rows []sql.NullString
+	if err := DB.Select(&rows, query, driftedOnly); err != nil {
+		return nil, fmt.Errorf("查询漂移检测结果失败: %v", err)
+	}
+
+	drifts := make([]ApplicationDrift, 0, len(rows))
+	for _, row := range rows {
+		var drift ApplicationDrift
+		if !row.Valid || json.Unmarshal([]byte(row.String), &drift) != nil {
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts, nil
+}
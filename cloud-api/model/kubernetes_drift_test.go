@@ -0,0 +1,104 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		desired map[string]interface{}
+		live    map[string]interface{}
+		want    []string
+	}{
+		{
+			name:    "相同",
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}},
+			live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}},
+		},
+		{
+			name:    "字段值不同",
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}},
+			live:    map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(5)}},
+			want:    []string{"spec.replicas"},
+		},
+		{
+			name:    "集群默认填充的字段不视为漂移",
+			desired: map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(2)}},
+			live: map[string]interface{}{"spec": map[string]interface{}{
+				"replicas":                float64(2),
+				"revisionHistoryLimit":    float64(10),
+				"progressDeadlineSeconds": float64(600),
+			}},
+		},
+		{
+			name:    "期望值为空时视为未指定",
+			desired: map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{}, "labels": nil}},
+			live:    map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"a": "b"}}},
+		},
+		{
+			name: "忽略状态和集群维护的字段",
+			desired: map[string]interface{}{
+				"status":   map[string]interface{}{"replicas": float64(1)},
+				"metadata": map[string]interface{}{"resourceVersion": "1", "uid": "a"},
+				"spec":     map[string]interface{}{"clusterIP": "10.0.0.1"},
+			},
+			live: map[string]interface{}{
+				"status":   map[string]interface{}{"replicas": float64(3)},
+				"metadata": map[string]interface{}{"resourceVersion": "2", "uid": "b"},
+				"spec":     map[string]interface{}{"clusterIP": "10.0.0.2"},
+			},
+		},
+		{
+			name: "数组长度不同",
+			desired: map[string]interface{}{"spec": map[string]interface{}{
+				"ports": []interface{}{map[string]interface{}{"port": float64(80)}},
+			}},
+			live: map[string]interface{}{"spec": map[string]interface{}{
+				"ports": []interface{}{map[string]interface{}{"port": float64(80)}, map[string]interface{}{"port": float64(443)}},
+			}},
+			want: []string{"spec.ports"},
+		},
+		{
+			name: "数组元素中的字段不同",
+			desired: map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"image": "nginx:1.25"}},
+			}},
+			live: map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"image": "nginx:1.26", "imagePullPolicy": "IfNotPresent"}},
+			}},
+			want: []string{"spec.containers[0].image"},
+		},
+		{
+			name:    "集群中缺少整个对象",
+			desired: map[string]interface{}{"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "web"}}},
+			live:    map[string]interface{}{},
+			want:    []string{"spec"},
+		},
+		{
+			name: "差异按路径排序",
+			desired: map[string]interface{}{"spec": map[string]interface{}{
+				"b": "1", "a": "1",
+			}},
+			live: map[string]interface{}{"spec": map[string]interface{}{
+				"b": "2", "a": "2",
+			}},
+			want: []string{"spec.a", "spec.b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var diffs []DriftDifference
+			compareManifest("", tt.desired, tt.live, &diffs)
+			var got []string
+			for _, diff := range diffs {
+				got = append(got, diff.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compareManifest() paths = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 在集群的共享缓存上监听Event并写入归档表，已启动时直接返回
+func (ci *clusterInformers) startEventArchiver() error {
+	ci.mu.Lock()
+	if ci.archivingEvents {
+		ci.mu.Unlock()
+		return nil
+	}
+	ci.archivingEvents = true
+	ci.mu.Unlock()
+
+	informer, err := ci.informer("events")
+	if err != nil {
+		return err
+	}
+
+	// 启动时会收到集群中现有的所有事件，已归档的事件由去重索引忽略
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: ci.archiveEvent,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldEvent, ok := oldObj.(*corev1.Event)
+			newEvent, ok2 := newObj.(*corev1.Event)
+			// 重新同步时对象未变化，不需要再次写入
+			if ok && ok2 && oldEvent.ResourceVersion == newEvent.ResourceVersion {
+				return
+			}
+			ci.archiveEvent(newObj)
+		},
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("已启动集群 %s 的事件归档", ci.kubeConfigID)
+	return nil
+}
+
+// 将事件写入归档表
+func (ci *clusterInformers) archiveEvent(obj interface{}) {
+	event, ok := obj.(*corev1.Event)
+	if !ok {
+		return
+	}
+
+	count := int(event.Count)
+	if count == 0 {
+		count = 1
+	}
+	source := event.Source.Component
+	if source == "" {
+		source = event.ReportingController
+	}
+	lastTimestamp := eventLastTime(event)
+	firstTimestamp := event.FirstTimestamp.Time
+	if firstTimestamp.IsZero() {
+		firstTimestamp = lastTimestamp
+	}
+
+	record := &ArchivedEvent{
+		KubeConfigID:   ci.kubeConfigID,
+		EventUID:       string(event.UID),
+		Namespace:      event.Namespace,
+		ObjectKind:     event.InvolvedObject.Kind,
+		ObjectName:     event.InvolvedObject.Name,
+		ObjectUID:      string(event.InvolvedObject.UID),
+		ApplicationID:  ci.eventApplicationID(event),
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Count:          count,
+		Source:         source,
+		FirstTimestamp: firstTimestamp,
+		LastTimestamp:  lastTimestamp,
+	}
+	if err := SaveArchivedEventToDB(record); err != nil {
+		log.Printf("归档集群 %s 的事件失败: %v", ci.kubeConfigID, err)
+	}
+}
+
+// 根据缓存中事件所属对象的app-id标签确定应用，无法确定时返回空
+func (ci *clusterInformers) eventApplicationID(event *corev1.Event) string {
+	namespace := event.InvolvedObject.Namespace
+	if namespace == "" {
+		namespace = event.Namespace
+	}
+	name := event.InvolvedObject.Name
+
+	switch event.InvolvedObject.Kind {
+	case "Pod":
+		if pod, err := ci.podLister().Pods(namespace).Get(name); err == nil {
+			return pod.Labels["app-id"]
+		}
+		return ""
+	case "StatefulSet":
+		if statefulSet, err := ci.statefulSetLister().StatefulSets(namespace).Get(name); err == nil {
+			return statefulSet.Labels["app-id"]
+		}
+		return ""
+	case "ReplicaSet":
+		// ReplicaSet名称为"<Deployment名称>-<模板哈希>"
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			name = name[:i]
+		}
+	}
+
+	// Deployment以及与应用同名的Service、HPA、PDB等
+	if deployment, err := ci.deploymentLister().Deployments(namespace).Get(name); err == nil {
+		return deployment.Labels["app-id"]
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 部署状态中最多返回的告警事件数量
+const maxStatusWarnings = 10
+
+// EventInfo Kubernetes事件
+type EventInfo struct {
+	Type           string    `json:"type"`
+	Reason         string    `json:"reason"`
+	Message        string    `json:"message"`
+	Namespace      string    `json:"namespace"`
+	ObjectKind     string    `json:"objectKind"`
+	ObjectName     string    `json:"objectName"`
+	Count          int32     `json:"count"`
+	Source         string    `json:"source,omitempty"`
+	FirstTimestamp time.Time `json:"firstTimestamp"`
+	LastTimestamp  time.Time `json:"lastTimestamp"`
+}
+
+// ValidateEventType 校验事件类型过滤条件，为空表示不过滤
+func ValidateEventType(eventType string) error {
+	switch eventType {
+	case "", corev1.EventTypeNormal, corev1.EventTypeWarning:
+		return nil
+	}
+	return fmt.Errorf("无效的事件类型: %s，可选值: Normal, Warning", eventType)
+}
+
+// 事件最后一次发生的时间，兼容只设置了eventTime的新版事件
+func eventLastTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// 列出命名空间中的事件，eventType不为空时只返回该类型的事件
+func listEvents(client kubernetes.Interface, namespace, eventType string, selector fields.Set) ([]corev1.Event, error) {
+	if selector == nil {
+		selector = fields.Set{}
+	}
+	if eventType != "" {
+		selector["type"] = eventType
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.SelectorFromSet(selector).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取事件列表失败: %v", err)
+	}
+	return events.Items, nil
+}
+
+// 转换为输出格式并按最后发生时间倒序排列，match为nil时保留所有事件
+func toEventInfos(events []corev1.Event, match func(*corev1.Event) bool) []EventInfo {
+	result := make([]EventInfo, 0, len(events))
+	for i := range events {
+		event := &events[i]
+		if match != nil && !match(event) {
+			continue
+		}
+
+		count := event.Count
+		if count == 0 {
+			count = 1
+		}
+		source := event.Source.Component
+		if source == "" {
+			source = event.ReportingController
+		}
+		firstTimestamp := event.FirstTimestamp.Time
+		if firstTimestamp.IsZero() {
+			firstTimestamp = eventLastTime(event)
+		}
+
+		result = append(result, EventInfo{
+			Type:           event.Type,
+			Reason:         event.Reason,
+			Message:        event.Message,
+			Namespace:      event.Namespace,
+			ObjectKind:     event.InvolvedObject.Kind,
+			ObjectName:     event.InvolvedObject.Name,
+			Count:          count,
+			Source:         source,
+			FirstTimestamp: firstTimestamp,
+			LastTimestamp:  eventLastTime(event),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].LastTimestamp.After(result[j].LastTimestamp)
+	})
+	return result
+}
+
+// GetNamespaceEvents 获取命名空间中的事件
+func (km *K8sManager) GetNamespaceEvents(kubeConfigID, namespace, eventType string) ([]EventInfo, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+	}
+
+	events, err := listEvents(client, namespace, eventType, nil)
+	if err != nil {
+		return nil, err
+	}
+	return toEventInfos(events, nil), nil
+}
+
+// GetPodEvents 获取单个Pod的事件
+func (km *K8sManager) GetPodEvents(kubeConfigID, namespace, podName, eventType string) ([]EventInfo, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+	}
+
+	events, err := listEvents(client, namespace, eventType, fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": podName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEventInfos(events, nil), nil
+}
+
+// GetApplicationEvents 获取应用的事件，包括其Deployment/StatefulSet、ReplicaSet、Pod、Service等资源上的事件
+func (km *K8sManager) GetApplicationEvents(app *Application, eventType string) ([]EventInfo, error) {
+	client, err := km.GetClient(app.KubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+	}
+
+	namespace, name := applicationTarget(app)
+	events, err := listEvents(client, namespace, eventType, nil)
+	if err != nil {
+		return nil, err
+	}
+	return toEventInfos(events, applicationEventMatcher(client, namespace, name, app.ID)), nil
+}
+
+// 应用的资源都带有app-id标签，或与应用同名（Deployment、Service、HPA、PDB等）
+func applicationEventMatcher(client kubernetes.Interface, namespace, name, appID string) func(*corev1.Event) bool {
+	return ownedOrNamedMatcher(applicationOwnedUIDs(client, namespace, appID), name)
+}
+
+// 匹配所属对象在owned中或与应用同名的事件
+func ownedOrNamedMatcher(owned map[types.UID]struct{}, name string) func(*corev1.Event) bool {
+	return func(event *corev1.Event) bool {
+		if _, ok := owned[event.InvolvedObject.UID]; ok {
+			return true
+		}
+		return event.InvolvedObject.Name == name
+	}
+}
+
+// 收集带有应用app-id标签的资源UID，单类资源获取失败时忽略
+func applicationOwnedUIDs(client kubernetes.Interface, namespace, appID string) map[types.UID]struct{} {
+	owned := make(map[types.UID]struct{})
+	if appID == "" {
+		return owned
+	}
+
+	ctx := context.TODO()
+	options := metav1.ListOptions{LabelSelector: "app-id=" + appID}
+	add := func(list runtime.Object, err error) {
+		if err != nil {
+			return
+		}
+		meta.EachListItem(list, func(obj runtime.Object) error {
+			if accessor, err := meta.Accessor(obj); err == nil {
+				owned[accessor.GetUID()] = struct{}{}
+			}
+			return nil
+		})
+	}
+
+	add(client.AppsV1().Deployments(namespace).List(ctx, options))
+	add(client.AppsV1().StatefulSets(namespace).List(ctx, options))
+	// ReplicaSet继承Pod模板上的app-id标签
+	add(client.AppsV1().ReplicaSets(namespace).List(ctx, options))
+	add(client.CoreV1().Pods(namespace).List(ctx, options))
+	add(client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, options))
+	return owned
+}
+
+// 部署状态中展示的应用最近告警事件，如FailedScheduling、BackOff、FailedMount
+func recentApplicationWarnings(client kubernetes.Interface, namespace, name, appID string) []EventInfo {
+	events, err := listEvents(client, namespace, corev1.EventTypeWarning, nil)
+	if err != nil {
+		return []EventInfo{}
+	}
+
+	return limitStatusWarnings(toEventInfos(events, applicationEventMatcher(client, namespace, name, appID)))
+}
+
+// 从informer缓存读取应用最近的告警事件，不请求API Server，事件缓存尚未同步时返回空
+func (ci *clusterInformers) cachedApplicationWarnings(namespace, name, appID string) []EventInfo {
+	informer, err := ci.informer("events")
+	if err != nil || !informer.HasSynced() {
+		return []EventInfo{}
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return []EventInfo{}
+	}
+
+	events := make([]corev1.Event, 0, len(objs))
+	for _, obj := range objs {
+		if event, ok := obj.(*corev1.Event); ok && event.Type == corev1.EventTypeWarning {
+			events = append(events, *event)
+		}
+	}
+	return limitStatusWarnings(toEventInfos(events, ownedOrNamedMatcher(ci.cachedOwnedUIDs(namespace, appID), name)))
+}
+
+// 从informer缓存收集带有应用app-id标签的资源UID，ReplicaSet不在缓存中，通过Pod的ownerReferences获取
+func (ci *clusterInformers) cachedOwnedUIDs(namespace, appID string) map[types.UID]struct{} {
+	owned := make(map[types.UID]struct{})
+	if appID == "" {
+		return owned
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{"app-id": appID})
+	if deployments, err := ci.deploymentLister().Deployments(namespace).List(selector); err == nil {
+		for _, deployment := range deployments {
+			owned[deployment.UID] = struct{}{}
+		}
+	}
+	if statefulSets, err := ci.statefulSetLister().StatefulSets(namespace).List(selector); err == nil {
+		for _, statefulSet := range statefulSets {
+			owned[statefulSet.UID] = struct{}{}
+		}
+	}
+	if pods, err := ci.podLister().Pods(namespace).List(selector); err == nil {
+		for _, pod := range pods {
+			owned[pod.UID] = struct{}{}
+			for _, ref := range pod.OwnerReferences {
+				owned[ref.UID] = struct{}{}
+			}
+		}
+	}
+	return owned
+}
+
+// 部署状态中最多保留maxStatusWarnings条告警事件
+func limitStatusWarnings(warnings []EventInfo) []EventInfo {
+	if len(warnings) > maxStatusWarnings {
+		warnings = warnings[:maxStatusWarnings]
+	}
+	return warnings
+}
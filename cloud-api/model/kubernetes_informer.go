@@ -0,0 +1,234 @@
+package model
+
+import (
+	"fmt"
+	"log"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	policylisters "k8s.io/client-go/listers/policy/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// 应用状态只关注由本系统创建的资源
+var managedBySelector = labels.SelectorFromSet(labels.Set{"managed-by": "cloud-deployment-api"})
+
+// 容器处于这些等待原因时应用视为错误状态
+var failingContainerReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+}
+
+// StartInformers 在集群的共享缓存上监听Deployment、StatefulSet、Pod和PodDisruptionBudget并维护应用状态，同时开始归档集群事件，已监听时直接返回
+func (km *K8sManager) StartInformers(kubeConfigID string) error {
+	ci, err := km.clusterCache(kubeConfigID)
+	if err != nil {
+		return err
+	}
+	if err := ci.startEventArchiver(); err != nil {
+		log.Printf("启动集群 %s 的事件归档失败: %v", kubeConfigID, err)
+	}
+
+	ci.mu.Lock()
+	if ci.watchingStatus {
+		ci.mu.Unlock()
+		return nil
+	}
+	ci.watchingStatus = true
+	ci.mu.Unlock()
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    ci.onChange,
+		UpdateFunc: func(_, obj interface{}) { ci.onChange(obj) },
+		DeleteFunc: ci.onChange,
+	}
+	for _, resource := range []string{"deployments", "statefulsets", "pods"} {
+		informer, err := ci.informer(resource)
+		if err != nil {
+			return err
+		}
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return fmt.Errorf("注册informer事件处理器失败: %v", err)
+		}
+	}
+	log.Printf("已启动集群 %s 的应用状态监听", kubeConfigID)
+
+	// 缓存同步完成前不处理事件，避免Pod先于Deployment到达时计算出错误的状态
+	go func() {
+		for _, resource := range []string{"deployments", "statefulsets", "pods", "poddisruptionbudgets"} {
+			if _, err := ci.syncedInformer(resource); err != nil {
+				log.Printf("集群 %s 的informer缓存同步失败: %v", kubeConfigID, err)
+				return
+			}
+		}
+		ci.ready.Store(true)
+		log.Printf("集群 %s 的informer缓存同步完成", kubeConfigID)
+		ci.refreshAll()
+	}()
+	return nil
+}
+
+// 缓存中的Deployment
+func (ci *clusterInformers) deploymentLister() appslisters.DeploymentLister {
+	informer, _ := ci.informer("deployments")
+	return appslisters.NewDeploymentLister(informer.GetIndexer())
+}
+
+// 缓存中的StatefulSet
+func (ci *clusterInformers) statefulSetLister() appslisters.StatefulSetLister {
+	informer, _ := ci.informer("statefulsets")
+	return appslisters.NewStatefulSetLister(informer.GetIndexer())
+}
+
+// 缓存中的Pod
+func (ci *clusterInformers) podLister() corelisters.PodLister {
+	informer, _ := ci.informer("pods")
+	return corelisters.NewPodLister(informer.GetIndexer())
+}
+
+// 缓存中的PodDisruptionBudget
+func (ci *clusterInformers) pdbLister() policylisters.PodDisruptionBudgetLister {
+	informer, _ := ci.informer("poddisruptionbudgets")
+	return policylisters.NewPodDisruptionBudgetLister(informer.GetIndexer())
+}
+
+// RestartInformers 集群配置（如当前上下文）变化后重新启动informer
+func (km *K8sManager) RestartInformers(kubeConfigID string) error {
+	km.StopInformers(kubeConfigID)
+	return km.StartInformers(kubeConfigID)
+}
+
+// StartAllInformers 为数据库中所有集群启动informer
+func (km *K8sManager) StartAllInformers() {
+	configs, err := GetKubeConfigsFromDB()
+	if err != nil {
+		log.Printf("获取集群列表失败，无法启动informer: %v", err)
+		return
+	}
+	for _, config := range configs {
+		if err := km.StartInformers(config.ID); err != nil {
+			log.Printf("启动集群 %s 的informer失败: %v", config.ID, err)
+		}
+	}
+}
+
+// 获取缓存已同步的集群informer，未启动或未同步时返回false
+func (km *K8sManager) readyInformers(kubeConfigID string) (*clusterInformers, bool) {
+	km.informerMu.Lock()
+	defer km.informerMu.Unlock()
+
+	ci, ok := km.informers[kubeConfigID]
+	if !ok || !ci.ready.Load() {
+		return nil, false
+	}
+	return ci, true
+}
+
+// 资源变化时重新计算其所属应用的状态
+func (ci *clusterInformers) onChange(obj interface{}) {
+	if !ci.ready.Load() {
+		return
+	}
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+
+	appID := accessor.GetLabels()["app-id"]
+	appName := accessor.GetLabels()["app"]
+	if appID == "" || appName == "" {
+		return
+	}
+	ci.refreshApplication(appID, accessor.GetNamespace(), appName)
+}
+
+// 重新计算集群中所有应用的状态
+func (ci *clusterInformers) refreshAll() {
+	deployments, err := ci.deploymentLister().List(managedBySelector)
+	if err == nil {
+		for _, deployment := range deployments {
+			if appID := deployment.Labels["app-id"]; appID != "" {
+				ci.refreshApplication(appID, deployment.Namespace, deployment.Name)
+			}
+		}
+	}
+	statefulSets, err := ci.statefulSetLister().List(managedBySelector)
+	if err == nil {
+		for _, statefulSet := range statefulSets {
+			if appID := statefulSet.Labels["app-id"]; appID != "" {
+				ci.refreshApplication(appID, statefulSet.Namespace, statefulSet.Name)
+			}
+		}
+	}
+}
+
+// 根据缓存中的Deployment/StatefulSet和Pod计算应用状态并写入数据库
+func (ci *clusterInformers) refreshApplication(appID, namespace, name string) {
+	status, readyReplicas := ci.applicationStatus(appID, namespace, name)
+	if err := UpdateApplicationRuntimeStatusToDB(appID, status, readyReplicas); err != nil {
+		log.Printf("更新应用 %s 的运行状态失败: %v", appID, err)
+	}
+}
+
+// 计算应用状态: running, deploying, unavailable 或 error。
+// 工作负载不存在或副本数为0时返回unavailable而不是stopped，stopped只表示用户主动停止，
+// 自动同步和漂移检测仍会处理unavailable的应用
+func (ci *clusterInformers) applicationStatus(appID, namespace, name string) (string, int) {
+	var status string
+	var readyReplicas int
+
+	if deployment, err := ci.deploymentLister().Deployments(namespace).Get(name); err == nil {
+		status = deploymentPhase(deployment)
+		readyReplicas = int(deployment.Status.ReadyReplicas)
+	} else if statefulSet, err := ci.statefulSetLister().StatefulSets(namespace).Get(name); err == nil {
+		status = statefulSetPhase(statefulSet)
+		readyReplicas = int(statefulSet.Status.ReadyReplicas)
+	} else {
+		// 工作负载已被删除
+		return "unavailable", 0
+	}
+	if status == "stopped" {
+		status = "unavailable"
+	}
+
+	pods, err := ci.podLister().Pods(namespace).List(labels.SelectorFromSet(labels.Set{"app-id": appID}))
+	if err == nil {
+		for _, pod := range pods {
+			if podFailing(pod) {
+				return "error", readyReplicas
+			}
+		}
+	}
+	return status, readyReplicas
+}
+
+// 根据StatefulSet的副本状态计算应用状态，规则与deploymentPhase一致
+func statefulSetPhase(statefulSet *appsv1.StatefulSet) string {
+	if statefulSet.Status.ReadyReplicas > 0 && statefulSet.Status.ReadyReplicas == statefulSet.Status.Replicas {
+		return "running"
+	} else if statefulSet.Status.Replicas == 0 {
+		return "stopped"
+	}
+	return "deploying"
+}
+
+// 判断Pod中是否有容器处于无法自行恢复的等待状态
+func podFailing(pod *corev1.Pod) bool {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Waiting != nil && failingContainerReasons[containerStatus.State.Waiting.Reason] {
+			return true
+		}
+	}
+	return false
+}
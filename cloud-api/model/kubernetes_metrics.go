@@ -0,0 +1,203 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	metricsAPIPath = "/apis/metrics.k8s.io/v1beta1"
+	// 请求metrics-server的超时时间，避免拖慢列表接口
+	metricsRequestTimeout = 5 * time.Second
+)
+
+// ErrMetricsUnavailable 集群未安装metrics-server或其暂时不可用
+var ErrMetricsUnavailable = errors.New("集群未安装metrics-server或metrics-server不可用")
+
+// ContainerUsage 容器的资源用量
+type ContainerUsage struct {
+	Name          string `json:"name"`
+	CPUMillicores int64  `json:"cpuMillicores"`
+	MemoryBytes   int64  `json:"memoryBytes"`
+}
+
+// PodUsage Pod的资源用量，为各容器用量之和
+type PodUsage struct {
+	Name          string           `json:"name"`
+	Namespace     string           `json:"namespace"`
+	ApplicationID string           `json:"applicationId,omitempty"`
+	CPUMillicores int64            `json:"cpuMillicores"`
+	MemoryBytes   int64            `json:"memoryBytes"`
+	Containers    []ContainerUsage `json:"containers"`
+	Timestamp     time.Time        `json:"timestamp"`
+}
+
+// NodeUsage 节点的资源用量及占可分配资源的百分比
+type NodeUsage struct {
+	Name                     string    `json:"name"`
+	CPUMillicores            int64     `json:"cpuMillicores"`
+	MemoryBytes              int64     `json:"memoryBytes"`
+	AllocatableCPUMillicores int64     `json:"allocatableCpuMillicores"`
+	AllocatableMemoryBytes   int64     `json:"allocatableMemoryBytes"`
+	CPUPercent               float64   `json:"cpuPercent"`
+	MemoryPercent            float64   `json:"memoryPercent"`
+	Timestamp                time.Time `json:"timestamp"`
+}
+
+// metrics.k8s.io返回的Pod指标
+type podMetricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Timestamp  metav1.Time       `json:"timestamp"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// metrics.k8s.io返回的节点指标
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata  metav1.ObjectMeta   `json:"metadata"`
+		Timestamp metav1.Time         `json:"timestamp"`
+		Usage     corev1.ResourceList `json:"usage"`
+	} `json:"items"`
+}
+
+// 请求metrics.k8s.io，metrics-server不存在或不可用时返回ErrMetricsUnavailable
+func getMetrics(client kubernetes.Interface, path string, params url.Values, out interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsRequestTimeout)
+	defer cancel()
+
+	request := client.Discovery().RESTClient().Get().AbsPath(metricsAPIPath, path)
+	for key, values := range params {
+		for _, value := range values {
+			request = request.Param(key, value)
+		}
+	}
+
+	data, err := request.DoRaw(ctx)
+	if err != nil {
+		if k8serrors.IsNotFound(err) || k8serrors.IsServiceUnavailable(err) || k8serrors.IsTimeout(err) ||
+			errors.Is(err, context.DeadlineExceeded) {
+			return ErrMetricsUnavailable
+		}
+		return fmt.Errorf("获取资源指标失败: %v", err)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("解析资源指标失败: %v", err)
+	}
+	return nil
+}
+
+// 获取Pod指标，namespace为空时获取所有命名空间
+func getPodUsage(client kubernetes.Interface, namespace, labelSelector string) ([]PodUsage, error) {
+	path := "pods"
+	if namespace != "" {
+		path = "namespaces/" + namespace + "/pods"
+	}
+	params := url.Values{}
+	if labelSelector != "" {
+		params.Set("labelSelector", labelSelector)
+	}
+
+	var list podMetricsList
+	if err := getMetrics(client, path, params, &list); err != nil {
+		return nil, err
+	}
+
+	result := make([]PodUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		usage := PodUsage{
+			Name:          item.Metadata.Name,
+			Namespace:     item.Metadata.Namespace,
+			ApplicationID: item.Metadata.Labels["app-id"],
+			Containers:    make([]ContainerUsage, 0, len(item.Containers)),
+			Timestamp:     item.Timestamp.Time,
+		}
+		for _, container := range item.Containers {
+			containerUsage := ContainerUsage{
+				Name:          container.Name,
+				CPUMillicores: container.Usage.Cpu().MilliValue(),
+				MemoryBytes:   container.Usage.Memory().Value(),
+			}
+			usage.CPUMillicores += containerUsage.CPUMillicores
+			usage.MemoryBytes += containerUsage.MemoryBytes
+			usage.Containers = append(usage.Containers, containerUsage)
+		}
+		result = append(result, usage)
+	}
+	return result, nil
+}
+
+// GetPodMetrics 获取命名空间中Pod及其容器的CPU和内存用量
+func (km *K8sManager) GetPodMetrics(kubeConfigID, namespace, labelSelector string) ([]PodUsage, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+	}
+	return getPodUsage(client, namespace, labelSelector)
+}
+
+// GetApplicationMetrics 获取应用各Pod的CPU和内存用量
+func (km *K8sManager) GetApplicationMetrics(app *Application) ([]PodUsage, error) {
+	namespace, _ := applicationTarget(app)
+	return km.GetPodMetrics(app.KubeConfigID, namespace, "app-id="+app.ID)
+}
+
+// GetNodeMetrics 获取节点的CPU和内存用量
+func (km *K8sManager) GetNodeMetrics(kubeConfigID string) ([]NodeUsage, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取Kubernetes客户端失败: %v", err)
+	}
+
+	var list nodeMetricsList
+	if err := getMetrics(client, "nodes", nil, &list); err != nil {
+		return nil, err
+	}
+
+	// 节点可分配资源用于计算使用率，获取失败时不计算百分比
+	allocatable := make(map[string]corev1.ResourceList)
+	if nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, node := range nodes.Items {
+			allocatable[node.Name] = node.Status.Allocatable
+		}
+	} else {
+		log.Printf("获取节点列表失败: %v", err)
+	}
+
+	result := make([]NodeUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		usage := NodeUsage{
+			Name:          item.Metadata.Name,
+			CPUMillicores: item.Usage.Cpu().MilliValue(),
+			MemoryBytes:   item.Usage.Memory().Value(),
+			Timestamp:     item.Timestamp.Time,
+		}
+		if resources, ok := allocatable[item.Metadata.Name]; ok {
+			usage.AllocatableCPUMillicores = resources.Cpu().MilliValue()
+			usage.AllocatableMemoryBytes = resources.Memory().Value()
+			if usage.AllocatableCPUMillicores > 0 {
+				usage.CPUPercent = float64(usage.CPUMillicores) * 100 / float64(usage.AllocatableCPUMillicores)
+			}
+			if usage.AllocatableMemoryBytes > 0 {
+				usage.MemoryPercent = float64(usage.MemoryBytes) * 100 / float64(usage.AllocatableMemoryBytes)
+			}
+		}
+		result = append(result, usage)
+	}
+	return result, nil
+}
@@ -0,0 +1,274 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 等待default ServiceAccount创建的最长时间，ServiceAccount由控制器在命名空间创建后异步生成
+const defaultServiceAccountWait = 10 * time.Second
+
+var (
+	// ErrNamespaceExists 命名空间已存在
+	ErrNamespaceExists = errors.New("命名空间已存在")
+	// ErrProtectedNamespace 系统命名空间不能删除
+	ErrProtectedNamespace = errors.New("系统命名空间不能删除")
+)
+
+// 不允许通过接口删除的命名空间
+var protectedNamespaces = map[string]bool{
+	"default":         true,
+	"kube-system":     true,
+	"kube-public":     true,
+	"kube-node-lease": true,
+}
+
+// NamespaceRequest 创建命名空间的请求，Labels会覆盖初始化配置中的同名标签
+type NamespaceRequest struct {
+	Name           string            `json:"name"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	SkipOnboarding bool              `json:"skipOnboarding"` // 为true时只创建命名空间，不应用初始化配置
+}
+
+// OnboardingStep 初始化配置中一项内容的应用结果
+type OnboardingStep struct {
+	Type    string `json:"type"` // resourceQuota, limitRange, defaultDeny, pullSecret, serviceAccount
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// NamespaceCreateResult 创建命名空间的结果，初始化某一项失败时命名空间仍然保留
+type NamespaceCreateResult struct {
+	Name       string            `json:"name"`
+	Status     string            `json:"status"`
+	Labels     map[string]string `json:"labels"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	Onboarding []OnboardingStep  `json:"onboarding"`
+}
+
+// NamespaceInUseError 命名空间中还有本系统管理的应用
+type NamespaceInUseError struct {
+	Applications []string
+}
+
+func (e *NamespaceInUseError) Error() string {
+	return fmt.Sprintf("命名空间中还有应用: %s，请先删除应用或使用force强制删除", strings.Join(e.Applications, ", "))
+}
+
+// ValidateNamespaceRequest 校验创建命名空间的请求
+func ValidateNamespaceRequest(request *NamespaceRequest) error {
+	if request.Name == "" {
+		return fmt.Errorf("命名空间名称不能为空")
+	}
+	if errs := validation.IsDNS1123Label(request.Name); len(errs) > 0 {
+		return fmt.Errorf("命名空间名称无效: %s", strings.Join(errs, "; "))
+	}
+	return validateLabels(request.Labels)
+}
+
+// CreateNamespace 创建命名空间并应用集群的初始化配置，创建后立即更新命名空间缓存
+func (km *K8sManager) CreateNamespace(kubeConfigID string, request *NamespaceRequest) (*NamespaceCreateResult, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	onboarding := &NamespaceOnboarding{KubeConfigID: kubeConfigID}
+	if !request.SkipOnboarding {
+		if onboarding, err = GetNamespaceOnboardingFromDB(kubeConfigID); err != nil {
+			return nil, err
+		}
+	}
+
+	labels := map[string]string{"managed-by": "cloud-deployment-api"}
+	for key, value := range onboarding.Labels {
+		labels[key] = value
+	}
+	for key, value := range request.Labels {
+		labels[key] = value
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        request.Name,
+			Labels:      labels,
+			Annotations: request.Annotations,
+		},
+	}
+	created, err := client.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	if err != nil {
+		if k8serrors.IsAlreadyExists(err) {
+			return nil, ErrNamespaceExists
+		}
+		return nil, fmt.Errorf("创建命名空间失败: %v", err)
+	}
+	log.Printf("创建命名空间成功: %s (KubeConfigID: %s)", created.Name, kubeConfigID)
+
+	if err := UpsertNamespaceCache(namespaceCacheEntry(kubeConfigID, created)); err != nil {
+		log.Printf("更新命名空间缓存失败 (KubeConfigID: %s, Namespace: %s): %v", kubeConfigID, created.Name, err)
+	}
+
+	return &NamespaceCreateResult{
+		Name:       created.Name,
+		Status:     string(created.Status.Phase),
+		Labels:     created.Labels,
+		CreatedAt:  created.CreationTimestamp.Time,
+		Onboarding: km.applyNamespaceOnboarding(client, kubeConfigID, created.Name, onboarding),
+	}, nil
+}
+
+// 在新命名空间中应用初始化配置，每一项单独执行，失败不影响其他项
+func (km *K8sManager) applyNamespaceOnboarding(client kubernetes.Interface, kubeConfigID, namespace string, onboarding *NamespaceOnboarding) []OnboardingStep {
+	steps := []OnboardingStep{}
+	record := func(stepType, name string, err error) {
+		step := OnboardingStep{Type: stepType, Name: name, Success: err == nil}
+		if err != nil {
+			step.Message = err.Error()
+			log.Printf("初始化命名空间 %s 失败 (%s %s): %v", namespace, stepType, name, err)
+		}
+		steps = append(steps, step)
+	}
+
+	if onboarding.ResourceQuota != nil {
+		_, err := km.CreateResourceQuota(kubeConfigID, namespace, onboarding.ResourceQuota)
+		record("resourceQuota", onboarding.ResourceQuota.Name, err)
+	}
+	if onboarding.LimitRange != nil {
+		_, err := km.CreateLimitRange(kubeConfigID, namespace, onboarding.LimitRange)
+		record("limitRange", onboarding.LimitRange.Name, err)
+	}
+	if onboarding.DefaultDeny {
+		err := km.SetNamespaceDefaultDeny(kubeConfigID, namespace, true, onboarding.DenyEgress)
+		record("defaultDeny", defaultDenyNetworkPolicyName, err)
+	}
+
+	var secretNames []string
+	for _, registryID := range onboarding.RegistryIDs {
+		registry, err := GetRegistryByIDFromDB(registryID)
+		if err != nil {
+			record("pullSecret", registryID, fmt.Errorf("镜像仓库不存在: %v", err))
+			continue
+		}
+		name, err := ensureRegistryPullSecret(client, namespace, registry)
+		record("pullSecret", RegistryPullSecretName(registry), err)
+		if err == nil {
+			secretNames = append(secretNames, name)
+		}
+	}
+	if len(secretNames) > 0 {
+		record("serviceAccount", "default", addDefaultServiceAccountPullSecrets(client, namespace, secretNames))
+	}
+	return steps
+}
+
+// 将镜像拉取Secret添加到命名空间的default ServiceAccount，使未指定imagePullSecrets的Pod也能拉取私有镜像
+func addDefaultServiceAccountPullSecrets(client kubernetes.Interface, namespace string, secretNames []string) error {
+	serviceAccounts := client.CoreV1().ServiceAccounts(namespace)
+
+	deadline := time.Now().Add(defaultServiceAccountWait)
+	for {
+		account, err := serviceAccounts.Get(context.TODO(), "default", metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) && time.Now().Before(deadline) {
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("获取default ServiceAccount失败: %v", err)
+		}
+
+		existing := make(map[string]bool)
+		for _, ref := range account.ImagePullSecrets {
+			existing[ref.Name] = true
+		}
+		for _, name := range secretNames {
+			if !existing[name] {
+				account.ImagePullSecrets = append(account.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+			}
+		}
+
+		_, err = serviceAccounts.Update(context.TODO(), account, metav1.UpdateOptions{})
+		if k8serrors.IsConflict(err) && time.Now().Before(deadline) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("更新default ServiceAccount失败: %v", err)
+		}
+		return nil
+	}
+}
+
+// DeleteNamespace 删除命名空间并立即移除其缓存。命名空间中还有应用时返回NamespaceInUseError，force为true时忽略
+func (km *K8sManager) DeleteNamespace(kubeConfigID, name string, force bool) error {
+	if protectedNamespaces[name] {
+		return ErrProtectedNamespace
+	}
+
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	if !force {
+		apps, err := GetApplicationsFromDB()
+		if err != nil {
+			return fmt.Errorf("获取应用列表失败: %v", err)
+		}
+		var inUse []string
+		for i := range apps {
+			if namespace, _ := applicationTarget(&apps[i]); apps[i].KubeConfigID == kubeConfigID && namespace == name {
+				inUse = append(inUse, apps[i].Name)
+			}
+		}
+		if len(inUse) > 0 {
+			return &NamespaceInUseError{Applications: inUse}
+		}
+	}
+
+	if err := client.CoreV1().Namespaces().Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("删除命名空间失败: %v", err)
+	}
+	log.Printf("删除命名空间成功: %s (KubeConfigID: %s)", name, kubeConfigID)
+
+	if err := DeleteNamespaceCache(kubeConfigID, name); err != nil {
+		log.Printf("%v (KubeConfigID: %s, Namespace: %s)", err, kubeConfigID, name)
+	}
+	return nil
+}
+
+// 将命名空间转换为缓存记录
+func namespaceCacheEntry(kubeConfigID string, namespace *corev1.Namespace) *NamespaceCache {
+	labels, annotations := "{}", "{}"
+	if len(namespace.Labels) > 0 {
+		data, _ := json.Marshal(namespace.Labels)
+		labels = string(data)
+	}
+	if len(namespace.Annotations) > 0 {
+		data, _ := json.Marshal(namespace.Annotations)
+		annotations = string(data)
+	}
+
+	status := string(namespace.Status.Phase)
+	if status == "" {
+		status = string(corev1.NamespaceActive)
+	}
+	return &NamespaceCache{
+		KubeConfigID: kubeConfigID,
+		Name:         namespace.Name,
+		Status:       status,
+		Labels:       labels,
+		Annotations:  annotations,
+	}
+}
@@ -0,0 +1,349 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 命名空间默认拒绝策略的名称
+const defaultDenyNetworkPolicyName = "default-deny"
+
+// ValidateNetworkPolicyConfig 校验网络策略配置
+func ValidateNetworkPolicyConfig(config *NetworkPolicyConfig) error {
+	if config == nil {
+		return nil
+	}
+
+	validatePeers := func(peers []NetworkPolicyPeer) error {
+		for _, peer := range peers {
+			set := 0
+			if peer.Application != "" || len(peer.PodSelector) > 0 {
+				set++
+			}
+			if peer.CIDR != "" {
+				set++
+			}
+			if set == 0 && peer.Namespace == "" && len(peer.NamespaceSelector) == 0 {
+				return fmt.Errorf("网络策略来源/目标必须指定应用、命名空间或CIDR")
+			}
+			if peer.CIDR != "" && (set > 1 || peer.Namespace != "" || len(peer.NamespaceSelector) > 0) {
+				return fmt.Errorf("CIDR不能与应用或命名空间同时指定")
+			}
+			if len(peer.Except) > 0 && peer.CIDR == "" {
+				return fmt.Errorf("except只能与CIDR一起使用")
+			}
+		}
+		return nil
+	}
+	validatePorts := func(ports []NetworkPolicyPort) error {
+		for _, port := range ports {
+			if port.Port < 0 || port.Port > 65535 {
+				return fmt.Errorf("无效的端口: %d", port.Port)
+			}
+			switch strings.ToUpper(port.Protocol) {
+			case "", "TCP", "UDP", "SCTP":
+			default:
+				return fmt.Errorf("不支持的协议: %s", port.Protocol)
+			}
+		}
+		return nil
+	}
+
+	for _, rule := range config.Ingress {
+		if err := validatePeers(rule.From); err != nil {
+			return err
+		}
+		if err := validatePorts(rule.Ports); err != nil {
+			return err
+		}
+	}
+	for _, rule := range config.Egress {
+		if err := validatePeers(rule.To); err != nil {
+			return err
+		}
+		if err := validatePorts(rule.Ports); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 将网络策略来源/目标转换为Kubernetes NetworkPolicyPeer
+func convertNetworkPolicyPeer(peer NetworkPolicyPeer) networkingv1.NetworkPolicyPeer {
+	if peer.CIDR != "" {
+		return networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{
+				CIDR:   peer.CIDR,
+				Except: peer.Except,
+			},
+		}
+	}
+
+	k8sPeer := networkingv1.NetworkPolicyPeer{}
+
+	// 应用名称对应Pod上的app标签
+	if peer.Application != "" || len(peer.PodSelector) > 0 {
+		matchLabels := map[string]string{}
+		for k, v := range peer.PodSelector {
+			matchLabels[k] = v
+		}
+		if peer.Application != "" {
+			matchLabels["app"] = peer.Application
+		}
+		k8sPeer.PodSelector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	}
+
+	// 命名空间名称对应kubernetes.io/metadata.name标签
+	if peer.Namespace != "" || len(peer.NamespaceSelector) > 0 {
+		matchLabels := map[string]string{}
+		for k, v := range peer.NamespaceSelector {
+			matchLabels[k] = v
+		}
+		if peer.Namespace != "" {
+			matchLabels[corev1.LabelMetadataName] = peer.Namespace
+		}
+		k8sPeer.NamespaceSelector = &metav1.LabelSelector{MatchLabels: matchLabels}
+	}
+
+	return k8sPeer
+}
+
+// 将端口配置转换为Kubernetes NetworkPolicyPort
+func convertNetworkPolicyPorts(ports []NetworkPolicyPort) []networkingv1.NetworkPolicyPort {
+	var k8sPorts []networkingv1.NetworkPolicyPort
+	for _, port := range ports {
+		protocol := corev1.ProtocolTCP
+		if port.Protocol != "" {
+			protocol = corev1.Protocol(strings.ToUpper(port.Protocol))
+		}
+		k8sPort := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+		if port.Port > 0 {
+			p := intstr.FromInt(int(port.Port))
+			k8sPort.Port = &p
+		}
+		k8sPorts = append(k8sPorts, k8sPort)
+	}
+	return k8sPorts
+}
+
+// 允许访问集群DNS的出站规则
+func dnsEgressRule() networkingv1.NetworkPolicyEgressRule {
+	udp := corev1.ProtocolUDP
+	tcp := corev1.ProtocolTCP
+	port := intstr.FromInt(53)
+	return networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{corev1.LabelMetadataName: "kube-system"},
+				},
+			},
+		},
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: &port},
+			{Protocol: &tcp, Port: &port},
+		},
+	}
+}
+
+// 根据应用声明的依赖关系构建NetworkPolicy
+func buildApplicationNetworkPolicy(app *Application, namespace string, appName string) *networkingv1.NetworkPolicy {
+	config := app.NetworkPolicy
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":        appName,
+				"managed-by": "cloud-deployment-api",
+				"app-id":     app.ID,
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": appName},
+			},
+			// 声明了网络策略即表示只允许列出的来源访问
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress:     []networkingv1.NetworkPolicyIngressRule{},
+		},
+	}
+
+	for _, rule := range config.Ingress {
+		k8sRule := networkingv1.NetworkPolicyIngressRule{
+			Ports: convertNetworkPolicyPorts(rule.Ports),
+		}
+		for _, peer := range rule.From {
+			k8sRule.From = append(k8sRule.From, convertNetworkPolicyPeer(peer))
+		}
+		policy.Spec.Ingress = append(policy.Spec.Ingress, k8sRule)
+	}
+
+	// 只有声明了出站白名单时才限制出站流量
+	if len(config.Egress) > 0 {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		if config.AllowDNS == nil || *config.AllowDNS {
+			policy.Spec.Egress = append(policy.Spec.Egress, dnsEgressRule())
+		}
+		for _, rule := range config.Egress {
+			k8sRule := networkingv1.NetworkPolicyEgressRule{
+				Ports: convertNetworkPolicyPorts(rule.Ports),
+			}
+			for _, peer := range rule.To {
+				k8sRule.To = append(k8sRule.To, convertNetworkPolicyPeer(peer))
+			}
+			policy.Spec.Egress = append(policy.Spec.Egress, k8sRule)
+		}
+	}
+
+	return policy
+}
+
+// 创建、更新或删除应用的NetworkPolicy
+func applyApplicationNetworkPolicy(client kubernetes.Interface, app *Application, namespace string, appName string) error {
+	policies := client.NetworkingV1().NetworkPolicies(namespace)
+	existing, err := policies.Get(context.TODO(), appName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("获取NetworkPolicy失败: %v", err)
+	}
+	exists := err == nil
+
+	// 未声明网络策略时，删除之前由本系统生成的策略
+	if app.NetworkPolicy == nil {
+		if exists && existing.Labels["managed-by"] == "cloud-deployment-api" {
+			log.Printf("应用未声明网络策略，删除NetworkPolicy: %s/%s", namespace, appName)
+			if err := policies.Delete(context.TODO(), appName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("删除NetworkPolicy失败: %v", err)
+			}
+		}
+		return nil
+	}
+
+	policy := buildApplicationNetworkPolicy(app, namespace, appName)
+	if !exists {
+		if _, err := policies.Create(context.TODO(), policy, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("创建NetworkPolicy失败: %v", err)
+		}
+		log.Printf("创建NetworkPolicy成功: %s/%s", namespace, appName)
+		return nil
+	}
+
+	policy.ResourceVersion = existing.ResourceVersion
+	if _, err := policies.Update(context.TODO(), policy, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("更新NetworkPolicy失败: %v", err)
+	}
+	log.Printf("更新NetworkPolicy成功: %s/%s", namespace, appName)
+	return nil
+}
+
+// GetNamespaceDefaultDeny 获取命名空间默认拒绝策略的状态
+func (km *K8sManager) GetNamespaceDefaultDeny(kubeConfigId, namespace string) (map[string]interface{}, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	policy, err := client.NetworkingV1().NetworkPolicies(namespace).Get(context.TODO(), defaultDenyNetworkPolicyName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return map[string]interface{}{
+				"namespace":   namespace,
+				"enabled":     false,
+				"denyIngress": false,
+				"denyEgress":  false,
+			}, nil
+		}
+		return nil, fmt.Errorf("获取默认拒绝策略失败: %v", err)
+	}
+
+	denyIngress, denyEgress := false, false
+	for _, t := range policy.Spec.PolicyTypes {
+		switch t {
+		case networkingv1.PolicyTypeIngress:
+			denyIngress = true
+		case networkingv1.PolicyTypeEgress:
+			denyEgress = true
+		}
+	}
+
+	return map[string]interface{}{
+		"namespace":   namespace,
+		"enabled":     true,
+		"denyIngress": denyIngress,
+		"denyEgress":  denyEgress,
+		"createdAt":   policy.CreationTimestamp.Time,
+	}, nil
+}
+
+// SetNamespaceDefaultDeny 开启或关闭命名空间默认拒绝策略
+// 开启后命名空间内所有Pod默认拒绝入站流量（可选拒绝出站流量），只有应用声明的规则放行的流量可达
+func (km *K8sManager) SetNamespaceDefaultDeny(kubeConfigId, namespace string, enabled bool, denyEgress bool) error {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	policies := client.NetworkingV1().NetworkPolicies(namespace)
+	existing, err := policies.Get(context.TODO(), defaultDenyNetworkPolicyName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("获取默认拒绝策略失败: %v", err)
+	}
+	exists := err == nil
+
+	if !enabled {
+		if !exists {
+			return nil
+		}
+		if existing.Labels["managed-by"] != "cloud-deployment-api" {
+			return fmt.Errorf("NetworkPolicy %s/%s 不是由本系统创建的，请手动删除", namespace, defaultDenyNetworkPolicyName)
+		}
+		if err := policies.Delete(context.TODO(), defaultDenyNetworkPolicyName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("删除默认拒绝策略失败: %v", err)
+		}
+		log.Printf("已关闭命名空间默认拒绝策略: %s", namespace)
+		return nil
+	}
+
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      defaultDenyNetworkPolicyName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"managed-by": "cloud-deployment-api",
+			},
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+	if denyEgress {
+		policy.Spec.PolicyTypes = append(policy.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		// 拒绝出站时仍允许访问集群DNS，否则服务发现将失效
+		policy.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{dnsEgressRule()}
+	}
+
+	if !exists {
+		if _, err := policies.Create(context.TODO(), policy, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("创建默认拒绝策略失败: %v", err)
+		}
+	} else {
+		policy.ResourceVersion = existing.ResourceVersion
+		if _, err := policies.Update(context.TODO(), policy, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("更新默认拒绝策略失败: %v", err)
+		}
+	}
+
+	log.Printf("已开启命名空间默认拒绝策略: %s (拒绝出站: %v)", namespace, denyEgress)
+	return nil
+}
@@ -0,0 +1,418 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/util/retry"
+)
+
+// 节点生命周期控制器维护的污点前缀，不能通过接口修改
+const systemTaintPrefix = "node.kubernetes.io/"
+
+// ErrNodeNotFound 节点不存在
+var ErrNodeNotFound = errors.New("节点不存在")
+
+// NodeCondition 节点状态条件
+type NodeCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// NodeTaint 节点污点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"` // NoSchedule, PreferNoSchedule, NoExecute
+}
+
+// NodeInfo 节点信息，Requested为节点上未结束Pod的资源请求之和
+type NodeInfo struct {
+	Name           string            `json:"name"`
+	Ready          bool              `json:"ready"`
+	Unschedulable  bool              `json:"unschedulable"`
+	Roles          []string          `json:"roles"`
+	InternalIP     string            `json:"internalIP,omitempty"`
+	KubeletVersion string            `json:"kubeletVersion"`
+	OSImage        string            `json:"osImage"`
+	Architecture   string            `json:"architecture"`
+	Capacity       map[string]string `json:"capacity"`
+	Allocatable    map[string]string `json:"allocatable"`
+	Requested      map[string]string `json:"requested"`
+	Conditions     []NodeCondition   `json:"conditions"`
+	Labels         map[string]string `json:"labels"`
+	Taints         []NodeTaint       `json:"taints"`
+	PodCount       int               `json:"podCount"`
+	CreatedAt      time.Time         `json:"createdAt"`
+}
+
+// NodePod 运行在节点上的Pod
+type NodePod struct {
+	Name                 string `json:"name"`
+	Namespace            string `json:"namespace"`
+	Phase                string `json:"phase"`
+	ApplicationID        string `json:"applicationId,omitempty"`
+	OwnerKind            string `json:"ownerKind,omitempty"`
+	CPURequestMillicores int64  `json:"cpuRequestMillicores"`
+	MemoryRequestBytes   int64  `json:"memoryRequestBytes"`
+}
+
+// NodeDetail 节点信息及节点上的Pod
+type NodeDetail struct {
+	NodeInfo
+	Pods []NodePod `json:"pods"`
+}
+
+// NodeLabelsUpdate 节点标签修改，Set中的标签新增或覆盖，Remove中的标签删除
+type NodeLabelsUpdate struct {
+	Set    map[string]string `json:"set"`
+	Remove []string          `json:"remove"`
+}
+
+// ValidateNodeLabelsUpdate 验证节点标签修改
+func ValidateNodeLabelsUpdate(update *NodeLabelsUpdate) error {
+	if len(update.Set) == 0 && len(update.Remove) == 0 {
+		return fmt.Errorf("set和remove不能同时为空")
+	}
+	if err := validateLabels(update.Set); err != nil {
+		return err
+	}
+	for _, key := range update.Remove {
+		if _, ok := update.Set[key]; ok {
+			return fmt.Errorf("标签 %s 不能同时设置和删除", key)
+		}
+	}
+	return nil
+}
+
+// ValidateNodeTaints 验证节点污点，系统维护的污点不能修改
+func ValidateNodeTaints(taints []NodeTaint) error {
+	seen := make(map[string]bool)
+	for _, taint := range taints {
+		if errs := validation.IsQualifiedName(taint.Key); len(errs) > 0 {
+			return fmt.Errorf("污点键 %s 无效: %s", taint.Key, strings.Join(errs, "; "))
+		}
+		if strings.HasPrefix(taint.Key, systemTaintPrefix) {
+			return fmt.Errorf("污点 %s 由Kubernetes维护，不能修改", taint.Key)
+		}
+		if taint.Value != "" {
+			if errs := validation.IsValidLabelValue(taint.Value); len(errs) > 0 {
+				return fmt.Errorf("污点 %s 的值无效: %s", taint.Key, strings.Join(errs, "; "))
+			}
+		}
+		switch corev1.TaintEffect(taint.Effect) {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("污点 %s 的effect无效，应为NoSchedule、PreferNoSchedule或NoExecute", taint.Key)
+		}
+		if seen[taint.Key+":"+taint.Effect] {
+			return fmt.Errorf("污点 %s:%s 重复", taint.Key, taint.Effect)
+		}
+		seen[taint.Key+":"+taint.Effect] = true
+	}
+	return nil
+}
+
+// ListCachedNodes 从缓存获取节点列表
+func (km *K8sManager) ListCachedNodes(kubeConfigID string) ([]corev1.Node, error) {
+	objs, err := km.listCached(kubeConfigID, "nodes", "")
+	if err != nil {
+		return nil, err
+	}
+	items := make([]corev1.Node, 0, len(objs))
+	for _, obj := range objs {
+		if node, ok := obj.(*corev1.Node); ok {
+			items = append(items, *node)
+		}
+	}
+	return items, nil
+}
+
+// GetNodes 获取集群节点列表及每个节点上的Pod数量和资源请求
+func (km *K8sManager) GetNodes(kubeConfigID string) ([]NodeInfo, error) {
+	nodes, err := km.ListCachedNodes(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %v", err)
+	}
+	pods, err := km.ListCachedPods(kubeConfigID, "")
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %v", err)
+	}
+
+	podsByNode := make(map[string][]corev1.Pod)
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" && !podFinished(&pod) {
+			podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		}
+	}
+
+	result := make([]NodeInfo, 0, len(nodes))
+	for i := range nodes {
+		result = append(result, convertNode(&nodes[i], podsByNode[nodes[i].Name]))
+	}
+	return result, nil
+}
+
+// GetNode 获取节点详情及节点上的Pod
+func (km *K8sManager) GetNode(kubeConfigID, name string) (*NodeDetail, error) {
+	nodes, err := km.ListCachedNodes(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %v", err)
+	}
+	var node *corev1.Node
+	for i := range nodes {
+		if nodes[i].Name == name {
+			node = &nodes[i]
+			break
+		}
+	}
+	if node == nil {
+		return nil, ErrNodeNotFound
+	}
+
+	nodePods, err := km.nodePods(kubeConfigID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &NodeDetail{NodeInfo: convertNode(node, nodePods), Pods: make([]NodePod, 0, len(nodePods))}
+	for i := range nodePods {
+		pod := &nodePods[i]
+		requests := podRequests(pod)
+		nodePod := NodePod{
+			Name:                 pod.Name,
+			Namespace:            pod.Namespace,
+			Phase:                string(pod.Status.Phase),
+			ApplicationID:        pod.Labels["app-id"],
+			CPURequestMillicores: requests.Cpu().MilliValue(),
+			MemoryRequestBytes:   requests.Memory().Value(),
+		}
+		if owner := metav1.GetControllerOf(pod); owner != nil {
+			nodePod.OwnerKind = owner.Kind
+		}
+		detail.Pods = append(detail.Pods, nodePod)
+	}
+	return detail, nil
+}
+
+// 从缓存获取节点上未结束的Pod
+func (km *K8sManager) nodePods(kubeConfigID, name string) ([]corev1.Pod, error) {
+	pods, err := km.ListCachedPods(kubeConfigID, "")
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %v", err)
+	}
+	var result []corev1.Pod
+	for _, pod := range pods {
+		if pod.Spec.NodeName == name && !podFinished(&pod) {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
+
+// 修改节点后返回的节点信息，Pod统计来自缓存，获取失败时不影响修改结果
+func (km *K8sManager) updatedNodeInfo(kubeConfigID string, node *corev1.Node) *NodeInfo {
+	pods, err := km.nodePods(kubeConfigID, node.Name)
+	if err != nil {
+		log.Printf("获取节点 %s 上的Pod失败: %v", node.Name, err)
+	}
+	info := convertNode(node, pods)
+	return &info
+}
+
+// CordonNode 设置节点是否可调度，unschedulable为true时禁止新Pod调度到节点
+func (km *K8sManager) CordonNode(kubeConfigID, name string, unschedulable bool) (*NodeInfo, error) {
+	node, err := km.updateNode(kubeConfigID, name, func(node *corev1.Node) {
+		node.Spec.Unschedulable = unschedulable
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if unschedulable {
+		log.Printf("节点 %s 已禁止调度", name)
+	} else {
+		log.Printf("节点 %s 已恢复调度", name)
+	}
+	return km.updatedNodeInfo(kubeConfigID, node), nil
+}
+
+// UpdateNodeLabels 修改节点标签
+func (km *K8sManager) UpdateNodeLabels(kubeConfigID, name string, update *NodeLabelsUpdate) (*NodeInfo, error) {
+	node, err := km.updateNode(kubeConfigID, name, func(node *corev1.Node) {
+		if node.Labels == nil {
+			node.Labels = make(map[string]string)
+		}
+		for key, value := range update.Set {
+			node.Labels[key] = value
+		}
+		for _, key := range update.Remove {
+			delete(node.Labels, key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("更新节点 %s 的标签成功", name)
+	return km.updatedNodeInfo(kubeConfigID, node), nil
+}
+
+// SetNodeTaints 替换节点上的污点，系统维护的污点保持不变
+func (km *K8sManager) SetNodeTaints(kubeConfigID, name string, taints []NodeTaint) (*NodeInfo, error) {
+	node, err := km.updateNode(kubeConfigID, name, func(node *corev1.Node) {
+		var result []corev1.Taint
+		for _, taint := range node.Spec.Taints {
+			if strings.HasPrefix(taint.Key, systemTaintPrefix) {
+				result = append(result, taint)
+			}
+		}
+		for _, taint := range taints {
+			result = append(result, corev1.Taint{
+				Key:    taint.Key,
+				Value:  taint.Value,
+				Effect: corev1.TaintEffect(taint.Effect),
+			})
+		}
+		node.Spec.Taints = result
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("更新节点 %s 的污点成功", name)
+	return km.updatedNodeInfo(kubeConfigID, node), nil
+}
+
+// 读取节点并修改后更新，版本冲突时重试
+func (km *K8sManager) updateNode(kubeConfigID, name string, mutate func(node *corev1.Node)) (*corev1.Node, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	var updated *corev1.Node
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		node, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return ErrNodeNotFound
+		}
+		if err != nil {
+			return err
+		}
+		mutate(node)
+		updated, err = client.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+		return err
+	})
+	if err == ErrNodeNotFound {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("更新节点 %s 失败: %v", name, err)
+	}
+	return updated, nil
+}
+
+func convertNode(node *corev1.Node, pods []corev1.Pod) NodeInfo {
+	info := NodeInfo{
+		Name:           node.Name,
+		Unschedulable:  node.Spec.Unschedulable,
+		Roles:          []string{},
+		KubeletVersion: node.Status.NodeInfo.KubeletVersion,
+		OSImage:        node.Status.NodeInfo.OSImage,
+		Architecture:   node.Status.NodeInfo.Architecture,
+		Capacity:       resourceListToMap(node.Status.Capacity),
+		Allocatable:    resourceListToMap(node.Status.Allocatable),
+		Conditions:     make([]NodeCondition, 0, len(node.Status.Conditions)),
+		Labels:         node.Labels,
+		Taints:         make([]NodeTaint, 0, len(node.Spec.Taints)),
+		PodCount:       len(pods),
+		CreatedAt:      node.CreationTimestamp.Time,
+	}
+
+	for key := range node.Labels {
+		if strings.HasPrefix(key, "node-role.kubernetes.io/") {
+			info.Roles = append(info.Roles, strings.TrimPrefix(key, "node-role.kubernetes.io/"))
+		}
+	}
+	sort.Strings(info.Roles)
+
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			info.InternalIP = address.Address
+			break
+		}
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			info.Ready = condition.Status == corev1.ConditionTrue
+		}
+		info.Conditions = append(info.Conditions, NodeCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time,
+		})
+	}
+
+	for _, taint := range node.Spec.Taints {
+		info.Taints = append(info.Taints, NodeTaint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+	}
+
+	requested := corev1.ResourceList{}
+	for i := range pods {
+		for name, quantity := range podRequests(&pods[i]) {
+			total := requested[name]
+			total.Add(quantity)
+			requested[name] = total
+		}
+	}
+	info.Requested = resourceListToMap(requested)
+	return info
+}
+
+// Pod的有效资源请求，取所有容器请求之和与各初始化容器请求中的较大值
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
+
+func resourceListToMap(list corev1.ResourceList) map[string]string {
+	result := make(map[string]string, len(list))
+	for name, quantity := range list {
+		result[string(name)] = quantity.String()
+	}
+	return result
+}
+
+func podFinished(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed
+}
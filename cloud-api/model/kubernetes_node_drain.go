@@ -0,0 +1,333 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// 驱逐被PodDisruptionBudget阻止时的重试间隔
+	drainRetryInterval  = 5 * time.Second
+	defaultDrainTimeout = 5 * time.Minute
+	maxDrainTimeout     = time.Hour
+)
+
+// ErrDrainInProgress 节点正在排空
+var ErrDrainInProgress = errors.New("节点正在排空，请等待当前排空结束")
+
+// DrainOptions 节点排空选项
+type DrainOptions struct {
+	// 等待所有Pod被驱逐的最长时间，默认300秒
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// Pod的优雅终止时间，为空时使用Pod自身的设置
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+	// 是否驱逐没有控制器管理的Pod，这些Pod被驱逐后不会重建
+	Force bool `json:"force"`
+	// 是否驱逐使用emptyDir的Pod，emptyDir中的数据会丢失
+	DeleteEmptyDirData bool `json:"deleteEmptyDirData"`
+}
+
+// DrainPodStatus 排空过程中单个Pod的状态
+type DrainPodStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Status    string `json:"status"` // pending, evicting, blocked, evicted, failed
+	Message   string `json:"message,omitempty"`
+}
+
+// NodeDrain 节点排空进度
+type NodeDrain struct {
+	KubeConfigID string           `json:"kubeConfigId"`
+	Node         string           `json:"node"`
+	Status       string           `json:"status"` // running, succeeded, failed
+	Message      string           `json:"message,omitempty"`
+	Total        int              `json:"total"`
+	Evicted      int              `json:"evicted"`
+	Skipped      []string         `json:"skipped"` // 不需要驱逐的DaemonSet和静态Pod
+	Pods         []DrainPodStatus `json:"pods"`
+	StartedAt    time.Time        `json:"startedAt"`
+	FinishedAt   *time.Time       `json:"finishedAt,omitempty"`
+}
+
+// DrainRejectedError 节点上有不允许驱逐的Pod，未开始排空
+type DrainRejectedError struct {
+	Pods []DrainPodStatus
+}
+
+func (e *DrainRejectedError) Error() string {
+	names := make([]string, 0, len(e.Pods))
+	for _, pod := range e.Pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return fmt.Sprintf("以下Pod不能驱逐: %s", strings.Join(names, ", "))
+}
+
+var (
+	nodeDrainsMu sync.Mutex
+	// 每个节点最近一次排空的进度，键为kubeConfigID/节点名
+	nodeDrains = make(map[string]*NodeDrain)
+)
+
+// ValidateDrainOptions 验证排空选项并设置默认值
+func ValidateDrainOptions(options *DrainOptions) error {
+	if options.TimeoutSeconds == 0 {
+		options.TimeoutSeconds = int(defaultDrainTimeout.Seconds())
+	}
+	if options.TimeoutSeconds < 0 || time.Duration(options.TimeoutSeconds)*time.Second > maxDrainTimeout {
+		return fmt.Errorf("timeoutSeconds应在1到%d之间", int(maxDrainTimeout.Seconds()))
+	}
+	if options.GracePeriodSeconds != nil && *options.GracePeriodSeconds < 0 {
+		return fmt.Errorf("gracePeriodSeconds不能为负数")
+	}
+	return nil
+}
+
+// GetNodeDrain 获取节点最近一次排空的进度，没有记录时返回nil
+func GetNodeDrain(kubeConfigID, node string) *NodeDrain {
+	nodeDrainsMu.Lock()
+	defer nodeDrainsMu.Unlock()
+	drain, ok := nodeDrains[kubeConfigID+"/"+node]
+	if !ok {
+		return nil
+	}
+	snapshot := *drain
+	snapshot.Skipped = append([]string{}, drain.Skipped...)
+	snapshot.Pods = append([]DrainPodStatus{}, drain.Pods...)
+	return &snapshot
+}
+
+// DrainNode 禁止节点调度并通过驱逐API逐个驱逐节点上的Pod，驱逐遵守PodDisruptionBudget。
+// 节点上有不允许驱逐的Pod时返回DrainRejectedError且不做任何修改；排空在后台进行，通过GetNodeDrain查询进度
+func (km *K8sManager) DrainNode(kubeConfigID, name string, options DrainOptions) (*NodeDrain, error) {
+	client, err := km.GetClient(kubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	key := kubeConfigID + "/" + name
+	nodeDrainsMu.Lock()
+	previous, hasPrevious := nodeDrains[key]
+	if hasPrevious && previous.Status == "running" {
+		nodeDrainsMu.Unlock()
+		return nil, ErrDrainInProgress
+	}
+	drain := &NodeDrain{
+		KubeConfigID: kubeConfigID,
+		Node:         name,
+		Status:       "running",
+		Skipped:      []string{},
+		Pods:         []DrainPodStatus{},
+		StartedAt:    time.Now(),
+	}
+	nodeDrains[key] = drain
+	nodeDrainsMu.Unlock()
+
+	// 排空未能开始时恢复之前的进度记录，避免留下一直处于running的进度
+	started := false
+	defer func() {
+		if !started {
+			nodeDrainsMu.Lock()
+			if hasPrevious {
+				nodeDrains[key] = previous
+			} else {
+				delete(nodeDrains, key)
+			}
+			nodeDrainsMu.Unlock()
+		}
+	}()
+
+	if _, err := client.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{}); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, ErrNodeNotFound
+		}
+		return nil, fmt.Errorf("获取节点失败: %v", err)
+	}
+
+	pods, err := client.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", name).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点上的Pod失败: %v", err)
+	}
+
+	skipped := []string{}
+	targets := []DrainPodStatus{}
+	var rejected []DrainPodStatus
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if drainSkipped(pod) {
+			skipped = append(skipped, pod.Namespace+"/"+pod.Name)
+			continue
+		}
+		if reason := drainRejectReason(pod, options); reason != "" {
+			rejected = append(rejected, DrainPodStatus{Name: pod.Name, Namespace: pod.Namespace, Status: "failed", Message: reason})
+			continue
+		}
+		targets = append(targets, DrainPodStatus{Name: pod.Name, Namespace: pod.Namespace, Status: "pending"})
+	}
+	if len(rejected) > 0 {
+		return nil, &DrainRejectedError{Pods: rejected}
+	}
+
+	if _, err := km.CordonNode(kubeConfigID, name, true); err != nil {
+		return nil, err
+	}
+
+	nodeDrainsMu.Lock()
+	drain.Skipped = skipped
+	drain.Pods = targets
+	drain.Total = len(targets)
+	nodeDrainsMu.Unlock()
+
+	started = true
+	log.Printf("开始排空节点 %s，需要驱逐%d个Pod", name, drain.Total)
+	go km.runNodeDrain(drain, pods.Items, options)
+	return GetNodeDrain(kubeConfigID, name), nil
+}
+
+// 不需要驱逐的Pod：DaemonSet的Pod会被重新调度回节点，静态Pod由kubelet管理
+func drainSkipped(pod *corev1.Pod) bool {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// 未经确认不能驱逐的Pod
+func drainRejectReason(pod *corev1.Pod, options DrainOptions) string {
+	if podFinished(pod) {
+		return ""
+	}
+	if !options.Force && metav1.GetControllerOf(pod) == nil {
+		return "Pod没有控制器管理，驱逐后不会重建，需要设置force"
+	}
+	if !options.DeleteEmptyDirData {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil {
+				return "Pod使用emptyDir，驱逐后数据会丢失，需要设置deleteEmptyDirData"
+			}
+		}
+	}
+	return ""
+}
+
+// 在后台驱逐Pod直到全部被删除或超时。被PodDisruptionBudget阻止的Pod稍后重试，不影响其他Pod的驱逐
+func (km *K8sManager) runNodeDrain(drain *NodeDrain, pods []corev1.Pod, options DrainOptions) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("排空节点 %s 时发生panic: %v", drain.Node, r)
+			km.finishNodeDrain(drain, "failed", fmt.Sprintf("排空异常终止: %v", r))
+		}
+	}()
+
+	uids := make(map[string]string, len(pods))
+	for _, pod := range pods {
+		uids[pod.Namespace+"/"+pod.Name] = string(pod.UID)
+	}
+
+	deadline := time.Now().Add(time.Duration(options.TimeoutSeconds) * time.Second)
+	for {
+		client, err := km.GetClient(drain.KubeConfigID)
+		if err != nil {
+			km.finishNodeDrain(drain, "failed", fmt.Sprintf("获取客户端失败: %v", err))
+			return
+		}
+
+		remaining := 0
+		for i := range drain.Pods {
+			status, message := drainPod(client, drain.Pods[i], uids[drain.Pods[i].Namespace+"/"+drain.Pods[i].Name], options)
+			nodeDrainsMu.Lock()
+			if drain.Pods[i].Status != "evicted" && status == "evicted" {
+				drain.Evicted++
+			}
+			drain.Pods[i].Status = status
+			drain.Pods[i].Message = message
+			nodeDrainsMu.Unlock()
+			if status != "evicted" {
+				remaining++
+			}
+		}
+
+		if remaining == 0 {
+			km.finishNodeDrain(drain, "succeeded", "")
+			return
+		}
+		if time.Now().After(deadline) {
+			nodeDrainsMu.Lock()
+			for i := range drain.Pods {
+				if drain.Pods[i].Status != "evicted" {
+					drain.Pods[i].Status = "failed"
+				}
+			}
+			nodeDrainsMu.Unlock()
+			km.finishNodeDrain(drain, "failed", fmt.Sprintf("排空超时，%d个Pod未能驱逐", remaining))
+			return
+		}
+		time.Sleep(drainRetryInterval)
+	}
+}
+
+// 推进单个Pod的驱逐，返回Pod的新状态
+func drainPod(client kubernetes.Interface, current DrainPodStatus, uid string, options DrainOptions) (string, string) {
+	if current.Status == "evicted" {
+		return current.Status, current.Message
+	}
+
+	// Pod已删除或已被同名的新Pod替代时视为驱逐完成
+	pod, err := client.CoreV1().Pods(current.Namespace).Get(context.TODO(), current.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) || (err == nil && string(pod.UID) != uid) {
+		return "evicted", ""
+	}
+	if err != nil {
+		return current.Status, fmt.Sprintf("获取Pod状态失败: %v", err)
+	}
+	if current.Status == "evicting" {
+		return "evicting", "等待Pod终止"
+	}
+
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: current.Name, Namespace: current.Namespace},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: options.GracePeriodSeconds,
+			Preconditions:      metav1.NewUIDPreconditions(uid),
+		},
+	}
+	err = client.PolicyV1().Evictions(current.Namespace).Evict(context.TODO(), eviction)
+	switch {
+	case err == nil:
+		return "evicting", "等待Pod终止"
+	case k8serrors.IsNotFound(err):
+		return "evicted", ""
+	case k8serrors.IsTooManyRequests(err):
+		return "blocked", fmt.Sprintf("驱逐被PodDisruptionBudget阻止，稍后重试: %v", err)
+	default:
+		return "pending", fmt.Sprintf("驱逐失败，稍后重试: %v", err)
+	}
+}
+
+func (km *K8sManager) finishNodeDrain(drain *NodeDrain, status, message string) {
+	nodeDrainsMu.Lock()
+	defer nodeDrainsMu.Unlock()
+	now := time.Now()
+	drain.Status = status
+	drain.Message = message
+	drain.FinishedAt = &now
+	if status == "succeeded" {
+		log.Printf("节点 %s 排空完成，驱逐%d个Pod", drain.Node, drain.Evicted)
+	} else {
+		log.Printf("节点 %s 排空失败: %s", drain.Node, message)
+	}
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 将"2"或"50%"形式的字符串解析为IntOrString
+func parseIntOrPercent(value string) (intstr.IntOrString, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return intstr.IntOrString{}, fmt.Errorf("无效的百分比: %s", value)
+		}
+		return intstr.FromString(value), nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return intstr.IntOrString{}, fmt.Errorf("无效的数值: %s", value)
+	}
+	return intstr.FromInt(n), nil
+}
+
+// ValidateDisruptionBudget 校验中断预算配置，minAvailable和maxUnavailable必须且只能指定一个
+func ValidateDisruptionBudget(budget *DisruptionBudget) error {
+	if budget == nil {
+		return nil
+	}
+	if (budget.MinAvailable == "") == (budget.MaxUnavailable == "") {
+		return fmt.Errorf("minAvailable和maxUnavailable必须且只能指定一个")
+	}
+	if budget.MinAvailable != "" {
+		if _, err := parseIntOrPercent(budget.MinAvailable); err != nil {
+			return fmt.Errorf("minAvailable%v", err)
+		}
+	}
+	if budget.MaxUnavailable != "" {
+		if _, err := parseIntOrPercent(budget.MaxUnavailable); err != nil {
+			return fmt.Errorf("maxUnavailable%v", err)
+		}
+	}
+	return nil
+}
+
+// 根据应用配置构建PodDisruptionBudget
+func buildApplicationPDB(app *Application, namespace string, appName string) (*policyv1.PodDisruptionBudget, error) {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":        appName,
+				"managed-by": "cloud-deployment-api",
+				"app-id":     app.ID,
+			},
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": appName},
+			},
+		},
+	}
+
+	budget := app.DisruptionBudget
+	if budget.MinAvailable != "" {
+		minAvailable, err := parseIntOrPercent(budget.MinAvailable)
+		if err != nil {
+			return nil, fmt.Errorf("minAvailable%v", err)
+		}
+		pdb.Spec.MinAvailable = &minAvailable
+
+		// minAvailable不小于副本数时，驱逐将被永久阻塞
+		if minAvailable.Type == intstr.Int && app.Replicas > 0 && minAvailable.IntValue() >= app.Replicas {
+			log.Printf("警告: 应用 %s 的minAvailable(%d)不小于副本数(%d)，节点排空将无法驱逐其Pod", appName, minAvailable.IntValue(), app.Replicas)
+		}
+	} else {
+		maxUnavailable, err := parseIntOrPercent(budget.MaxUnavailable)
+		if err != nil {
+			return nil, fmt.Errorf("maxUnavailable%v", err)
+		}
+		pdb.Spec.MaxUnavailable = &maxUnavailable
+	}
+
+	return pdb, nil
+}
+
+// 创建、更新或删除应用的PodDisruptionBudget
+func applyApplicationPDB(client kubernetes.Interface, app *Application, namespace string, appName string) error {
+	pdbs := client.PolicyV1().PodDisruptionBudgets(namespace)
+	existing, err := pdbs.Get(context.TODO(), appName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("获取PodDisruptionBudget失败: %v", err)
+	}
+	exists := err == nil
+
+	// 未配置中断预算时，删除之前由本系统生成的PDB
+	if app.DisruptionBudget == nil {
+		if exists && existing.Labels["managed-by"] == "cloud-deployment-api" {
+			log.Printf("应用未配置中断预算，删除PodDisruptionBudget: %s/%s", namespace, appName)
+			if err := pdbs.Delete(context.TODO(), appName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+				return fmt.Errorf("删除PodDisruptionBudget失败: %v", err)
+			}
+		}
+		return nil
+	}
+
+	pdb, err := buildApplicationPDB(app, namespace, appName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if _, err := pdbs.Create(context.TODO(), pdb, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("创建PodDisruptionBudget失败: %v", err)
+		}
+		log.Printf("创建PodDisruptionBudget成功: %s/%s", namespace, appName)
+		return nil
+	}
+
+	pdb.ResourceVersion = existing.ResourceVersion
+	if _, err := pdbs.Update(context.TODO(), pdb, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("更新PodDisruptionBudget失败: %v", err)
+	}
+	log.Printf("更新PodDisruptionBudget成功: %s/%s", namespace, appName)
+	return nil
+}
+
+// 获取应用PodDisruptionBudget的当前状态，不存在时返回nil
+func getPDBStatus(client kubernetes.Interface, namespace string, name string) map[string]interface{} {
+	pdb, err := client.PolicyV1().PodDisruptionBudgets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Printf("获取PodDisruptionBudget状态失败: %v", err)
+		}
+		return nil
+	}
+	return pdbStatusMap(pdb)
+}
+
+// 将PodDisruptionBudget转换为状态信息
+func pdbStatusMap(pdb *policyv1.PodDisruptionBudget) map[string]interface{} {
+	status := map[string]interface{}{
+		"name":               pdb.Name,
+		"disruptionsAllowed": pdb.Status.DisruptionsAllowed,
+		"currentHealthy":     pdb.Status.CurrentHealthy,
+		"desiredHealthy":     pdb.Status.DesiredHealthy,
+		"expectedPods":       pdb.Status.ExpectedPods,
+	}
+	if pdb.Spec.MinAvailable != nil {
+		status["minAvailable"] = pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		status["maxUnavailable"] = pdb.Spec.MaxUnavailable.String()
+	}
+	return status
+}
+
+// DeletePodDisruptionBudget 删除指定的PodDisruptionBudget
+func (km *K8sManager) DeletePodDisruptionBudget(kubeConfigId, namespace, name string) error {
+	if kubeConfigId == "" || name == "" {
+		return fmt.Errorf("kubeConfigId和应用名称不能为空")
+	}
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	log.Printf("删除PodDisruptionBudget: kubeConfigId=%s, namespace=%s, name=%s", kubeConfigId, namespace, name)
+
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	err = client.PolicyV1().PodDisruptionBudgets(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			log.Printf("PodDisruptionBudget不存在，视为删除成功: %s/%s", namespace, name)
+			return nil
+		}
+		return fmt.Errorf("删除PodDisruptionBudget失败: %v", err)
+	}
+
+	log.Printf("成功删除PodDisruptionBudget: %s/%s", namespace, name)
+	return nil
+}
@@ -0,0 +1,502 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuotaResourceUsage 配额中单项资源的上限与使用情况
+type QuotaResourceUsage struct {
+	Resource  string `json:"resource"`
+	Hard      string `json:"hard"`
+	Used      string `json:"used"`
+	Remaining string `json:"remaining"`
+}
+
+// NamespaceQuota 命名空间中的一个ResourceQuota及其使用情况
+type NamespaceQuota struct {
+	Name      string               `json:"name"`
+	Namespace string               `json:"namespace"`
+	Scopes    []string             `json:"scopes,omitempty"`
+	Resources []QuotaResourceUsage `json:"resources"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+// ResourceQuotaSpec 创建或更新ResourceQuota的请求
+type ResourceQuotaSpec struct {
+	Name string            `json:"name"`
+	Hard map[string]string `json:"hard"` // 如 {"requests.cpu": "4", "limits.memory": "8Gi", "pods": "20"}
+}
+
+// LimitRangeSpec 创建或更新LimitRange的请求，也用于返回LimitRange
+type LimitRangeSpec struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace,omitempty"`
+	Limits    []LimitRangeItem `json:"limits"`
+}
+
+// LimitRangeItem LimitRange中的一条限制
+type LimitRangeItem struct {
+	Type                 string            `json:"type"` // Container, Pod, PersistentVolumeClaim
+	Max                  map[string]string `json:"max,omitempty"`
+	Min                  map[string]string `json:"min,omitempty"`
+	Default              map[string]string `json:"default,omitempty"`
+	DefaultRequest       map[string]string `json:"defaultRequest,omitempty"`
+	MaxLimitRequestRatio map[string]string `json:"maxLimitRequestRatio,omitempty"`
+}
+
+// 将字符串形式的资源列表解析为ResourceList
+func parseResourceList(values map[string]string) (corev1.ResourceList, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	list := corev1.ResourceList{}
+	for name, value := range values {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("无效的资源数量 %s: %s", name, value)
+		}
+		list[corev1.ResourceName(name)] = q
+	}
+	return list, nil
+}
+
+// 将ResourceList格式化为字符串形式
+func formatResourceList(list corev1.ResourceList) map[string]string {
+	if len(list) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(list))
+	for name, q := range list {
+		values[string(name)] = q.String()
+	}
+	return values
+}
+
+// ValidateResourceQuotaSpec 校验ResourceQuota请求
+func ValidateResourceQuotaSpec(spec *ResourceQuotaSpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("配额名称不能为空")
+	}
+	if len(spec.Hard) == 0 {
+		return fmt.Errorf("至少需要设置一项资源上限")
+	}
+	_, err := parseResourceList(spec.Hard)
+	return err
+}
+
+// ValidateLimitRangeSpec 校验LimitRange请求
+func ValidateLimitRangeSpec(spec *LimitRangeSpec) error {
+	if spec.Name == "" {
+		return fmt.Errorf("LimitRange名称不能为空")
+	}
+	if len(spec.Limits) == 0 {
+		return fmt.Errorf("至少需要设置一条限制")
+	}
+	_, err := buildLimitRangeItems(spec.Limits)
+	return err
+}
+
+// 将ResourceQuota转换为包含剩余量的使用情况
+func convertResourceQuota(quota *corev1.ResourceQuota) NamespaceQuota {
+	result := NamespaceQuota{
+		Name:      quota.Name,
+		Namespace: quota.Namespace,
+		Resources: []QuotaResourceUsage{},
+		CreatedAt: quota.CreationTimestamp.Time,
+	}
+	for _, scope := range quota.Spec.Scopes {
+		result.Scopes = append(result.Scopes, string(scope))
+	}
+
+	names := make([]string, 0, len(quota.Spec.Hard))
+	for name := range quota.Spec.Hard {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		hard := quota.Spec.Hard[corev1.ResourceName(name)]
+		used, ok := quota.Status.Used[corev1.ResourceName(name)]
+		if !ok {
+			used = resource.MustParse("0")
+		}
+		remaining := hard.DeepCopy()
+		remaining.Sub(used)
+		if remaining.Sign() < 0 {
+			remaining = resource.MustParse("0")
+		}
+		result.Resources = append(result.Resources, QuotaResourceUsage{
+			Resource:  name,
+			Hard:      hard.String(),
+			Used:      used.String(),
+			Remaining: remaining.String(),
+		})
+	}
+	return result
+}
+
+// ListResourceQuotas 获取命名空间中所有ResourceQuota的上限和使用情况
+func (km *K8sManager) ListResourceQuotas(kubeConfigId, namespace string) ([]NamespaceQuota, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	list, err := client.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取资源配额失败: %v", err)
+	}
+
+	quotas := make([]NamespaceQuota, 0, len(list.Items))
+	for i := range list.Items {
+		quotas = append(quotas, convertResourceQuota(&list.Items[i]))
+	}
+	return quotas, nil
+}
+
+// CreateResourceQuota 在命名空间中创建ResourceQuota
+func (km *K8sManager) CreateResourceQuota(kubeConfigId, namespace string, spec *ResourceQuotaSpec) (*NamespaceQuota, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	hard, err := parseResourceList(spec.Hard)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.Name,
+			Namespace: namespace,
+			Labels:    map[string]string{"managed-by": "cloud-deployment-api"},
+		},
+		Spec: corev1.ResourceQuotaSpec{Hard: hard},
+	}
+	created, err := client.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), quota, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("创建资源配额失败: %v", err)
+	}
+
+	log.Printf("创建资源配额成功: %s/%s", namespace, spec.Name)
+	result := convertResourceQuota(created)
+	return &result, nil
+}
+
+// UpdateResourceQuota 更新ResourceQuota的资源上限
+func (km *K8sManager) UpdateResourceQuota(kubeConfigId, namespace, name string, spec *ResourceQuotaSpec) (*NamespaceQuota, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	hard, err := parseResourceList(spec.Hard)
+	if err != nil {
+		return nil, err
+	}
+
+	quotas := client.CoreV1().ResourceQuotas(namespace)
+	existing, err := quotas.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取资源配额失败: %v", err)
+	}
+
+	existing.Spec.Hard = hard
+	updated, err := quotas.Update(context.TODO(), existing, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("更新资源配额失败: %v", err)
+	}
+
+	log.Printf("更新资源配额成功: %s/%s", namespace, name)
+	result := convertResourceQuota(updated)
+	return &result, nil
+}
+
+// DeleteResourceQuota 删除ResourceQuota
+func (km *K8sManager) DeleteResourceQuota(kubeConfigId, namespace, name string) error {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	if err := client.CoreV1().ResourceQuotas(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("删除资源配额失败: %v", err)
+	}
+	log.Printf("删除资源配额成功: %s/%s", namespace, name)
+	return nil
+}
+
+// 将LimitRange请求转换为Kubernetes的LimitRangeItem
+func buildLimitRangeItems(items []LimitRangeItem) ([]corev1.LimitRangeItem, error) {
+	var result []corev1.LimitRangeItem
+	for i, item := range items {
+		limitType := corev1.LimitType(item.Type)
+		switch limitType {
+		case corev1.LimitTypeContainer, corev1.LimitTypePod, corev1.LimitTypePersistentVolumeClaim:
+		default:
+			return nil, fmt.Errorf("limits[%d]的类型无效: %s", i, item.Type)
+		}
+
+		converted := corev1.LimitRangeItem{Type: limitType}
+		fields := []struct {
+			name   string
+			values map[string]string
+			target *corev1.ResourceList
+		}{
+			{"max", item.Max, &converted.Max},
+			{"min", item.Min, &converted.Min},
+			{"default", item.Default, &converted.Default},
+			{"defaultRequest", item.DefaultRequest, &converted.DefaultRequest},
+			{"maxLimitRequestRatio", item.MaxLimitRequestRatio, &converted.MaxLimitRequestRatio},
+		}
+		for _, field := range fields {
+			list, err := parseResourceList(field.values)
+			if err != nil {
+				return nil, fmt.Errorf("limits[%d].%s: %v", i, field.name, err)
+			}
+			*field.target = list
+		}
+		result = append(result, converted)
+	}
+	return result, nil
+}
+
+// 将LimitRange转换为请求格式
+func convertLimitRange(limitRange *corev1.LimitRange) LimitRangeSpec {
+	spec := LimitRangeSpec{
+		Name:      limitRange.Name,
+		Namespace: limitRange.Namespace,
+		Limits:    []LimitRangeItem{},
+	}
+	for _, item := range limitRange.Spec.Limits {
+		spec.Limits = append(spec.Limits, LimitRangeItem{
+			Type:                 string(item.Type),
+			Max:                  formatResourceList(item.Max),
+			Min:                  formatResourceList(item.Min),
+			Default:              formatResourceList(item.Default),
+			DefaultRequest:       formatResourceList(item.DefaultRequest),
+			MaxLimitRequestRatio: formatResourceList(item.MaxLimitRequestRatio),
+		})
+	}
+	return spec
+}
+
+// ListLimitRanges 获取命名空间中所有LimitRange
+func (km *K8sManager) ListLimitRanges(kubeConfigId, namespace string) ([]LimitRangeSpec, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	list, err := client.CoreV1().LimitRanges(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取LimitRange失败: %v", err)
+	}
+
+	limitRanges := make([]LimitRangeSpec, 0, len(list.Items))
+	for i := range list.Items {
+		limitRanges = append(limitRanges, convertLimitRange(&list.Items[i]))
+	}
+	return limitRanges, nil
+}
+
+// CreateLimitRange 在命名空间中创建LimitRange
+func (km *K8sManager) CreateLimitRange(kubeConfigId, namespace string, spec *LimitRangeSpec) (*LimitRangeSpec, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	items, err := buildLimitRangeItems(spec.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spec.Name,
+			Namespace: namespace,
+			Labels:    map[string]string{"managed-by": "cloud-deployment-api"},
+		},
+		Spec: corev1.LimitRangeSpec{Limits: items},
+	}
+	created, err := client.CoreV1().LimitRanges(namespace).Create(context.TODO(), limitRange, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("创建LimitRange失败: %v", err)
+	}
+
+	log.Printf("创建LimitRange成功: %s/%s", namespace, spec.Name)
+	result := convertLimitRange(created)
+	return &result, nil
+}
+
+// UpdateLimitRange 更新LimitRange的限制
+func (km *K8sManager) UpdateLimitRange(kubeConfigId, namespace, name string, spec *LimitRangeSpec) (*LimitRangeSpec, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	items, err := buildLimitRangeItems(spec.Limits)
+	if err != nil {
+		return nil, err
+	}
+
+	limitRanges := client.CoreV1().LimitRanges(namespace)
+	existing, err := limitRanges.Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取LimitRange失败: %v", err)
+	}
+
+	existing.Spec.Limits = items
+	updated, err := limitRanges.Update(context.TODO(), existing, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("更新LimitRange失败: %v", err)
+	}
+
+	log.Printf("更新LimitRange成功: %s/%s", namespace, name)
+	result := convertLimitRange(updated)
+	return &result, nil
+}
+
+// DeleteLimitRange 删除LimitRange
+func (km *K8sManager) DeleteLimitRange(kubeConfigId, namespace, name string) error {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	if err := client.CoreV1().LimitRanges(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+		return fmt.Errorf("删除LimitRange失败: %v", err)
+	}
+	log.Printf("删除LimitRange成功: %s/%s", namespace, name)
+	return nil
+}
+
+// 将数量乘以副本数
+func scaleQuantity(q resource.Quantity, n int64) resource.Quantity {
+	return *resource.NewMilliQuantity(q.MilliValue()*n, q.Format)
+}
+
+// 计算一组容器按副本数折算后占用的配额资源
+func podTemplateQuotaUsage(containers []corev1.Container, replicas int64) corev1.ResourceList {
+	usage := corev1.ResourceList{
+		corev1.ResourcePods: *resource.NewQuantity(replicas, resource.DecimalSI),
+	}
+	add := func(name corev1.ResourceName, q resource.Quantity) {
+		total := usage[name]
+		total.Add(scaleQuantity(q, replicas))
+		usage[name] = total
+	}
+
+	for _, container := range containers {
+		for _, kind := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			request, hasRequest := container.Resources.Requests[kind]
+			limit, hasLimit := container.Resources.Limits[kind]
+			// 未设置请求时，Kubernetes使用限制作为请求
+			if !hasRequest && hasLimit {
+				request, hasRequest = limit, true
+			}
+			if hasRequest {
+				add(kind, request)
+				add(corev1.ResourceName("requests."+string(kind)), request)
+			}
+			if hasLimit {
+				add(corev1.ResourceName("limits."+string(kind)), limit)
+			}
+		}
+	}
+	return usage
+}
+
+// CheckApplicationQuota 检查部署应用是否会超出命名空间的剩余配额
+// 应用已部署时只计算与当前Deployment相比新增的用量；返回超出配额的说明，为空表示可以部署
+func (km *K8sManager) CheckApplicationQuota(app *Application) ([]string, error) {
+	namespace, name := applicationTarget(app)
+	client, err := km.GetClient(app.KubeConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	quotaList, err := client.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取资源配额失败: %v", err)
+	}
+	if len(quotaList.Items) == 0 {
+		return nil, nil
+	}
+
+	replicas := int64(app.Replicas)
+	if replicas <= 0 {
+		replicas = 1
+	}
+	container := corev1.Container{Resources: convertResourceRequirements(app.Resources)}
+	required := podTemplateQuotaUsage([]corev1.Container{container}, replicas)
+
+	// 扣除当前Deployment已占用的配额
+	current := corev1.ResourceList{}
+	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil {
+		currentReplicas := int64(1)
+		if deployment.Spec.Replicas != nil {
+			currentReplicas = int64(*deployment.Spec.Replicas)
+		}
+		current = podTemplateQuotaUsage(deployment.Spec.Template.Spec.Containers, currentReplicas)
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("获取部署失败: %v", err)
+	}
+
+	var violations []string
+	for _, quota := range quotaList.Items {
+		// 带作用域的配额只约束部分Pod，无法准确判断，跳过
+		if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+
+		names := make([]string, 0, len(required))
+		for resourceName := range required {
+			names = append(names, string(resourceName))
+		}
+		sort.Strings(names)
+
+		for _, resourceName := range names {
+			hard, ok := quota.Spec.Hard[corev1.ResourceName(resourceName)]
+			if !ok {
+				continue
+			}
+			delta := required[corev1.ResourceName(resourceName)].DeepCopy()
+			if existing, ok := current[corev1.ResourceName(resourceName)]; ok {
+				delta.Sub(existing)
+			}
+			if delta.Sign() <= 0 {
+				continue
+			}
+
+			used := quota.Status.Used[corev1.ResourceName(resourceName)]
+			remaining := hard.DeepCopy()
+			remaining.Sub(used)
+			if delta.Cmp(remaining) > 0 {
+				if remaining.Sign() < 0 {
+					remaining = resource.MustParse("0")
+				}
+				violations = append(violations, fmt.Sprintf("配额 %s 中 %s 需要新增 %s，剩余 %s (已用 %s / 上限 %s)",
+					quota.Name, resourceName, delta.String(), remaining.String(), used.String(), hard.String()))
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		log.Printf("应用 %s/%s 超出资源配额: %v", namespace, name, violations)
+	}
+	return violations, nil
+}
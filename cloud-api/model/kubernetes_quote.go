@@ -0,0 +1,281 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// QuoteEstimate 某个副本数下的资源用量和每月费用
+type QuoteEstimate struct {
+	Replicas      int             `json:"replicas"`
+	CPUCores      float64         `json:"cpuCores"`
+	MemoryGiB     float64         `json:"memoryGiB"`
+	StorageGiB    float64         `json:"storageGiB"`
+	LoadBalancers int             `json:"loadBalancers"`
+	Cost          PriceEstimation `json:"cost"`
+}
+
+// QuoteRange 最小和最大副本数下的费用
+type QuoteRange struct {
+	Min QuoteEstimate `json:"min"`
+	Max QuoteEstimate `json:"max"`
+}
+
+// ApplicationQuote 应用配置的每月费用报价
+type ApplicationQuote struct {
+	PriceBook *PriceBook  `json:"priceBook"`
+	Quote     QuoteRange  `json:"quote"`
+	Current   *QuoteRange `json:"current,omitempty"` // 集群中当前部署版本的费用，未部署时为空
+	Delta     *QuoteRange `json:"delta,omitempty"`   // quote - current
+	Warnings  []string    `json:"warnings,omitempty"`
+}
+
+// ValidateAutoscalingConfig 校验报价使用的自动扩缩容副本范围
+func ValidateAutoscalingConfig(config *AutoscalingConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.MaxReplicas < 1 {
+		return fmt.Errorf("maxReplicas必须大于0")
+	}
+	if config.MinReplicas < 0 || config.MinReplicas > config.MaxReplicas {
+		return fmt.Errorf("minReplicas必须在0到maxReplicas之间")
+	}
+	return nil
+}
+
+// ValidateVolumeConfigs 校验卷配置中的PVC容量
+func ValidateVolumeConfigs(volumes []VolumeConfig) error {
+	for _, vol := range volumes {
+		if vol.Type != "pvc" || vol.Size == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(vol.Size); err != nil {
+			return fmt.Errorf("卷 %s 的容量无效: %s", vol.Name, vol.Size)
+		}
+	}
+	return nil
+}
+
+// 获取报价使用的最小和最大副本数，未设置自动扩缩容时两者相同
+func quoteReplicaRange(app *Application) (int, int) {
+	replicas := app.Replicas
+	if replicas < 1 {
+		replicas = 1
+	}
+	if app.Autoscaling == nil {
+		return replicas, replicas
+	}
+
+	minReplicas := app.Autoscaling.MinReplicas
+	if minReplicas < 1 {
+		minReplicas = replicas
+	}
+	maxReplicas := app.Autoscaling.MaxReplicas
+	if maxReplicas < minReplicas {
+		maxReplicas = minReplicas
+	}
+	return minReplicas, maxReplicas
+}
+
+// 单个副本的资源请求和共享资源用量
+type quoteUsage struct {
+	minReplicas   int
+	maxReplicas   int
+	podCPU        float64
+	podMemoryGiB  float64
+	storageGiB    float64
+	loadBalancers int
+}
+
+// 计算指定副本数下的费用
+func (u *quoteUsage) estimate(book *PriceBook, replicas int) QuoteEstimate {
+	estimate := QuoteEstimate{
+		Replicas:      replicas,
+		CPUCores:      u.podCPU * float64(replicas),
+		MemoryGiB:     u.podMemoryGiB * float64(replicas),
+		StorageGiB:    u.storageGiB,
+		LoadBalancers: u.loadBalancers,
+	}
+	estimate.Cost = book.MonthlyCost(estimate.CPUCores, estimate.MemoryGiB, estimate.StorageGiB, estimate.LoadBalancers)
+	return estimate
+}
+
+// 计算最小和最大副本数下的费用区间
+func (u *quoteUsage) quoteRange(book *PriceBook) QuoteRange {
+	return QuoteRange{
+		Min: u.estimate(book, u.minReplicas),
+		Max: u.estimate(book, u.maxReplicas),
+	}
+}
+
+// 计算两个费用估算的差值
+func diffEstimate(a, b QuoteEstimate) QuoteEstimate {
+	return QuoteEstimate{
+		Replicas:      a.Replicas - b.Replicas,
+		CPUCores:      a.CPUCores - b.CPUCores,
+		MemoryGiB:     a.MemoryGiB - b.MemoryGiB,
+		StorageGiB:    a.StorageGiB - b.StorageGiB,
+		LoadBalancers: a.LoadBalancers - b.LoadBalancers,
+		Cost: PriceEstimation{
+			CPU:          a.Cost.CPU - b.Cost.CPU,
+			Memory:       a.Cost.Memory - b.Cost.Memory,
+			Storage:      a.Cost.Storage - b.Cost.Storage,
+			LoadBalancer: a.Cost.LoadBalancer - b.Cost.LoadBalancer,
+			Total:        a.Cost.Total - b.Cost.Total,
+			Currency:     a.Cost.Currency,
+		},
+	}
+}
+
+// QuoteApplication 根据应用配置计算每月费用区间，并与集群中当前部署的版本比较
+// 费用按资源请求计算；启用自动扩缩容时最小/最大费用分别对应HPA的最小/最大副本数
+func (km *K8sManager) QuoteApplication(app *Application) (*ApplicationQuote, error) {
+	book, err := GetEffectivePriceBook(app.KubeConfigID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ApplicationQuote{PriceBook: book}
+
+	// 应用配置的用量
+	minReplicas, maxReplicas := quoteReplicaRange(app)
+	requests := convertResourceRequirements(app.Resources).Requests
+	cpu := requests[corev1.ResourceCPU]
+	memory := requests[corev1.ResourceMemory]
+	proposed := &quoteUsage{
+		minReplicas:  minReplicas,
+		maxReplicas:  maxReplicas,
+		podCPU:       float64(cpu.MilliValue()) / 1000,
+		podMemoryGiB: quantityToGiB(memory),
+	}
+	if app.ServiceType == string(corev1.ServiceTypeLoadBalancer) {
+		proposed.loadBalancers = 1
+	}
+
+	// 集群不可用时仍然可以基于配置报价，只是无法与当前版本比较
+	_, clientErr := km.GetClient(app.KubeConfigID)
+	if clientErr != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("无法连接集群，未与当前部署版本比较: %v", clientErr))
+	}
+
+	namespace, name := applicationTarget(app)
+	for _, vol := range app.Volumes {
+		if vol.Type != "pvc" {
+			continue
+		}
+		if vol.Size != "" {
+			size, err := resource.ParseQuantity(vol.Size)
+			if err != nil {
+				return nil, fmt.Errorf("卷 %s 的容量无效: %s", vol.Name, vol.Size)
+			}
+			proposed.storageGiB += quantityToGiB(size)
+			continue
+		}
+		// 未设置容量时使用集群中已存在PVC的容量
+		if clientErr == nil && vol.ClaimName != "" {
+			if size, ok := km.claimSize(app.KubeConfigID, namespace, vol.ClaimName); ok {
+				proposed.storageGiB += quantityToGiB(size)
+				continue
+			}
+		}
+		result.Warnings = append(result.Warnings, fmt.Sprintf("卷 %s 未设置容量，存储费用未计入", vol.Name))
+	}
+	result.Quote = proposed.quoteRange(book)
+
+	if clientErr != nil {
+		return result, nil
+	}
+
+	current, err := km.currentDeploymentUsage(namespace, name, app.KubeConfigID)
+	if err != nil {
+		log.Printf("获取应用 %s/%s 当前部署版本失败: %v", namespace, name, err)
+		result.Warnings = append(result.Warnings, fmt.Sprintf("获取当前部署版本失败: %v", err))
+		return result, nil
+	}
+	if current == nil {
+		return result, nil
+	}
+
+	currentRange := current.quoteRange(book)
+	result.Current = &currentRange
+	result.Delta = &QuoteRange{
+		Min: diffEstimate(result.Quote.Min, currentRange.Min),
+		Max: diffEstimate(result.Quote.Max, currentRange.Max),
+	}
+	return result, nil
+}
+
+// 获取PVC的容量，优先使用实际分配的容量
+func (km *K8sManager) claimSize(kubeConfigId, namespace, claimName string) (resource.Quantity, bool) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), claimName, metav1.GetOptions{})
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	if size, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		return size, true
+	}
+	size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	return size, ok
+}
+
+// 从集群读取当前部署版本的用量：Deployment、HPA、挂载的PVC和Service，未部署时返回nil
+func (km *K8sManager) currentDeploymentUsage(namespace, name, kubeConfigId string) (*quoteUsage, error) {
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return nil, fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("获取Deployment失败: %v", err)
+	}
+
+	replicas := 1
+	if deployment.Spec.Replicas != nil {
+		replicas = int(*deployment.Spec.Replicas)
+	}
+	usage := &quoteUsage{minReplicas: replicas, maxReplicas: replicas}
+
+	pod := &corev1.Pod{Spec: deployment.Spec.Template.Spec}
+	usage.podCPU, usage.podMemoryGiB = podEffectiveRequests(pod)
+
+	hpa, err := client.AutoscalingV2().HorizontalPodAutoscalers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil {
+		if hpa.Spec.MinReplicas != nil {
+			usage.minReplicas = int(*hpa.Spec.MinReplicas)
+		}
+		usage.maxReplicas = int(hpa.Spec.MaxReplicas)
+	} else if !k8serrors.IsNotFound(err) {
+		return nil, fmt.Errorf("获取HorizontalPodAutoscaler失败: %v", err)
+	}
+
+	for _, volume := range deployment.Spec.Template.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		if size, ok := km.claimSize(kubeConfigId, namespace, volume.PersistentVolumeClaim.ClaimName); ok {
+			usage.storageGiB += quantityToGiB(size)
+		}
+	}
+
+	service, err := client.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err == nil && service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		usage.loadBalancers = 1
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,289 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ValidateServiceAccountConfig 校验ServiceAccount和RBAC配置
+func ValidateServiceAccountConfig(config *ServiceAccountConfig) error {
+	if config == nil {
+		return nil
+	}
+	if !config.Create && config.Name == "" && len(config.Rules) > 0 {
+		return fmt.Errorf("声明RBAC规则时必须创建ServiceAccount或指定已有ServiceAccount名称")
+	}
+	for i, rule := range config.Rules {
+		if len(rule.Verbs) == 0 {
+			return fmt.Errorf("第%d条RBAC规则缺少verbs", i+1)
+		}
+		if len(rule.Resources) == 0 {
+			return fmt.Errorf("第%d条RBAC规则缺少resources", i+1)
+		}
+	}
+	return nil
+}
+
+// 获取应用实际使用的ServiceAccount名称，使用命名空间默认账号时返回空字符串
+func applicationServiceAccountName(app *Application, appName string) string {
+	config := app.ServiceAccount
+	if config == nil {
+		return ""
+	}
+	if config.Name != "" {
+		return config.Name
+	}
+	if config.Create {
+		return appName
+	}
+	return ""
+}
+
+// 应用级资源的公共标签
+func applicationResourceLabels(app *Application, appName string) map[string]string {
+	return map[string]string{
+		"app":        appName,
+		"managed-by": "cloud-deployment-api",
+		"app-id":     app.ID,
+	}
+}
+
+// 创建或更新应用的ServiceAccount、Role和RoleBinding，返回Pod使用的ServiceAccount名称
+func applyApplicationServiceAccount(client kubernetes.Interface, app *Application, namespace string, appName string) (string, error) {
+	config := app.ServiceAccount
+	saName := applicationServiceAccountName(app, appName)
+
+	// 创建专用ServiceAccount
+	if config != nil && config.Create {
+		sa := &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      saName,
+				Namespace: namespace,
+				Labels:    applicationResourceLabels(app, appName),
+			},
+			AutomountServiceAccountToken: config.AutomountServiceAccountToken,
+		}
+
+		serviceAccounts := client.CoreV1().ServiceAccounts(namespace)
+		existing, err := serviceAccounts.Get(context.TODO(), saName, metav1.GetOptions{})
+		if err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return "", fmt.Errorf("获取ServiceAccount失败: %v", err)
+			}
+			if _, err := serviceAccounts.Create(context.TODO(), sa, metav1.CreateOptions{}); err != nil {
+				return "", fmt.Errorf("创建ServiceAccount失败: %v", err)
+			}
+			log.Printf("创建ServiceAccount成功: %s/%s", namespace, saName)
+		} else {
+			// 不接管用户自行创建或属于其他应用的同名账号
+			if existing.Labels["managed-by"] != "cloud-deployment-api" {
+				return "", fmt.Errorf("ServiceAccount %s/%s 已存在且不是由本系统创建的，请更换名称", namespace, saName)
+			}
+			if owner := existing.Labels["app-id"]; owner != "" && owner != app.ID {
+				return "", fmt.Errorf("ServiceAccount %s/%s 已被其他应用使用，请更换名称", namespace, saName)
+			}
+			// 保留集群自动填充的secrets字段
+			existing.Labels = sa.Labels
+			existing.AutomountServiceAccountToken = sa.AutomountServiceAccountToken
+			if _, err := serviceAccounts.Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+				return "", fmt.Errorf("更新ServiceAccount失败: %v", err)
+			}
+			log.Printf("更新ServiceAccount成功: %s/%s", namespace, saName)
+		}
+		// 修改名称后删除之前为应用创建的账号
+		if err := deleteManagedServiceAccounts(client, namespace, appName, saName); err != nil {
+			return "", err
+		}
+	} else if err := deleteManagedServiceAccounts(client, namespace, appName, ""); err != nil {
+		return "", err
+	}
+
+	// 根据声明的规则生成Role和RoleBinding
+	if config == nil || len(config.Rules) == 0 || saName == "" {
+		if err := deleteApplicationRBAC(client, namespace, appName); err != nil {
+			return "", err
+		}
+		return saName, nil
+	}
+
+	role := &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels:    applicationResourceLabels(app, appName),
+		},
+	}
+	for _, rule := range config.Rules {
+		apiGroups := rule.APIGroups
+		if len(apiGroups) == 0 {
+			apiGroups = []string{""} // 默认核心API组
+		}
+		role.Rules = append(role.Rules, rbacv1.PolicyRule{
+			APIGroups:     apiGroups,
+			Resources:     rule.Resources,
+			Verbs:         rule.Verbs,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+
+	roles := client.RbacV1().Roles(namespace)
+	existingRole, err := roles.Get(context.TODO(), appName, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("获取Role失败: %v", err)
+		}
+		if _, err := roles.Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("创建Role失败: %v", err)
+		}
+		log.Printf("创建Role成功: %s/%s", namespace, appName)
+	} else {
+		// 不接管用户自行创建或属于其他应用的同名Role
+		if err := checkManagedRBACObject("Role", namespace, appName, existingRole.Labels, app.ID); err != nil {
+			return "", err
+		}
+		role.ResourceVersion = existingRole.ResourceVersion
+		if _, err := roles.Update(context.TODO(), role, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("更新Role失败: %v", err)
+		}
+		log.Printf("更新Role成功: %s/%s", namespace, appName)
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels:    applicationResourceLabels(app, appName),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     appName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      saName,
+				Namespace: namespace,
+			},
+		},
+	}
+
+	roleBindings := client.RbacV1().RoleBindings(namespace)
+	existingBinding, err := roleBindings.Get(context.TODO(), appName, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("获取RoleBinding失败: %v", err)
+		}
+		if _, err := roleBindings.Create(context.TODO(), roleBinding, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("创建RoleBinding失败: %v", err)
+		}
+		log.Printf("创建RoleBinding成功: %s/%s", namespace, appName)
+	} else if err := checkManagedRBACObject("RoleBinding", namespace, appName, existingBinding.Labels, app.ID); err != nil {
+		// 不接管用户自行创建或属于其他应用的同名RoleBinding
+		return "", err
+	} else if existingBinding.RoleRef != roleBinding.RoleRef {
+		// roleRef不可修改，只能删除后重建
+		if err := roleBindings.Delete(context.TODO(), appName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("删除RoleBinding失败: %v", err)
+		}
+		if _, err := roleBindings.Create(context.TODO(), roleBinding, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("重建RoleBinding失败: %v", err)
+		}
+		log.Printf("重建RoleBinding成功: %s/%s", namespace, appName)
+	} else {
+		roleBinding.ResourceVersion = existingBinding.ResourceVersion
+		if _, err := roleBindings.Update(context.TODO(), roleBinding, metav1.UpdateOptions{}); err != nil {
+			return "", fmt.Errorf("更新RoleBinding失败: %v", err)
+		}
+		log.Printf("更新RoleBinding成功: %s/%s", namespace, appName)
+	}
+
+	return saName, nil
+}
+
+// 检查已存在的同名Role/RoleBinding是否由本系统为该应用创建，否则不能覆盖
+func checkManagedRBACObject(kind, namespace, name string, labels map[string]string, appID string) error {
+	if labels["managed-by"] != "cloud-deployment-api" {
+		return fmt.Errorf("%s %s/%s 已存在且不是由本系统创建的", kind, namespace, name)
+	}
+	if owner := labels["app-id"]; owner != "" && owner != appID {
+		return fmt.Errorf("%s %s/%s 已被其他应用使用", kind, namespace, name)
+	}
+	return nil
+}
+
+// 删除由本系统为应用创建的ServiceAccount（按app和managed-by标签查找，包括自定义名称的账号），
+// 不会删除用户自行创建的账号。keep不为空时保留该名称的账号
+func deleteManagedServiceAccounts(client kubernetes.Interface, namespace string, appName string, keep string) error {
+	serviceAccounts := client.CoreV1().ServiceAccounts(namespace)
+	list, err := serviceAccounts.List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("app=%s,managed-by=cloud-deployment-api", appName),
+	})
+	if err != nil {
+		return fmt.Errorf("获取ServiceAccount失败: %v", err)
+	}
+
+	for _, sa := range list.Items {
+		if sa.Name == keep {
+			continue
+		}
+		log.Printf("删除ServiceAccount: %s/%s", namespace, sa.Name)
+		if err := serviceAccounts.Delete(context.TODO(), sa.Name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("删除ServiceAccount失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// 删除由本系统为应用创建的Role和RoleBinding
+func deleteApplicationRBAC(client kubernetes.Interface, namespace string, appName string) error {
+	roleBinding, err := client.RbacV1().RoleBindings(namespace).Get(context.TODO(), appName, metav1.GetOptions{})
+	if err == nil && roleBinding.Labels["managed-by"] == "cloud-deployment-api" {
+		log.Printf("删除RoleBinding: %s/%s", namespace, appName)
+		if err := client.RbacV1().RoleBindings(namespace).Delete(context.TODO(), appName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("删除RoleBinding失败: %v", err)
+		}
+	} else if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("获取RoleBinding失败: %v", err)
+	}
+
+	role, err := client.RbacV1().Roles(namespace).Get(context.TODO(), appName, metav1.GetOptions{})
+	if err == nil && role.Labels["managed-by"] == "cloud-deployment-api" {
+		log.Printf("删除Role: %s/%s", namespace, appName)
+		if err := client.RbacV1().Roles(namespace).Delete(context.TODO(), appName, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("删除Role失败: %v", err)
+		}
+	} else if err != nil && !k8serrors.IsNotFound(err) {
+		return fmt.Errorf("获取Role失败: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteServiceAccountResources 删除应用的ServiceAccount、Role和RoleBinding
+func (km *K8sManager) DeleteServiceAccountResources(kubeConfigId, namespace, name string) error {
+	if kubeConfigId == "" || name == "" {
+		return fmt.Errorf("kubeConfigId和应用名称不能为空")
+	}
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	client, err := km.GetClient(kubeConfigId)
+	if err != nil {
+		return fmt.Errorf("获取客户端失败: %v", err)
+	}
+
+	if err := deleteApplicationRBAC(client, namespace, name); err != nil {
+		return err
+	}
+	return deleteManagedServiceAccounts(client, namespace, name, "")
+}
@@ -0,0 +1,234 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 同步失败后的重试间隔，每次失败翻倍
+	reconcileBaseBackoff = 30 * time.Second
+	reconcileMaxBackoff  = 30 * time.Minute
+)
+
+// SyncAttempt 应用的一次同步记录
+type SyncAttempt struct {
+	ID            string     `json:"id" db:"id"`
+	ApplicationID string     `json:"applicationId" db:"application_id"`
+	Trigger       string     `json:"trigger" db:"trigger"` // auto, manual
+	Reason        string     `json:"reason" db:"reason"`   // 触发同步的原因，如缺失或漂移的资源
+	Result        string     `json:"result" db:"result"`   // success, failed, blocked
+	Message       string     `json:"message" db:"message"`
+	StartedAt     time.Time  `json:"startedAt" db:"started_at"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
+}
+
+// 应用的同步退避状态
+type syncBackoff struct {
+	failures    int
+	nextAttempt time.Time
+}
+
+var (
+	syncBackoffMu sync.Mutex
+	syncBackoffs  = make(map[string]*syncBackoff)
+)
+
+// 判断应用当前是否处于退避期
+func inSyncBackoff(appID string) bool {
+	syncBackoffMu.Lock()
+	defer syncBackoffMu.Unlock()
+	state, ok := syncBackoffs[appID]
+	return ok && time.Now().Before(state.nextAttempt)
+}
+
+// 记录同步结果：成功时清除退避状态，失败时按指数退避推迟下一次尝试
+func recordSyncBackoff(appID string, success bool) {
+	syncBackoffMu.Lock()
+	defer syncBackoffMu.Unlock()
+	if success {
+		delete(syncBackoffs, appID)
+		return
+	}
+
+	state, ok := syncBackoffs[appID]
+	if !ok {
+		state = &syncBackoff{}
+		syncBackoffs[appID] = state
+	}
+	delay := reconcileBaseBackoff
+	for i := 0; i < state.failures && delay < reconcileMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > reconcileMaxBackoff {
+		delay = reconcileMaxBackoff
+	}
+	state.failures++
+	state.nextAttempt = time.Now().Add(delay)
+	log.Printf("应用 %s 同步失败%d次，%v后重试", appID, state.failures, delay)
+}
+
+// 根据漂移检测结果生成同步原因，不需要同步时返回空字符串
+func syncReason(km *K8sManager, app *Application, drift *ApplicationDrift) (string, error) {
+	var reasons []string
+	for _, resource := range drift.Resources {
+		if resource.Missing {
+			reasons = append(reasons, fmt.Sprintf("%s %s 不存在", resource.ResourceType, resource.Name))
+		} else if len(resource.Differences) > 0 {
+			paths := make([]string, 0, len(resource.Differences))
+			for _, diff := range resource.Differences {
+				paths = append(paths, diff.Path)
+			}
+			reasons = append(reasons, fmt.Sprintf("%s %s 存在漂移: %s", resource.ResourceType, resource.Name, strings.Join(paths, ", ")))
+		}
+	}
+
+	// 在记录期望清单之前部署的应用只能检查Deployment是否存在
+	if len(drift.Resources) == 0 {
+		client, err := km.GetClient(app.KubeConfigID)
+		if err != nil {
+			return "", fmt.Errorf("获取客户端失败: %v", err)
+		}
+		namespace, name := applicationTarget(app)
+		live, err := getLiveManifest(client, "deployments", namespace, name)
+		if err != nil {
+			return "", err
+		}
+		if live == nil {
+			reasons = append(reasons, fmt.Sprintf("deployments %s 不存在", name))
+		}
+	}
+
+	return strings.Join(reasons, "; "), nil
+}
+
+// SyncApplication 按应用配置重新部署并记录同步结果，部署前执行策略和配额检查
+func (km *K8sManager) SyncApplication(app *Application, trigger string, reason string) *SyncAttempt {
+	attempt := &SyncAttempt{
+		ApplicationID: app.ID,
+		Trigger:       trigger,
+		Reason:        reason,
+		StartedAt:     time.Now(),
+	}
+
+	finish := func(result, message string) *SyncAttempt {
+		now := time.Now()
+		attempt.Result = result
+		attempt.Message = message
+		attempt.FinishedAt = &now
+		if err := SaveSyncAttemptToDB(attempt); err != nil {
+			log.Printf("保存同步记录失败: %v", err)
+		}
+		recordSyncBackoff(app.ID, result == "success")
+		return attempt
+	}
+
+	evaluation, err := EvaluateDeploymentPolicies(app)
+	if err != nil {
+		return finish("failed", fmt.Sprintf("评估部署策略失败: %v", err))
+	}
+	if !evaluation.Allowed {
+		return finish("blocked", fmt.Sprintf("违反部署策略: %s", evaluation.Violations[0].Message))
+	}
+
+	violations, err := km.CheckApplicationQuota(app)
+	if err != nil {
+		return finish("failed", fmt.Sprintf("检查资源配额失败: %v", err))
+	}
+	if len(violations) > 0 {
+		return finish("blocked", fmt.Sprintf("超出资源配额: %s", strings.Join(violations, "; ")))
+	}
+
+	if err := km.DeployApplication(app); err != nil {
+		UpdateApplicationStatusToDB(app.ID, "error")
+		return finish("failed", fmt.Sprintf("部署失败: %v", err))
+	}
+	UpdateApplicationStatusToDB(app.ID, "running")
+
+	if drift, err := km.CheckApplicationDrift(app); err == nil && drift.Drifted {
+		return finish("failed", "重新部署后仍存在漂移")
+	}
+	return finish("success", "已按应用配置重新部署")
+}
+
+// ReconcileAutoSyncApplications 检查开启自动同步的应用，集群中的对象缺失或漂移时重新部署
+func (km *K8sManager) ReconcileAutoSyncApplications() {
+	apps, err := GetApplicationsFromDB()
+	if err != nil {
+		log.Printf("获取应用列表失败: %v", err)
+		return
+	}
+
+	synced := 0
+	for i := range apps {
+		app := &apps[i]
+		// 从未部署过的应用不自动同步
+		if !app.AutoSync || app.KubeConfigID == "" || (app.Status != "running" && app.Status != "error" && app.Status != "unavailable") {
+			continue
+		}
+		if inSyncBackoff(app.ID) {
+			continue
+		}
+
+		drift, err := km.CheckApplicationDrift(app)
+		if err != nil {
+			log.Printf("检测应用 %s 的配置漂移失败: %v", app.Name, err)
+			continue
+		}
+		reason, err := syncReason(km, app, drift)
+		if err != nil {
+			log.Printf("检查应用 %s 的集群状态失败: %v", app.Name, err)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+
+		log.Printf("自动同步应用 %s: %s", app.Name, reason)
+		attempt := km.SyncApplication(app, "auto", reason)
+		log.Printf("自动同步应用 %s 结果: %s %s", app.Name, attempt.Result, attempt.Message)
+		synced++
+	}
+	if synced > 0 {
+		log.Printf("自动同步完成，同步了%d个应用", synced)
+	}
+}
+
+// SaveSyncAttemptToDB 保存同步记录
+func SaveSyncAttemptToDB(attempt *SyncAttempt) error {
+	if attempt.ID == "" {
+		attempt.ID = uuid.New().String()
+	}
+
+	query := `
+        INSERT INTO application_sync_attempts (id, application_id, trigger, reason, result, message, started_at, finished_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	if _, err := DB.Exec(query, attempt.ID, attempt.ApplicationID, attempt.Trigger, attempt.Reason,
+		attempt.Result, attempt.Message, attempt.StartedAt, attempt.FinishedAt); err != nil {
+		return fmt.Errorf("保存同步记录失败: %v", err)
+	}
+	return nil
+}
+
+// GetSyncAttemptsFromDB 获取应用最近的同步记录
+func GetSyncAttemptsFromDB(appID string, limit int) ([]SyncAttempt, error) {
+	attempts := []SyncAttempt{}
+	query := `
+        SELECT id, application_id, trigger, COALESCE(reason, '') AS reason, result,
+               COALESCE(message, '') AS message, started_at, finished_at
+        FROM application_sync_attempts
+        WHERE application_id = $1
+        ORDER BY started_at DESC
+        LIMIT $2
+    `
+	if err := DB.Select(&attempts, query, appID, limit); err != nil {
+		return nil, fmt.Errorf("查询同步记录失败: %v", err)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,211 @@
+package model
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// 镜像拉取Secret上记录来源镜像仓库ID的标签
+	registryIDLabel = "cloud-deployment-api/registry-id"
+	// Docker Hub在dockerconfigjson中使用的认证地址
+	dockerHubAuthKey = "https://index.docker.io/v1/"
+)
+
+var invalidDNSLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// 将任意字符串转换为合法的DNS标签（小写字母、数字和-，不超过63个字符）
+func toDNSLabel(value string) string {
+	label := invalidDNSLabelChars.ReplaceAllString(strings.ToLower(value), "-")
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return strings.Trim(label, "-")
+}
+
+// 规范化镜像仓库地址，去掉协议和路径，Docker Hub的各种地址统一为docker.io
+func normalizeRegistryHost(url string) string {
+	host := strings.TrimSpace(strings.ToLower(url))
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+
+	switch host {
+	case "docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com", "hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// 从镜像地址中解析镜像仓库地址，未带仓库地址的镜像属于Docker Hub
+func imageRegistryHost(image string) string {
+	i := strings.Index(image, "/")
+	if i < 0 {
+		return "docker.io"
+	}
+
+	first := image[:i]
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return normalizeRegistryHost(first)
+	}
+	return "docker.io"
+}
+
+// FindRegistryForImage 根据镜像地址查找匹配且配置了凭据的已注册镜像仓库
+func FindRegistryForImage(image string) (*Registry, error) {
+	if image == "" {
+		return nil, nil
+	}
+
+	registries, err := GetRegistriesFromDB()
+	if err != nil {
+		return nil, fmt.Errorf("获取镜像仓库列表失败: %v", err)
+	}
+
+	host := imageRegistryHost(image)
+	for i := range registries {
+		registry := registries[i]
+		if registry.Username == "" || registry.Password == "" {
+			continue
+		}
+		if normalizeRegistryHost(registry.URL) == host {
+			return &registry, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// RegistryPullSecretName 获取镜像仓库对应的镜像拉取Secret名称
+func RegistryPullSecretName(registry *Registry) string {
+	name := toDNSLabel("regcred-" + registry.ID)
+	if name == "regcred" {
+		name = toDNSLabel("regcred-" + registry.Name)
+	}
+	return name
+}
+
+// 根据镜像仓库凭据生成.dockerconfigjson内容
+func buildDockerConfigJSON(registry *Registry) ([]byte, error) {
+	server := normalizeRegistryHost(registry.URL)
+	if server == "docker.io" {
+		server = dockerHubAuthKey
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(registry.Username + ":" + registry.Password))
+	entry := map[string]string{
+		"username": registry.Username,
+		"password": registry.Password,
+		"auth":     auth,
+	}
+	if registry.Email != "" {
+		entry["email"] = registry.Email
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			server: entry,
+		},
+	})
+}
+
+// 在指定命名空间中创建或更新镜像仓库的dockerconfigjson Secret，返回Secret名称
+func ensureRegistryPullSecret(client kubernetes.Interface, namespace string, registry *Registry) (string, error) {
+	dockerConfig, err := buildDockerConfigJSON(registry)
+	if err != nil {
+		return "", fmt.Errorf("生成dockerconfigjson失败: %v", err)
+	}
+
+	name := RegistryPullSecretName(registry)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"managed-by":    "cloud-deployment-api",
+				registryIDLabel: toDNSLabel(registry.ID),
+			},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			corev1.DockerConfigJsonKey: dockerConfig,
+		},
+	}
+
+	existing, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return "", fmt.Errorf("获取镜像拉取Secret失败: %v", err)
+		}
+		if _, err := client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("创建镜像拉取Secret失败: %v", err)
+		}
+		log.Printf("创建镜像拉取Secret成功: %s/%s", namespace, name)
+		return name, nil
+	}
+
+	existing.Labels = secret.Labels
+	existing.Type = secret.Type
+	existing.Data = secret.Data
+	if _, err := client.CoreV1().Secrets(namespace).Update(context.TODO(), existing, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("更新镜像拉取Secret失败: %v", err)
+	}
+	log.Printf("更新镜像拉取Secret成功: %s/%s", namespace, name)
+	return name, nil
+}
+
+// RefreshRegistryPullSecrets 镜像仓库凭据变更后，刷新所有集群中由该仓库生成的镜像拉取Secret
+func (km *K8sManager) RefreshRegistryPullSecrets(registry *Registry) (int, error) {
+	configs, err := GetKubeConfigsFromDB()
+	if err != nil {
+		return 0, fmt.Errorf("获取KubeConfig列表失败: %v", err)
+	}
+
+	selector := fmt.Sprintf("managed-by=cloud-deployment-api,%s=%s", registryIDLabel, toDNSLabel(registry.ID))
+	refreshed := 0
+	for _, config := range configs {
+		if !config.IsActive {
+			continue
+		}
+
+		client, err := km.GetClient(config.ID)
+		if err != nil {
+			log.Printf("刷新镜像拉取Secret时获取客户端失败 (KubeConfigID: %s): %v", config.ID, err)
+			continue
+		}
+
+		secrets, err := client.CoreV1().Secrets("").List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			log.Printf("列出镜像拉取Secret失败 (KubeConfigID: %s): %v", config.ID, err)
+			continue
+		}
+
+		for _, secret := range secrets.Items {
+			// 仓库凭据被清空时保留原Secret，避免已运行的工作负载无法拉取镜像
+			if registry.Username == "" || registry.Password == "" {
+				log.Printf("镜像仓库 %s 未配置凭据，跳过刷新Secret: %s/%s", registry.Name, secret.Namespace, secret.Name)
+				continue
+			}
+			if _, err := ensureRegistryPullSecret(client, secret.Namespace, registry); err != nil {
+				log.Printf("刷新镜像拉取Secret失败 (KubeConfigID: %s, %s/%s): %v", config.ID, secret.Namespace, secret.Name, err)
+				continue
+			}
+			refreshed++
+		}
+	}
+
+	log.Printf("镜像仓库 %s 的凭据已同步到%d个命名空间", registry.Name, refreshed)
+	return refreshed, nil
+}
@@ -0,0 +1,209 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// 样本数少于该值时认为数据不足，只给出参考建议
+	minRecommendationSamples = 30
+	// 请求值在P95用量基础上预留的余量
+	cpuRequestHeadroom    = 1.15
+	memoryRequestHeadroom = 1.2
+	// 限制值在峰值用量基础上预留的余量
+	cpuLimitHeadroom    = 2.0
+	memoryLimitHeadroom = 1.3
+	// 发生过OOMKilled时内存限制至少提高的倍数
+	oomMemoryBump = 1.5
+	// P99用量达到CPU限制的该比例时认为有限流风险
+	cpuThrottlingRatio = 0.8
+
+	minCPUMillicores = 10
+	minMemoryBytes   = 32 * 1024 * 1024
+)
+
+// ErrNoMetricsHistory 应用没有资源用量记录
+var ErrNoMetricsHistory = errors.New("应用没有资源用量记录，请确认集群已安装metrics-server且应用已运行一段时间")
+
+// UsagePercentiles 资源用量的分位数
+type UsagePercentiles struct {
+	P50 int64 `json:"p50"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+	Max int64 `json:"max"`
+}
+
+// RecommendationFinding 资源配置问题
+type RecommendationFinding struct {
+	Type     string `json:"type"`     // oomKilled, cpuThrottlingRisk, overProvisioned, insufficientData
+	Severity string `json:"severity"` // warning, info
+	Pod      string `json:"pod,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ResourceRecommendation 应用的资源请求和限制建议
+type ResourceRecommendation struct {
+	ApplicationID string                  `json:"applicationId"`
+	Since         time.Time               `json:"since"`
+	Samples       int                     `json:"samples"`
+	Sufficient    bool                    `json:"sufficient"` // 样本是否足够
+	CPU           UsagePercentiles        `json:"cpuMillicores"`
+	Memory        UsagePercentiles        `json:"memoryBytes"`
+	Current       *ResourceRequirements   `json:"current"`
+	Recommended   ResourceRequirements    `json:"recommended"`
+	Findings      []RecommendationFinding `json:"findings"`
+	// 应用建议后的应用配置，可直接作为PUT /applications/:id的请求体
+	Update *Application `json:"update"`
+}
+
+// RecommendApplicationResources 根据记录的Pod资源用量为应用推荐资源请求和限制
+func (km *K8sManager) RecommendApplicationResources(app *Application, since time.Time) (*ResourceRecommendation, error) {
+	history, err := GetApplicationMetricsHistoryFromDB(app.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var cpuSamples, memorySamples []int64
+	for _, pod := range history.Pods {
+		for _, point := range pod.Points {
+			cpuSamples = append(cpuSamples, point.CPUMillicores)
+			memorySamples = append(memorySamples, point.MemoryBytes)
+		}
+	}
+	if len(cpuSamples) == 0 {
+		return nil, ErrNoMetricsHistory
+	}
+
+	recommendation := &ResourceRecommendation{
+		ApplicationID: app.ID,
+		Since:         since,
+		Samples:       len(cpuSamples),
+		Sufficient:    len(cpuSamples) >= minRecommendationSamples,
+		CPU:           usagePercentiles(cpuSamples),
+		Memory:        usagePercentiles(memorySamples),
+		Current:       app.Resources,
+		Findings:      []RecommendationFinding{},
+	}
+	if !recommendation.Sufficient {
+		recommendation.Findings = append(recommendation.Findings, RecommendationFinding{
+			Type:     "insufficientData",
+			Severity: "info",
+			Message:  fmt.Sprintf("仅有%d个样本，建议在应用运行更长时间后再参考", len(cpuSamples)),
+		})
+	}
+
+	cpuRequest := maxInt64(int64(float64(recommendation.CPU.P95)*cpuRequestHeadroom), minCPUMillicores)
+	cpuLimit := maxInt64(int64(float64(recommendation.CPU.Max)*cpuLimitHeadroom), cpuRequest*2)
+	memoryRequest := maxInt64(int64(float64(recommendation.Memory.P95)*memoryRequestHeadroom), minMemoryBytes)
+	memoryLimit := maxInt64(int64(float64(recommendation.Memory.Max)*memoryLimitHeadroom), memoryRequest)
+
+	// 未设置的项按部署时使用的默认值计算
+	current := convertResourceRequirements(app.Resources)
+	currentCPULimit := current.Limits.Cpu().MilliValue()
+	currentMemoryLimit := current.Limits.Memory().Value()
+	currentCPURequest := current.Requests.Cpu().MilliValue()
+
+	// 发生过OOMKilled的容器，内存限制至少在当前限制的基础上提高
+	for _, podName := range km.oomKilledPods(app) {
+		recommendation.Findings = append(recommendation.Findings, RecommendationFinding{
+			Type:     "oomKilled",
+			Severity: "warning",
+			Pod:      podName,
+			Message:  "容器因内存不足被终止(OOMKilled)，需要提高内存限制",
+		})
+		memoryLimit = maxInt64(memoryLimit, int64(float64(currentMemoryLimit)*oomMemoryBump))
+	}
+
+	if currentCPULimit > 0 && float64(recommendation.CPU.P99) >= float64(currentCPULimit)*cpuThrottlingRatio {
+		recommendation.Findings = append(recommendation.Findings, RecommendationFinding{
+			Type:     "cpuThrottlingRisk",
+			Severity: "warning",
+			Message: fmt.Sprintf("CPU用量P99为%dm，已达到限制%dm的%.0f%%，容易被限流",
+				recommendation.CPU.P99, currentCPULimit, float64(recommendation.CPU.P99)*100/float64(currentCPULimit)),
+		})
+	}
+
+	if recommendation.Sufficient && recommendation.CPU.P95*2 < currentCPURequest {
+		recommendation.Findings = append(recommendation.Findings, RecommendationFinding{
+			Type:     "overProvisioned",
+			Severity: "info",
+			Message: fmt.Sprintf("CPU用量P95为%dm，不到请求值%dm的一半，可以降低请求以节省资源",
+				recommendation.CPU.P95, currentCPURequest),
+		})
+	}
+
+	recommendation.Recommended = ResourceRequirements{
+		Requests: ResourceList{CPU: formatMilli(cpuRequest), Memory: formatMebibytes(memoryRequest)},
+		Limits:   ResourceList{CPU: formatMilli(cpuLimit), Memory: formatMebibytes(memoryLimit)},
+	}
+
+	update := *app
+	resources := recommendation.Recommended
+	update.Resources = &resources
+	recommendation.Update = &update
+	return recommendation, nil
+}
+
+// 当前Pod中因OOMKilled终止过的容器所在的Pod
+func (km *K8sManager) oomKilledPods(app *Application) []string {
+	namespace, _ := applicationTarget(app)
+	pods, err := km.ListCachedPods(app.KubeConfigID, namespace)
+	if err != nil {
+		return nil
+	}
+
+	var result []string
+	for _, pod := range pods {
+		if pod.Labels["app-id"] != app.ID {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if (status.LastTerminationState.Terminated != nil && status.LastTerminationState.Terminated.Reason == "OOMKilled") ||
+				(status.State.Terminated != nil && status.State.Terminated.Reason == "OOMKilled") {
+				result = append(result, pod.Name)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// 计算样本的分位数
+func usagePercentiles(samples []int64) UsagePercentiles {
+	if len(samples) == 0 {
+		return UsagePercentiles{}
+	}
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p float64) int64 {
+		return sorted[int(p*float64(len(sorted)-1))]
+	}
+	return UsagePercentiles{
+		P50: percentile(0.50),
+		P95: percentile(0.95),
+		P99: percentile(0.99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// CPU向上取整到5m的倍数
+func formatMilli(millicores int64) string {
+	return fmt.Sprintf("%dm", (millicores+4)/5*5)
+}
+
+// 内存按Mi向上取整
+func formatMebibytes(bytes int64) string {
+	const mebibyte = 1024 * 1024
+	return fmt.Sprintf("%dMi", (bytes+mebibyte-1)/mebibyte)
+}
@@ -0,0 +1,50 @@
+package model
+
+import "testing"
+
+func TestUsagePercentiles(t *testing.T) {
+	hundred := make([]int64, 100)
+	for i := range hundred {
+		hundred[i] = int64(100 - i)
+	}
+
+	tests := []struct {
+		name    string
+		samples []int64
+		want    UsagePercentiles
+	}{
+		{
+			name: "没有样本",
+			want: UsagePercentiles{},
+		},
+		{
+			name:    "单个样本",
+			samples: []int64{42},
+			want:    UsagePercentiles{P50: 42, P95: 42, P99: 42, Max: 42},
+		},
+		{
+			name:    "未排序的样本",
+			samples: []int64{30, 10, 20},
+			want:    UsagePercentiles{P50: 20, P95: 20, P99: 20, Max: 30},
+		},
+		{
+			name:    "一百个样本",
+			samples: hundred,
+			want:    UsagePercentiles{P50: 50, P95: 95, P99: 99, Max: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := append([]int64(nil), tt.samples...)
+			if got := usagePercentiles(input); got != tt.want {
+				t.Errorf("usagePercentiles(%v) = %+v, want %+v", tt.samples, got, tt.want)
+			}
+			for i := range input {
+				if input[i] != tt.samples[i] {
+					t.Fatalf("usagePercentiles修改了输入样本")
+				}
+			}
+		})
+	}
+}
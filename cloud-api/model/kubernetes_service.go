@@ -0,0 +1,261 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 服务类型（在Kubernetes原生类型之外增加Headless）
+const (
+	ServiceTypeClusterIP    = "ClusterIP"
+	ServiceTypeNodePort     = "NodePort"
+	ServiceTypeLoadBalancer = "LoadBalancer"
+	ServiceTypeExternalName = "ExternalName"
+	ServiceTypeHeadless     = "Headless"
+)
+
+// ValidateServiceConfig 校验服务类型和服务配置是否匹配
+func ValidateServiceConfig(serviceType string, config *ServiceConfig) error {
+	switch serviceType {
+	case "", ServiceTypeClusterIP, ServiceTypeNodePort, ServiceTypeLoadBalancer, ServiceTypeHeadless:
+	case ServiceTypeExternalName:
+		if config == nil || config.ExternalName == "" {
+			return fmt.Errorf("ExternalName类型的服务必须指定externalName")
+		}
+	default:
+		return fmt.Errorf("不支持的服务类型: %s", serviceType)
+	}
+
+	if config == nil {
+		return nil
+	}
+
+	if config.NodePort != 0 {
+		if serviceType != ServiceTypeNodePort && serviceType != ServiceTypeLoadBalancer {
+			return fmt.Errorf("只有NodePort或LoadBalancer类型的服务可以指定nodePort")
+		}
+		if config.NodePort < 1 || config.NodePort > 65535 {
+			return fmt.Errorf("无效的nodePort: %d", config.NodePort)
+		}
+	}
+
+	switch config.SessionAffinity {
+	case "", string(corev1.ServiceAffinityNone), string(corev1.ServiceAffinityClientIP):
+	default:
+		return fmt.Errorf("不支持的会话亲和性: %s", config.SessionAffinity)
+	}
+	if config.SessionAffinityTimeoutSeconds != nil {
+		timeout := *config.SessionAffinityTimeoutSeconds
+		if timeout <= 0 || timeout > 86400 {
+			return fmt.Errorf("会话亲和性超时时间必须在1-86400秒之间")
+		}
+	}
+
+	if config.ExternalTrafficPolicy != "" {
+		if serviceType != ServiceTypeNodePort && serviceType != ServiceTypeLoadBalancer {
+			return fmt.Errorf("只有NodePort或LoadBalancer类型的服务可以设置externalTrafficPolicy")
+		}
+		if config.ExternalTrafficPolicy != string(corev1.ServiceExternalTrafficPolicyTypeCluster) &&
+			config.ExternalTrafficPolicy != string(corev1.ServiceExternalTrafficPolicyTypeLocal) {
+			return fmt.Errorf("不支持的externalTrafficPolicy: %s", config.ExternalTrafficPolicy)
+		}
+	}
+	if config.InternalTrafficPolicy != "" &&
+		config.InternalTrafficPolicy != string(corev1.ServiceInternalTrafficPolicyCluster) &&
+		config.InternalTrafficPolicy != string(corev1.ServiceInternalTrafficPolicyLocal) {
+		return fmt.Errorf("不支持的internalTrafficPolicy: %s", config.InternalTrafficPolicy)
+	}
+
+	if len(config.LoadBalancerSourceRanges) > 0 && serviceType != ServiceTypeLoadBalancer {
+		return fmt.Errorf("只有LoadBalancer类型的服务可以设置loadBalancerSourceRanges")
+	}
+
+	return nil
+}
+
+// 根据应用配置构建Service
+func buildApplicationService(app *Application, namespace string, appName string, containerPort int32) *corev1.Service {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      appName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app":        appName,
+				"managed-by": "cloud-deployment-api",
+				"app-id":     app.ID,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{
+				{
+					Port:       containerPort,
+					TargetPort: intstr.FromInt(int(containerPort)),
+				},
+			},
+			Selector: map[string]string{
+				"app": appName,
+			},
+		},
+	}
+
+	config := app.ServiceConfig
+	if config == nil {
+		config = &ServiceConfig{}
+	}
+
+	switch app.ServiceType {
+	case ServiceTypeNodePort:
+		service.Spec.Type = corev1.ServiceTypeNodePort
+	case ServiceTypeLoadBalancer:
+		service.Spec.Type = corev1.ServiceTypeLoadBalancer
+	case ServiceTypeHeadless:
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	case ServiceTypeExternalName:
+		// ExternalName服务只做DNS别名，不选择Pod
+		service.Spec.Type = corev1.ServiceTypeExternalName
+		service.Spec.ExternalName = config.ExternalName
+		service.Spec.Selector = nil
+	}
+
+	if config.Headless && service.Spec.Type == corev1.ServiceTypeClusterIP {
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	// 固定NodePort端口
+	if config.NodePort > 0 && (service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer) {
+		service.Spec.Ports[0].NodePort = config.NodePort
+	}
+
+	// 会话亲和性
+	if config.SessionAffinity == string(corev1.ServiceAffinityClientIP) && service.Spec.Type != corev1.ServiceTypeExternalName {
+		service.Spec.SessionAffinity = corev1.ServiceAffinityClientIP
+		if config.SessionAffinityTimeoutSeconds != nil {
+			timeout := *config.SessionAffinityTimeoutSeconds
+			service.Spec.SessionAffinityConfig = &corev1.SessionAffinityConfig{
+				ClientIP: &corev1.ClientIPConfig{TimeoutSeconds: &timeout},
+			}
+		}
+	}
+
+	// 流量策略
+	if config.ExternalTrafficPolicy != "" && (service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer) {
+		service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyType(config.ExternalTrafficPolicy)
+	}
+	if config.InternalTrafficPolicy != "" && service.Spec.Type != corev1.ServiceTypeExternalName {
+		policy := corev1.ServiceInternalTrafficPolicyType(config.InternalTrafficPolicy)
+		service.Spec.InternalTrafficPolicy = &policy
+	}
+
+	// 负载均衡器访问来源限制和云厂商注解
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		service.Spec.LoadBalancerSourceRanges = config.LoadBalancerSourceRanges
+	}
+	if len(config.Annotations) > 0 {
+		service.Annotations = map[string]string{}
+		for k, v := range config.Annotations {
+			service.Annotations[k] = v
+		}
+	}
+
+	return service
+}
+
+// 判断已有Service是否需要删除重建（clusterIP和ExternalName类型的切换无法原地更新）
+func serviceNeedsRecreate(existing *corev1.Service, desired *corev1.Service) bool {
+	existingHeadless := existing.Spec.ClusterIP == corev1.ClusterIPNone
+	desiredHeadless := desired.Spec.ClusterIP == corev1.ClusterIPNone
+	if existingHeadless != desiredHeadless {
+		return true
+	}
+	return (existing.Spec.Type == corev1.ServiceTypeExternalName) != (desired.Spec.Type == corev1.ServiceTypeExternalName)
+}
+
+// 创建或更新应用的Service
+func applyApplicationService(client kubernetes.Interface, service *corev1.Service) error {
+	namespace := service.Namespace
+	name := service.Name
+
+	existing, err := client.CoreV1().Services(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			log.Printf("获取Service失败: %v", err)
+			return fmt.Errorf("获取Service失败: %v", err)
+		}
+
+		log.Printf("创建Service: %s/%s", namespace, name)
+		if _, err := client.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+			log.Printf("创建Service失败: %v", err)
+			return fmt.Errorf("创建Service失败: %v", err)
+		}
+		log.Printf("创建Service成功: %s/%s", namespace, name)
+		return nil
+	}
+
+	if serviceNeedsRecreate(existing, service) {
+		log.Printf("Service类型变更无法原地更新，删除后重建: %s/%s", namespace, name)
+		if err := client.CoreV1().Services(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil && !k8serrors.IsNotFound(err) {
+			log.Printf("删除Service失败: %v", err)
+			return fmt.Errorf("删除Service失败: %v", err)
+		}
+		if _, err := client.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{}); err != nil {
+			log.Printf("重建Service失败: %v", err)
+			return fmt.Errorf("重建Service失败: %v", err)
+		}
+		log.Printf("重建Service成功: %s/%s", namespace, name)
+		return nil
+	}
+
+	// 保留集群分配的字段
+	service.ResourceVersion = existing.ResourceVersion
+	if service.Spec.Type != corev1.ServiceTypeExternalName {
+		service.Spec.ClusterIP = existing.Spec.ClusterIP
+		service.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	}
+	if service.Spec.Ports[0].NodePort == 0 && len(existing.Spec.Ports) > 0 {
+		service.Spec.Ports[0].NodePort = existing.Spec.Ports[0].NodePort
+	}
+	if service.Spec.Type == corev1.ServiceTypeClusterIP || service.Spec.Type == corev1.ServiceTypeExternalName {
+		service.Spec.Ports[0].NodePort = 0
+	}
+
+	log.Printf("Service已存在，尝试更新: %s/%s", namespace, name)
+	if _, err := client.CoreV1().Services(namespace).Update(context.TODO(), service, metav1.UpdateOptions{}); err != nil {
+		log.Printf("更新Service失败: %v", err)
+		return fmt.Errorf("更新Service失败: %v", err)
+	}
+	log.Printf("更新Service成功: %s/%s", namespace, name)
+	return nil
+}
+
+// SummarizeEndpoints 汇总Service后端端点的就绪情况
+func SummarizeEndpoints(endpoints *corev1.Endpoints) map[string]interface{} {
+	readyAddresses := []string{}
+	notReadyAddresses := []string{}
+
+	if endpoints != nil {
+		for _, subset := range endpoints.Subsets {
+			for _, addr := range subset.Addresses {
+				readyAddresses = append(readyAddresses, addr.IP)
+			}
+			for _, addr := range subset.NotReadyAddresses {
+				notReadyAddresses = append(notReadyAddresses, addr.IP)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"ready":             len(readyAddresses),
+		"notReady":          len(notReadyAddresses),
+		"readyAddresses":    readyAddresses,
+		"notReadyAddresses": notReadyAddresses,
+		"hasReadyEndpoints": len(readyAddresses) > 0,
+	}
+}
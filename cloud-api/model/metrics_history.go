@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 采样记录保留时长
+	MetricsHistoryRetention = 24 * time.Hour
+	// 采样间隔
+	MetricsSampleInterval = time.Minute
+)
+
+// MetricsSample 单个Pod的一次资源用量采样
+type MetricsSample struct {
+	ID            string    `json:"-" db:"id"`
+	ApplicationID string    `json:"applicationId" db:"application_id"`
+	KubeConfigID  string    `json:"kubeConfigId" db:"kube_config_id"`
+	Namespace     string    `json:"namespace" db:"namespace"`
+	PodName       string    `json:"podName" db:"pod_name"`
+	CPUMillicores int64     `json:"cpuMillicores" db:"cpu_millicores"`
+	MemoryBytes   int64     `json:"memoryBytes" db:"memory_bytes"`
+	SampledAt     time.Time `json:"sampledAt" db:"sampled_at"`
+}
+
+// MetricsPoint 时间序列中的一个点
+type MetricsPoint struct {
+	Timestamp     time.Time `json:"timestamp"`
+	CPUMillicores int64     `json:"cpuMillicores"`
+	MemoryBytes   int64     `json:"memoryBytes"`
+	Pods          int       `json:"pods,omitempty"`
+}
+
+// PodMetricsSeries 单个Pod的用量时间序列
+type PodMetricsSeries struct {
+	Name   string         `json:"name"`
+	Points []MetricsPoint `json:"points"`
+}
+
+// ApplicationMetricsHistory 应用的用量时间序列，Total为每次采样时所有Pod的用量之和
+type ApplicationMetricsHistory struct {
+	ApplicationID string             `json:"applicationId"`
+	Since         time.Time          `json:"since"`
+	Total         []MetricsPoint     `json:"total"`
+	Pods          []PodMetricsSeries `json:"pods"`
+}
+
+// SaveMetricsSamplesToDB 批量保存采样记录
+func SaveMetricsSamplesToDB(samples []MetricsSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("开启事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO application_metrics_samples (id, application_id, kube_config_id, namespace, pod_name,
+            cpu_millicores, memory_bytes, sampled_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+    `
+	for i := range samples {
+		sample := &samples[i]
+		if sample.ID == "" {
+			sample.ID = uuid.New().String()
+		}
+		if _, err := tx.Exec(query, sample.ID, sample.ApplicationID, sample.KubeConfigID, sample.Namespace,
+			sample.PodName, sample.CPUMillicores, sample.MemoryBytes, sample.SampledAt); err != nil {
+			return fmt.Errorf("保存资源用量采样失败: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交资源用量采样失败: %v", err)
+	}
+	return nil
+}
+
+// GetApplicationMetricsHistoryFromDB 获取应用从since开始的用量时间序列
+func GetApplicationMetricsHistoryFromDB(appID string, since time.Time) (*ApplicationMetricsHistory, error) {
+	samples := []MetricsSample{}
+	query := `
+        SELECT id, application_id, kube_config_id, namespace, pod_name, cpu_millicores, memory_bytes, sampled_at
+        FROM application_metrics_samples
+        WHERE application_id = $1 AND sampled_at >= $2
+        ORDER BY sampled_at
+    `
+	if err := DB.Select(&samples, query, appID, since); err != nil {
+		return nil, fmt.Errorf("查询资源用量采样失败: %v", err)
+	}
+
+	history := &ApplicationMetricsHistory{
+		ApplicationID: appID,
+		Since:         since,
+		Total:         []MetricsPoint{},
+		Pods:          []PodMetricsSeries{},
+	}
+	podIndex := make(map[string]int)
+	for _, sample := range samples {
+		point := MetricsPoint{
+			Timestamp:     sample.SampledAt,
+			CPUMillicores: sample.CPUMillicores,
+			MemoryBytes:   sample.MemoryBytes,
+		}
+
+		// 同一轮采样的记录时间相同，按时间排序后相邻
+		if n := len(history.Total); n > 0 && history.Total[n-1].Timestamp.Equal(sample.SampledAt) {
+			history.Total[n-1].CPUMillicores += sample.CPUMillicores
+			history.Total[n-1].MemoryBytes += sample.MemoryBytes
+			history.Total[n-1].Pods++
+		} else {
+			total := point
+			total.Pods = 1
+			history.Total = append(history.Total, total)
+		}
+
+		i, ok := podIndex[sample.PodName]
+		if !ok {
+			i = len(history.Pods)
+			podIndex[sample.PodName] = i
+			history.Pods = append(history.Pods, PodMetricsSeries{Name: sample.PodName})
+		}
+		history.Pods[i].Points = append(history.Pods[i].Points, point)
+	}
+
+	sort.Slice(history.Pods, func(i, j int) bool { return history.Pods[i].Name < history.Pods[j].Name })
+	return history, nil
+}
+
+// PurgeMetricsSamplesFromDB 删除超过保留时长的采样记录
+func PurgeMetricsSamplesFromDB() error {
+	if _, err := DB.Exec("DELETE FROM application_metrics_samples WHERE sampled_at < $1",
+		time.Now().Add(-MetricsHistoryRetention)); err != nil {
+		return fmt.Errorf("删除过期资源用量采样失败: %v", err)
+	}
+	return nil
+}
+
+// RecordApplicationMetrics 采集所有集群中应用Pod的资源用量并删除过期的采样
+func (km *K8sManager) RecordApplicationMetrics() {
+	configs, err := GetKubeConfigsFromDB()
+	if err != nil {
+		log.Printf("获取集群列表失败，无法采集资源用量: %v", err)
+		return
+	}
+
+	sampledAt := time.Now().Truncate(time.Second)
+	for _, config := range configs {
+		usages, err := km.GetPodMetrics(config.ID, "", managedBySelector.String())
+		if err != nil {
+			if err != ErrMetricsUnavailable {
+				log.Printf("采集集群 %s 的资源用量失败: %v", config.ID, err)
+			}
+			continue
+		}
+
+		samples := make([]MetricsSample, 0, len(usages))
+		for _, usage := range usages {
+			if usage.ApplicationID == "" {
+				continue
+			}
+			samples = append(samples, MetricsSample{
+				ApplicationID: usage.ApplicationID,
+				KubeConfigID:  config.ID,
+				Namespace:     usage.Namespace,
+				PodName:       usage.Name,
+				CPUMillicores: usage.CPUMillicores,
+				MemoryBytes:   usage.MemoryBytes,
+				SampledAt:     sampledAt,
+			})
+		}
+		if err := SaveMetricsSamplesToDB(samples); err != nil {
+			log.Printf("保存集群 %s 的资源用量失败: %v", config.ID, err)
+		}
+	}
+
+	if err := PurgeMetricsSamplesFromDB(); err != nil {
+		log.Printf("%v", err)
+	}
+}
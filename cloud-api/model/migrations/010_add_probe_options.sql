@@ -0,0 +1,8 @@
+-- 为applications表添加完整探针模型所需字段
+-- 探针的gRPC、HTTP协议/请求头/主机、exec参数数组和优雅终止时间均保存在探针JSON中，无需新增列
+
+-- 端口协议，用于生成默认探针
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS port_protocol VARCHAR(20) DEFAULT 'http';
+
+-- 添加注释
+COMMENT ON COLUMN applications.port_protocol IS '端口协议: http, https, grpc, tcp';
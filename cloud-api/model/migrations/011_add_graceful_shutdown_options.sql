@@ -0,0 +1,8 @@
+-- 为applications表添加优雅停止相关字段
+-- preStop等待时间、HTTP钩子协议和请求头保存在lifecycle_json中，无需新增列
+
+-- Pod优雅终止时间（秒），为空时使用Kubernetes默认值30秒
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS termination_grace_period_seconds BIGINT DEFAULT NULL;
+
+-- 添加注释
+COMMENT ON COLUMN applications.termination_grace_period_seconds IS 'Pod优雅终止时间（秒）';
@@ -0,0 +1,8 @@
+-- 添加调度相关字段：拓扑分布约束、优先级类和调度预设
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS topology_spread_constraints_json TEXT;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS priority_class_name VARCHAR(253) DEFAULT '';
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS scheduling_presets_json TEXT;
+
+COMMENT ON COLUMN applications.topology_spread_constraints_json IS '拓扑分布约束(JSON)';
+COMMENT ON COLUMN applications.priority_class_name IS 'Pod优先级类名称';
+COMMENT ON COLUMN applications.scheduling_presets_json IS '调度预设列表(JSON)，如spread-across-nodes、spread-across-zones';
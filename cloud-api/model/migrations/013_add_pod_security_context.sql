@@ -0,0 +1,6 @@
+-- 为applications表添加Pod级别安全上下文字段
+-- 容器级别的capabilities和seccompProfile保存在security_context_json中，无需新增列
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS pod_security_context_json TEXT;
+
+-- 添加注释
+COMMENT ON COLUMN applications.pod_security_context_json IS 'Pod安全上下文(JSON)，包括fsGroup、supplementalGroups、sysctls和seccompProfile';
@@ -0,0 +1,6 @@
+-- 为applications表添加Service高级配置字段
+-- 包括无头服务、ExternalName、会话亲和性、流量策略、固定NodePort、负载均衡器来源限制和注解
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS service_config_json TEXT;
+
+-- 添加注释
+COMMENT ON COLUMN applications.service_config_json IS 'Service高级配置(JSON)';
@@ -0,0 +1,6 @@
+-- 为applications表添加网络策略字段
+-- 保存允许访问应用的来源（应用、命名空间、CIDR）以及出站白名单
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS network_policy_json TEXT;
+
+-- 添加注释
+COMMENT ON COLUMN applications.network_policy_json IS '网络策略配置(JSON)';
@@ -0,0 +1,5 @@
+-- 为applications表添加Pod中断预算字段
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS disruption_budget_json TEXT;
+
+-- 添加注释
+COMMENT ON COLUMN applications.disruption_budget_json IS 'Pod中断预算配置(JSON)，包括minAvailable或maxUnavailable';
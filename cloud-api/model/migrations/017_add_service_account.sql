@@ -0,0 +1,5 @@
+-- 为applications表添加ServiceAccount和RBAC配置字段
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS service_account_json TEXT;
+
+-- 添加注释
+COMMENT ON COLUMN applications.service_account_json IS 'ServiceAccount和RBAC规则配置(JSON)';
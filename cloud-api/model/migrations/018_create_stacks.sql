@@ -0,0 +1,21 @@
+-- 创建应用栈表
+-- 应用栈由多个存在依赖关系的应用组成，部署时按拓扑顺序依次部署
+CREATE TABLE IF NOT EXISTS stacks (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    members_json TEXT,
+    status VARCHAR(50) NOT NULL DEFAULT 'created',
+    message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_stacks_deleted_at ON stacks(deleted_at);
+
+-- 添加注释
+COMMENT ON TABLE stacks IS '应用栈';
+COMMENT ON COLUMN stacks.members_json IS '成员应用及其依赖关系(JSON)';
+COMMENT ON COLUMN stacks.status IS '状态: created, deploying, deployed, deleting, error, deleted';
+COMMENT ON COLUMN stacks.message IS '最近一次部署或删除的进度/错误信息';
@@ -0,0 +1,28 @@
+-- 为applications表添加容器资源配置字段
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS resources_json TEXT;
+
+COMMENT ON COLUMN applications.resources_json IS '容器资源请求和限制(JSON)';
+
+-- 创建部署策略表
+-- 策略可作用于所有集群、指定集群或指定命名空间，在创建、更新和部署应用时评估
+CREATE TABLE IF NOT EXISTS deployment_policies (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kube_config_id VARCHAR(36) NOT NULL DEFAULT '',
+    namespace VARCHAR(253) NOT NULL DEFAULT '',
+    rule VARCHAR(50) NOT NULL,
+    params_json TEXT,
+    mode VARCHAR(20) NOT NULL DEFAULT 'enforce',
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deployment_policies_scope ON deployment_policies(kube_config_id, namespace);
+
+COMMENT ON TABLE deployment_policies IS '部署策略';
+COMMENT ON COLUMN deployment_policies.kube_config_id IS '作用的集群ID，为空表示所有集群';
+COMMENT ON COLUMN deployment_policies.namespace IS '作用的命名空间，为空表示所有命名空间';
+COMMENT ON COLUMN deployment_policies.rule IS '规则: forbid-latest-tag, require-resource-limits, forbid-privileged, forbid-host-path, require-readiness-probe, allowed-registries';
+COMMENT ON COLUMN deployment_policies.mode IS '模式: warn仅警告, enforce拒绝';
@@ -0,0 +1,19 @@
+-- 创建价格表
+-- 每个集群可配置独立的单价，kube_config_id为空的记录作为全局默认价格
+CREATE TABLE IF NOT EXISTS price_books (
+    kube_config_id VARCHAR(36) PRIMARY KEY,
+    currency VARCHAR(10) NOT NULL DEFAULT 'CNY',
+    cpu_core_hour NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    memory_gib_hour NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    storage_gib_month NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    load_balancer_month NUMERIC(12, 6) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE price_books IS '资源价格表';
+COMMENT ON COLUMN price_books.kube_config_id IS '集群ID，为空表示全局默认价格';
+COMMENT ON COLUMN price_books.cpu_core_hour IS '每CPU核每小时价格';
+COMMENT ON COLUMN price_books.memory_gib_hour IS '每GiB内存每小时价格';
+COMMENT ON COLUMN price_books.storage_gib_month IS '每GiB存储每月价格';
+COMMENT ON COLUMN price_books.load_balancer_month IS '每个LoadBalancer每月价格';
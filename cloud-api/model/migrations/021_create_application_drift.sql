@@ -0,0 +1,13 @@
+-- 创建应用配置漂移检测结果表
+-- 每个应用只保留最近一次检测结果，期望清单保存在kubernetes_resources.resource_yaml中
+CREATE TABLE IF NOT EXISTS application_drift (
+    application_id VARCHAR(36) PRIMARY KEY,
+    drifted BOOLEAN NOT NULL DEFAULT false,
+    result_json TEXT,
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_drift_drifted ON application_drift(drifted);
+
+COMMENT ON TABLE application_drift IS '应用配置漂移检测结果';
+COMMENT ON COLUMN application_drift.result_json IS '字段级差异(JSON)';
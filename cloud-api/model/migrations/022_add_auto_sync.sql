@@ -0,0 +1,22 @@
+-- 为applications表添加自动同步开关
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS auto_sync BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN applications.auto_sync IS '是否自动同步，开启后集群中的对象缺失或漂移时自动重新部署';
+
+-- 创建应用同步记录表
+CREATE TABLE IF NOT EXISTS application_sync_attempts (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36) NOT NULL,
+    trigger VARCHAR(20) NOT NULL,
+    reason TEXT,
+    result VARCHAR(20) NOT NULL,
+    message TEXT,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_sync_attempts_app ON application_sync_attempts(application_id, started_at DESC);
+
+COMMENT ON TABLE application_sync_attempts IS '应用同步记录';
+COMMENT ON COLUMN application_sync_attempts.trigger IS '触发方式: auto(自动同步), manual(手动同步)';
+COMMENT ON COLUMN application_sync_attempts.result IS '同步结果: success, failed, blocked(被策略或配额阻止)';
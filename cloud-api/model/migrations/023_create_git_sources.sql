@@ -0,0 +1,39 @@
+-- 创建git源表
+-- git源定期拉取git仓库中指定目录下的应用定义，并据此创建、更新、删除和部署应用
+CREATE TABLE IF NOT EXISTS git_sources (
+    id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    repo_url TEXT NOT NULL,
+    branch VARCHAR(255) NOT NULL DEFAULT '',
+    path TEXT NOT NULL DEFAULT '',
+    kube_config_id VARCHAR(36) NOT NULL DEFAULT '',
+    interval_seconds INTEGER NOT NULL DEFAULT 300,
+    prune BOOLEAN NOT NULL DEFAULT false,
+    last_commit VARCHAR(64) NOT NULL DEFAULT '',
+    status VARCHAR(50) NOT NULL DEFAULT 'pending',
+    message TEXT NOT NULL DEFAULT '',
+    app_status_json TEXT,
+    last_synced_at TIMESTAMP DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_git_sources_deleted_at ON git_sources(deleted_at);
+
+-- 为applications表添加管理该应用的git源ID
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS source_id VARCHAR(36) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_applications_source_id ON applications(source_id);
+
+-- 添加注释
+COMMENT ON TABLE git_sources IS 'git源';
+COMMENT ON COLUMN git_sources.repo_url IS 'git仓库地址，支持本地路径和file://地址';
+COMMENT ON COLUMN git_sources.branch IS '分支，为空时使用仓库默认分支';
+COMMENT ON COLUMN git_sources.path IS '仓库中存放应用定义的目录';
+COMMENT ON COLUMN git_sources.kube_config_id IS '应用定义未指定集群时使用的集群ID';
+COMMENT ON COLUMN git_sources.prune IS '是否删除仓库中已不存在的应用';
+COMMENT ON COLUMN git_sources.last_commit IS '最近一次同步的提交';
+COMMENT ON COLUMN git_sources.status IS '状态: pending, syncing, synced, error';
+COMMENT ON COLUMN git_sources.app_status_json IS '最近一次同步中每个应用的同步结果(JSON)';
+COMMENT ON COLUMN applications.source_id IS '管理该应用的git源ID，为空表示通过API管理';
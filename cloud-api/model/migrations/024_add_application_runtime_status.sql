@@ -0,0 +1,6 @@
+-- 为applications表添加由集群informer维护的运行状态字段
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS ready_replicas INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE applications ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP DEFAULT NULL;
+
+COMMENT ON COLUMN applications.ready_replicas IS '集群中就绪的副本数';
+COMMENT ON COLUMN applications.status_changed_at IS '最近一次状态变化的时间';
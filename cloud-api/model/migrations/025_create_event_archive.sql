@@ -0,0 +1,39 @@
+-- 创建集群事件归档表
+-- Kubernetes默认只保留约一小时的事件，归档后可用于事后排查
+CREATE TABLE IF NOT EXISTS cluster_events (
+    id VARCHAR(36) PRIMARY KEY,
+    kube_config_id VARCHAR(36) NOT NULL,
+    namespace VARCHAR(255) NOT NULL DEFAULT '',
+    object_kind VARCHAR(100) NOT NULL DEFAULT '',
+    object_name VARCHAR(255) NOT NULL DEFAULT '',
+    object_uid VARCHAR(36) NOT NULL DEFAULT '',
+    application_id VARCHAR(36) NOT NULL DEFAULT '',
+    type VARCHAR(20) NOT NULL DEFAULT '',
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    message TEXT NOT NULL DEFAULT '',
+    count INTEGER NOT NULL DEFAULT 1,
+    source VARCHAR(255) NOT NULL DEFAULT '',
+    first_timestamp TIMESTAMP NOT NULL,
+    last_timestamp TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 同一对象同一原因的事件每次发生时count递增，按count去重
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cluster_events_dedup
+    ON cluster_events(kube_config_id, namespace, object_kind, object_name, object_uid, reason, count);
+CREATE INDEX IF NOT EXISTS idx_cluster_events_time ON cluster_events(kube_config_id, last_timestamp DESC);
+CREATE INDEX IF NOT EXISTS idx_cluster_events_app ON cluster_events(application_id, last_timestamp DESC);
+
+-- 创建事件归档设置表，未设置的集群使用默认保留天数
+CREATE TABLE IF NOT EXISTS event_archive_settings (
+    kube_config_id VARCHAR(36) PRIMARY KEY,
+    retention_days INTEGER NOT NULL DEFAULT 7,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 添加注释
+COMMENT ON TABLE cluster_events IS '集群事件归档';
+COMMENT ON COLUMN cluster_events.application_id IS '事件所属应用的ID，无法确定时为空';
+COMMENT ON COLUMN cluster_events.count IS '事件在该对象上发生的次数，与Kubernetes事件的count一致';
+COMMENT ON TABLE event_archive_settings IS '事件归档设置';
+COMMENT ON COLUMN event_archive_settings.retention_days IS '事件保留天数';
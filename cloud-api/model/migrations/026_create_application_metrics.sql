@@ -0,0 +1,21 @@
+-- 创建应用资源用量采样表
+-- 定期从metrics-server采集应用各Pod的CPU和内存用量，只保留最近一段时间的数据用于趋势图
+CREATE TABLE IF NOT EXISTS application_metrics_samples (
+    id VARCHAR(36) PRIMARY KEY,
+    application_id VARCHAR(36) NOT NULL,
+    kube_config_id VARCHAR(36) NOT NULL,
+    namespace VARCHAR(255) NOT NULL,
+    pod_name VARCHAR(255) NOT NULL,
+    cpu_millicores BIGINT NOT NULL DEFAULT 0,
+    memory_bytes BIGINT NOT NULL DEFAULT 0,
+    sampled_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_metrics_samples_app ON application_metrics_samples(application_id, sampled_at);
+CREATE INDEX IF NOT EXISTS idx_application_metrics_samples_time ON application_metrics_samples(sampled_at);
+
+-- 添加注释
+COMMENT ON TABLE application_metrics_samples IS '应用资源用量采样';
+COMMENT ON COLUMN application_metrics_samples.cpu_millicores IS 'Pod的CPU用量(毫核)';
+COMMENT ON COLUMN application_metrics_samples.memory_bytes IS 'Pod的内存用量(字节)';
+COMMENT ON COLUMN application_metrics_samples.sampled_at IS '采样时间，同一轮采样的记录相同';
@@ -0,0 +1,11 @@
+-- 创建命名空间初始化配置表
+-- 通过接口创建命名空间时按集群的配置自动添加标签、ResourceQuota、LimitRange、默认拒绝策略和镜像拉取Secret
+CREATE TABLE IF NOT EXISTS namespace_onboarding_settings (
+    kube_config_id VARCHAR(36) PRIMARY KEY,
+    bundle_json TEXT NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 添加注释
+COMMENT ON TABLE namespace_onboarding_settings IS '命名空间初始化配置';
+COMMENT ON COLUMN namespace_onboarding_settings.bundle_json IS 'JSON格式的初始化内容：labels、resourceQuota、limitRange、defaultDeny、denyEgress、registryIds';
@@ -0,0 +1,11 @@
+-- 为cluster_events表添加Kubernetes事件自身的UID，按事件UID和count去重
+-- 原去重键不含事件标识，同一对象同一原因的新事件会被当作重复丢弃
+ALTER TABLE cluster_events ADD COLUMN IF NOT EXISTS event_uid VARCHAR(36) NOT NULL DEFAULT '';
+
+-- 已归档的事件没有事件UID，使用记录ID保证唯一
+UPDATE cluster_events SET event_uid = id WHERE event_uid = '';
+
+DROP INDEX IF EXISTS idx_cluster_events_dedup;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cluster_events_dedup ON cluster_events(kube_config_id, event_uid, count);
+
+COMMENT ON COLUMN cluster_events.event_uid IS 'Kubernetes事件的metadata.uid';
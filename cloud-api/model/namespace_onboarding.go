@@ -0,0 +1,125 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// 初始化配置中ResourceQuota和LimitRange未指定名称时使用的名称
+	defaultOnboardingQuotaName      = "default-quota"
+	defaultOnboardingLimitRangeName = "default-limits"
+)
+
+// NamespaceOnboarding 集群的命名空间初始化配置，通过接口创建命名空间时自动应用
+type NamespaceOnboarding struct {
+	KubeConfigID  string             `json:"kubeConfigId"`
+	Labels        map[string]string  `json:"labels,omitempty"`
+	ResourceQuota *ResourceQuotaSpec `json:"resourceQuota,omitempty"`
+	LimitRange    *LimitRangeSpec    `json:"limitRange,omitempty"`
+	DefaultDeny   bool               `json:"defaultDeny"`
+	DenyEgress    bool               `json:"denyEgress"`
+	// 需要在命名空间中创建镜像拉取Secret的镜像仓库ID，Secret同时添加到default ServiceAccount
+	RegistryIDs []string  `json:"registryIds,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// 校验标签名和标签值
+func validateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("标签名 %s 无效: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("标签 %s 的值无效: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// ValidateNamespaceOnboarding 校验命名空间初始化配置，并为ResourceQuota和LimitRange设置默认名称
+func ValidateNamespaceOnboarding(onboarding *NamespaceOnboarding) error {
+	if err := validateLabels(onboarding.Labels); err != nil {
+		return err
+	}
+	if onboarding.ResourceQuota != nil {
+		if onboarding.ResourceQuota.Name == "" {
+			onboarding.ResourceQuota.Name = defaultOnboardingQuotaName
+		}
+		if err := ValidateResourceQuotaSpec(onboarding.ResourceQuota); err != nil {
+			return fmt.Errorf("resourceQuota: %v", err)
+		}
+	}
+	if onboarding.LimitRange != nil {
+		if onboarding.LimitRange.Name == "" {
+			onboarding.LimitRange.Name = defaultOnboardingLimitRangeName
+		}
+		if err := ValidateLimitRangeSpec(onboarding.LimitRange); err != nil {
+			return fmt.Errorf("limitRange: %v", err)
+		}
+	}
+	if onboarding.DenyEgress && !onboarding.DefaultDeny {
+		return fmt.Errorf("denyEgress需要同时开启defaultDeny")
+	}
+	for _, id := range onboarding.RegistryIDs {
+		registry, err := GetRegistryByIDFromDB(id)
+		if err != nil {
+			return fmt.Errorf("镜像仓库 %s 不存在", id)
+		}
+		if registry.Username == "" {
+			return fmt.Errorf("镜像仓库 %s 未配置凭据，无法生成镜像拉取Secret", registry.Name)
+		}
+	}
+	return nil
+}
+
+// GetNamespaceOnboardingFromDB 获取集群的命名空间初始化配置，未设置时返回空配置
+func GetNamespaceOnboardingFromDB(kubeConfigID string) (*NamespaceOnboarding, error) {
+	var row struct {
+		BundleJSON string    `db:"bundle_json"`
+		UpdatedAt  time.Time `db:"updated_at"`
+	}
+	query := `
+        SELECT bundle_json, updated_at
+        FROM namespace_onboarding_settings
+        WHERE kube_config_id = $1
+    `
+	if err := DB.Get(&row, query, kubeConfigID); err != nil {
+		if err == sql.ErrNoRows {
+			return &NamespaceOnboarding{KubeConfigID: kubeConfigID}, nil
+		}
+		return nil, fmt.Errorf("查询命名空间初始化配置失败: %v", err)
+	}
+
+	onboarding := &NamespaceOnboarding{}
+	if err := json.Unmarshal([]byte(row.BundleJSON), onboarding); err != nil {
+		return nil, fmt.Errorf("解析命名空间初始化配置失败: %v", err)
+	}
+	onboarding.KubeConfigID = kubeConfigID
+	onboarding.UpdatedAt = row.UpdatedAt
+	return onboarding, nil
+}
+
+// SaveNamespaceOnboardingToDB 保存集群的命名空间初始化配置
+func SaveNamespaceOnboardingToDB(onboarding *NamespaceOnboarding) error {
+	onboarding.UpdatedAt = time.Now()
+	bundleJSON, err := serializeJSONField(onboarding)
+	if err != nil {
+		return fmt.Errorf("序列化命名空间初始化配置失败: %v", err)
+	}
+
+	query := `
+        INSERT INTO namespace_onboarding_settings (kube_config_id, bundle_json, updated_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (kube_config_id) DO UPDATE SET bundle_json = $2, updated_at = $3
+    `
+	if _, err := DB.Exec(query, onboarding.KubeConfigID, bundleJSON, onboarding.UpdatedAt); err != nil {
+		return fmt.Errorf("保存命名空间初始化配置失败: %v", err)
+	}
+	return nil
+}
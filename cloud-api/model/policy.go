@@ -0,0 +1,387 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 策略规则类型
+const (
+	PolicyRuleForbidLatestTag       = "forbid-latest-tag"
+	PolicyRuleRequireResourceLimits = "require-resource-limits"
+	PolicyRuleForbidPrivileged      = "forbid-privileged"
+	PolicyRuleForbidHostPath        = "forbid-host-path"
+	PolicyRuleRequireReadinessProbe = "require-readiness-probe"
+	PolicyRuleAllowedRegistries     = "allowed-registries"
+)
+
+// 策略执行模式
+const (
+	PolicyModeWarn    = "warn"    // 仅给出警告，不阻止操作
+	PolicyModeEnforce = "enforce" // 违反时拒绝操作
+)
+
+// DeploymentPolicy 部署策略，可作用于全部集群、指定集群或指定命名空间
+type DeploymentPolicy struct {
+	ID           string           `json:"id" db:"id"`
+	Name         string           `json:"name" db:"name"`
+	Description  string           `json:"description" db:"description"`
+	KubeConfigID string           `json:"kubeConfigId" db:"kube_config_id"` // 为空表示所有集群
+	Namespace    string           `json:"namespace" db:"namespace"`         // 为空表示所有命名空间
+	Rule         string           `json:"rule" db:"rule"`
+	Params       PolicyRuleParams `json:"params" db:"-"`
+	Mode         string           `json:"mode" db:"mode"` // warn 或 enforce
+	Enabled      bool             `json:"enabled" db:"enabled"`
+	CreatedAt    time.Time        `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time        `json:"updatedAt" db:"updated_at"`
+
+	ParamsJSON sql.NullString `json:"-" db:"params_json"`
+}
+
+// PolicyRuleParams 策略规则参数
+type PolicyRuleParams struct {
+	Registries []string `json:"registries,omitempty"` // allowed-registries: 允许的镜像仓库地址
+}
+
+// PolicyViolation 策略违规信息
+type PolicyViolation struct {
+	PolicyID   string `json:"policyId"`
+	PolicyName string `json:"policyName"`
+	Rule       string `json:"rule"`
+	Mode       string `json:"mode"`
+	Field      string `json:"field"`
+	Message    string `json:"message"`
+}
+
+// PolicyEvaluation 策略评估结果
+type PolicyEvaluation struct {
+	Allowed    bool              `json:"allowed"`
+	Violations []PolicyViolation `json:"violations"` // enforce模式下的违规，存在时拒绝操作
+	Warnings   []PolicyViolation `json:"warnings"`   // warn模式下的违规
+}
+
+// IsValidPolicyRule 检查策略规则类型是否受支持
+func IsValidPolicyRule(rule string) bool {
+	switch rule {
+	case PolicyRuleForbidLatestTag, PolicyRuleRequireResourceLimits, PolicyRuleForbidPrivileged,
+		PolicyRuleForbidHostPath, PolicyRuleRequireReadinessProbe, PolicyRuleAllowedRegistries:
+		return true
+	}
+	return false
+}
+
+// ValidateDeploymentPolicy 校验部署策略配置
+func ValidateDeploymentPolicy(policy *DeploymentPolicy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("策略名称不能为空")
+	}
+	if !IsValidPolicyRule(policy.Rule) {
+		return fmt.Errorf("不支持的策略规则: %s", policy.Rule)
+	}
+	if policy.Mode == "" {
+		policy.Mode = PolicyModeEnforce
+	}
+	if policy.Mode != PolicyModeWarn && policy.Mode != PolicyModeEnforce {
+		return fmt.Errorf("不支持的策略模式: %s", policy.Mode)
+	}
+	if policy.Rule == PolicyRuleAllowedRegistries && len(policy.Params.Registries) == 0 {
+		return fmt.Errorf("allowed-registries策略必须指定允许的镜像仓库")
+	}
+	return nil
+}
+
+// SaveDeploymentPolicyToDB 保存部署策略到数据库
+func SaveDeploymentPolicyToDB(policy *DeploymentPolicy) error {
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+		policy.CreatedAt = time.Now()
+	}
+	policy.UpdatedAt = time.Now()
+
+	paramsJSON, err := serializeJSONField(policy.Params)
+	if err != nil {
+		return fmt.Errorf("序列化策略参数失败: %v", err)
+	}
+
+	var exists bool
+	err = DB.Get(&exists, "SELECT EXISTS(SELECT 1 FROM deployment_policies WHERE id = $1)", policy.ID)
+	if err != nil {
+		return fmt.Errorf("检查策略是否存在时出错: %v", err)
+	}
+
+	if exists {
+		query := `
+            UPDATE deployment_policies
+            SET name = $1, description = $2, kube_config_id = $3, namespace = $4, rule = $5,
+                params_json = $6, mode = $7, enabled = $8, updated_at = $9
+            WHERE id = $10
+        `
+		_, err = DB.Exec(query,
+			policy.Name, policy.Description, policy.KubeConfigID, policy.Namespace, policy.Rule,
+			paramsJSON, policy.Mode, policy.Enabled, policy.UpdatedAt, policy.ID)
+		if err != nil {
+			return fmt.Errorf("更新部署策略失败: %v", err)
+		}
+	} else {
+		query := `
+            INSERT INTO deployment_policies (id, name, description, kube_config_id, namespace, rule,
+                params_json, mode, enabled, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        `
+		_, err = DB.Exec(query,
+			policy.ID, policy.Name, policy.Description, policy.KubeConfigID, policy.Namespace, policy.Rule,
+			paramsJSON, policy.Mode, policy.Enabled, policy.CreatedAt, policy.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("插入部署策略失败: %v", err)
+		}
+	}
+
+	log.Printf("成功保存部署策略: %s (%s)", policy.Name, policy.ID)
+	return nil
+}
+
+// 解析数据库中的策略参数
+func (p *DeploymentPolicy) decodeParams() {
+	if p.ParamsJSON.Valid && p.ParamsJSON.String != "" {
+		if err := json.Unmarshal([]byte(p.ParamsJSON.String), &p.Params); err != nil {
+			log.Printf("解析策略参数失败 (ID: %s): %v", p.ID, err)
+		}
+	}
+}
+
+// GetDeploymentPoliciesFromDB 从数据库获取所有部署策略
+func GetDeploymentPoliciesFromDB() ([]DeploymentPolicy, error) {
+	var policies []DeploymentPolicy
+	query := `
+        SELECT id, name, description, kube_config_id, namespace, rule, params_json, mode, enabled,
+               created_at, updated_at
+        FROM deployment_policies
+        ORDER BY created_at DESC
+    `
+	if err := DB.Select(&policies, query); err != nil {
+		return nil, fmt.Errorf("查询部署策略失败: %v", err)
+	}
+
+	for i := range policies {
+		policies[i].decodeParams()
+	}
+	return policies, nil
+}
+
+// GetDeploymentPolicyByIDFromDB 从数据库获取指定ID的部署策略
+func GetDeploymentPolicyByIDFromDB(id string) (*DeploymentPolicy, error) {
+	var policy DeploymentPolicy
+	query := `
+        SELECT id, name, description, kube_config_id, namespace, rule, params_json, mode, enabled,
+               created_at, updated_at
+        FROM deployment_policies
+        WHERE id = $1
+    `
+	if err := DB.Get(&policy, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("未找到ID为%s的部署策略", id)
+		}
+		return nil, fmt.Errorf("查询部署策略失败: %v", err)
+	}
+
+	policy.decodeParams()
+	return &policy, nil
+}
+
+// DeleteDeploymentPolicyFromDB 从数据库删除部署策略
+func DeleteDeploymentPolicyFromDB(id string) error {
+	result, err := DB.Exec("DELETE FROM deployment_policies WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("删除部署策略失败: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数时出错: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("未找到ID为%s的部署策略", id)
+	}
+
+	log.Printf("成功删除部署策略: %s", id)
+	return nil
+}
+
+// 获取作用于指定集群和命名空间的已启用策略
+func getApplicablePolicies(kubeConfigID string, namespace string) ([]DeploymentPolicy, error) {
+	var policies []DeploymentPolicy
+	query := `
+        SELECT id, name, description, kube_config_id, namespace, rule, params_json, mode, enabled,
+               created_at, updated_at
+        FROM deployment_policies
+        WHERE enabled = true
+          AND (kube_config_id = '' OR kube_config_id = $1)
+          AND (namespace = '' OR namespace = $2)
+        ORDER BY created_at
+    `
+	if err := DB.Select(&policies, query, kubeConfigID, namespace); err != nil {
+		return nil, fmt.Errorf("查询部署策略失败: %v", err)
+	}
+
+	for i := range policies {
+		policies[i].decodeParams()
+	}
+	return policies, nil
+}
+
+// EvaluateDeploymentPolicies 使用作用于应用所在集群和命名空间的策略评估应用
+func EvaluateDeploymentPolicies(app *Application) (*PolicyEvaluation, error) {
+	namespace := app.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	policies, err := getApplicablePolicies(app.KubeConfigID, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return evaluatePolicies(app, policies), nil
+}
+
+// 使用给定的策略评估应用
+func evaluatePolicies(app *Application, policies []DeploymentPolicy) *PolicyEvaluation {
+	result := &PolicyEvaluation{
+		Allowed:    true,
+		Violations: []PolicyViolation{},
+		Warnings:   []PolicyViolation{},
+	}
+
+	for _, policy := range policies {
+		for _, v := range checkPolicyRule(app, policy) {
+			v.PolicyID = policy.ID
+			v.PolicyName = policy.Name
+			v.Rule = policy.Rule
+			v.Mode = policy.Mode
+			if policy.Mode == PolicyModeWarn {
+				result.Warnings = append(result.Warnings, v)
+			} else {
+				result.Violations = append(result.Violations, v)
+				result.Allowed = false
+			}
+		}
+	}
+
+	return result
+}
+
+// 检查应用是否符合单条策略规则，返回违规列表
+func checkPolicyRule(app *Application, policy DeploymentPolicy) []PolicyViolation {
+	var violations []PolicyViolation
+
+	switch policy.Rule {
+	case PolicyRuleForbidLatestTag:
+		if imageUsesLatestTag(app.ImageURL) {
+			violations = append(violations, PolicyViolation{
+				Field:   "imageURL",
+				Message: fmt.Sprintf("镜像 %s 使用了latest标签或未指定标签", app.ImageURL),
+			})
+		}
+
+	case PolicyRuleRequireResourceLimits:
+		if app.Resources == nil || app.Resources.Limits.CPU == "" {
+			violations = append(violations, PolicyViolation{
+				Field:   "resources.limits.cpu",
+				Message: "必须设置CPU限制",
+			})
+		}
+		if app.Resources == nil || app.Resources.Limits.Memory == "" {
+			violations = append(violations, PolicyViolation{
+				Field:   "resources.limits.memory",
+				Message: "必须设置内存限制",
+			})
+		}
+
+	case PolicyRuleForbidPrivileged:
+		if app.SecurityContext != nil && app.SecurityContext.Privileged != nil && *app.SecurityContext.Privileged {
+			violations = append(violations, PolicyViolation{
+				Field:   "securityContext.privileged",
+				Message: "禁止以特权模式运行容器",
+			})
+		}
+		if app.SecurityContext != nil && app.SecurityContext.Capabilities != nil {
+			for _, c := range app.SecurityContext.Capabilities.Add {
+				if strings.EqualFold(c, "SYS_ADMIN") || strings.EqualFold(c, "ALL") {
+					violations = append(violations, PolicyViolation{
+						Field:   "securityContext.capabilities.add",
+						Message: fmt.Sprintf("禁止添加特权能力 %s", strings.ToUpper(c)),
+					})
+				}
+			}
+		}
+
+	case PolicyRuleForbidHostPath:
+		for i, v := range app.Volumes {
+			if v.Type == "hostPath" || v.HostPath != "" {
+				violations = append(violations, PolicyViolation{
+					Field:   fmt.Sprintf("volumes[%d]", i),
+					Message: fmt.Sprintf("禁止使用hostPath卷 %s", v.Name),
+				})
+			}
+		}
+		if app.SyncHostTimezone {
+			violations = append(violations, PolicyViolation{
+				Field:   "syncHostTimezone",
+				Message: "同步主机时区会挂载hostPath卷/etc/localtime",
+			})
+		}
+
+	case PolicyRuleRequireReadinessProbe:
+		if app.ReadinessProbe == nil || !isValidProbeConfig(app.ReadinessProbe) {
+			violations = append(violations, PolicyViolation{
+				Field:   "readinessProbe",
+				Message: "必须配置就绪探针",
+			})
+		}
+
+	case PolicyRuleAllowedRegistries:
+		host := imageRegistryHost(app.ImageURL)
+		allowed := false
+		for _, r := range policy.Params.Registries {
+			if normalizeRegistryHost(r) == host {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, PolicyViolation{
+				Field:   "imageURL",
+				Message: fmt.Sprintf("镜像仓库 %s 不在允许列表中: %s", host, strings.Join(policy.Params.Registries, ", ")),
+			})
+		}
+	}
+
+	return violations
+}
+
+// 判断镜像是否使用latest标签（未指定标签和摘要时视为latest）
+func imageUsesLatestTag(image string) bool {
+	if image == "" {
+		return true
+	}
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	// 只看最后一段路径，避免把仓库端口误认为标签
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return true
+	}
+	return name[i+1:] == "latest"
+}
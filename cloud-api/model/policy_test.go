@@ -0,0 +1,127 @@
+package model
+
+import "testing"
+
+func TestEvaluatePolicies(t *testing.T) {
+	privileged := true
+	policy := func(rule, mode string) DeploymentPolicy {
+		return DeploymentPolicy{ID: rule, Name: rule, Rule: rule, Mode: mode, Enabled: true}
+	}
+	limits := &ResourceRequirements{Limits: ResourceList{CPU: "500m", Memory: "256Mi"}}
+
+	tests := []struct {
+		name           string
+		app            Application
+		policies       []DeploymentPolicy
+		wantAllowed    bool
+		wantViolations int
+		wantWarnings   int
+	}{
+		{
+			name:        "没有策略",
+			app:         Application{ImageURL: "nginx"},
+			wantAllowed: true,
+		},
+		{
+			name:           "enforce模式拒绝latest标签",
+			app:            Application{ImageURL: "nginx:latest"},
+			policies:       []DeploymentPolicy{policy(PolicyRuleForbidLatestTag, PolicyModeEnforce)},
+			wantAllowed:    false,
+			wantViolations: 1,
+		},
+		{
+			name:         "warn模式只给出警告",
+			app:          Application{ImageURL: "nginx"},
+			policies:     []DeploymentPolicy{policy(PolicyRuleForbidLatestTag, PolicyModeWarn)},
+			wantAllowed:  true,
+			wantWarnings: 1,
+		},
+		{
+			name:        "指定标签和仓库端口",
+			app:         Application{ImageURL: "registry.local:5000/team/app:1.2.0"},
+			policies:    []DeploymentPolicy{policy(PolicyRuleForbidLatestTag, PolicyModeEnforce)},
+			wantAllowed: true,
+		},
+		{
+			name:           "缺少CPU和内存限制",
+			app:            Application{ImageURL: "nginx:1.25"},
+			policies:       []DeploymentPolicy{policy(PolicyRuleRequireResourceLimits, PolicyModeEnforce)},
+			wantAllowed:    false,
+			wantViolations: 2,
+		},
+		{
+			name:        "设置了资源限制",
+			app:         Application{ImageURL: "nginx:1.25", Resources: limits},
+			policies:    []DeploymentPolicy{policy(PolicyRuleRequireResourceLimits, PolicyModeEnforce)},
+			wantAllowed: true,
+		},
+		{
+			name:           "特权容器",
+			app:            Application{SecurityContext: &SecurityContext{Privileged: &privileged}},
+			policies:       []DeploymentPolicy{policy(PolicyRuleForbidPrivileged, PolicyModeEnforce)},
+			wantAllowed:    false,
+			wantViolations: 1,
+		},
+		{
+			name:           "hostPath卷和同步主机时区",
+			app:            Application{Volumes: []VolumeConfig{{Name: "data", Type: "hostPath", HostPath: "/data"}}, SyncHostTimezone: true},
+			policies:       []DeploymentPolicy{policy(PolicyRuleForbidHostPath, PolicyModeEnforce)},
+			wantAllowed:    false,
+			wantViolations: 2,
+		},
+		{
+			name:         "缺少就绪探针",
+			app:          Application{},
+			policies:     []DeploymentPolicy{policy(PolicyRuleRequireReadinessProbe, PolicyModeWarn)},
+			wantAllowed:  true,
+			wantWarnings: 1,
+		},
+		{
+			name: "镜像仓库在允许列表中",
+			app:  Application{ImageURL: "harbor.example.com/team/app:1.0"},
+			policies: []DeploymentPolicy{{
+				Rule:   PolicyRuleAllowedRegistries,
+				Mode:   PolicyModeEnforce,
+				Params: PolicyRuleParams{Registries: []string{"https://harbor.example.com"}},
+			}},
+			wantAllowed: true,
+		},
+		{
+			name: "Docker Hub镜像不在允许列表中",
+			app:  Application{ImageURL: "nginx:1.25"},
+			policies: []DeploymentPolicy{{
+				Rule:   PolicyRuleAllowedRegistries,
+				Mode:   PolicyModeEnforce,
+				Params: PolicyRuleParams{Registries: []string{"harbor.example.com"}},
+			}},
+			wantAllowed:    false,
+			wantViolations: 1,
+		},
+		{
+			name: "warn和enforce同时存在",
+			app:  Application{ImageURL: "nginx"},
+			policies: []DeploymentPolicy{
+				policy(PolicyRuleForbidLatestTag, PolicyModeWarn),
+				policy(PolicyRuleRequireResourceLimits, PolicyModeEnforce),
+			},
+			wantAllowed:    false,
+			wantViolations: 2,
+			wantWarnings:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := evaluatePolicies(&tt.app, tt.policies)
+			if got.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", got.Allowed, tt.wantAllowed)
+			}
+			if len(got.Violations) != tt.wantViolations {
+				t.Errorf("len(Violations) = %d, want %d: %+v", len(got.Violations), tt.wantViolations, got.Violations)
+			}
+			if len(got.Warnings) != tt.wantWarnings {
+				t.Errorf("len(Warnings) = %d, want %d: %+v", len(got.Warnings), tt.wantWarnings, got.Warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// 每月按730小时计费
+const hoursPerMonth = 730.0
+
+// PriceBook 集群的资源单价
+type PriceBook struct {
+	KubeConfigID      string    `json:"kubeConfigId" db:"kube_config_id"` // 为空表示全局默认价格
+	Currency          string    `json:"currency" db:"currency"`
+	CPUCoreHour       float64   `json:"cpuCoreHour" db:"cpu_core_hour"`
+	MemoryGiBHour     float64   `json:"memoryGiBHour" db:"memory_gib_hour"`
+	StorageGiBMonth   float64   `json:"storageGiBMonth" db:"storage_gib_month"`
+	LoadBalancerMonth float64   `json:"loadBalancerMonth" db:"load_balancer_month"`
+	CreatedAt         time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// DefaultPriceBook 未配置价格表时使用的内置价格
+func DefaultPriceBook() *PriceBook {
+	return &PriceBook{
+		Currency:          "CNY",
+		CPUCoreHour:       0.25,
+		MemoryGiBHour:     0.035,
+		StorageGiBMonth:   0.5,
+		LoadBalancerMonth: 120,
+	}
+}
+
+// ValidatePriceBook 校验价格表
+func ValidatePriceBook(book *PriceBook) error {
+	if book.Currency == "" {
+		book.Currency = "CNY"
+	}
+	if book.CPUCoreHour < 0 || book.MemoryGiBHour < 0 || book.StorageGiBMonth < 0 || book.LoadBalancerMonth < 0 {
+		return fmt.Errorf("单价不能为负数")
+	}
+	return nil
+}
+
+// SavePriceBookToDB 保存集群价格表
+func SavePriceBookToDB(book *PriceBook) error {
+	now := time.Now()
+	book.UpdatedAt = now
+
+	// 检查是否已存在
+	var exists bool
+	err := DB.Get(&exists, "SELECT EXISTS(SELECT 1 FROM price_books WHERE kube_config_id = $1)", book.KubeConfigID)
+	if err != nil {
+		return fmt.Errorf("检查价格表是否存在时出错: %v", err)
+	}
+
+	if exists {
+		query := `
+            UPDATE price_books
+            SET currency = $1, cpu_core_hour = $2, memory_gib_hour = $3, storage_gib_month = $4,
+                load_balancer_month = $5, updated_at = $6
+            WHERE kube_config_id = $7
+        `
+		_, err = DB.Exec(query,
+			book.Currency, book.CPUCoreHour, book.MemoryGiBHour, book.StorageGiBMonth,
+			book.LoadBalancerMonth, book.UpdatedAt, book.KubeConfigID)
+		if err != nil {
+			return fmt.Errorf("更新价格表失败: %v", err)
+		}
+	} else {
+		book.CreatedAt = now
+		query := `
+            INSERT INTO price_books (kube_config_id, currency, cpu_core_hour, memory_gib_hour,
+                storage_gib_month, load_balancer_month, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        `
+		_, err = DB.Exec(query,
+			book.KubeConfigID, book.Currency, book.CPUCoreHour, book.MemoryGiBHour,
+			book.StorageGiBMonth, book.LoadBalancerMonth, book.CreatedAt, book.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("插入价格表失败: %v", err)
+		}
+	}
+
+	log.Printf("成功保存价格表 (集群: %q)", book.KubeConfigID)
+	return nil
+}
+
+// GetPriceBooksFromDB 获取所有已配置的价格表
+func GetPriceBooksFromDB() ([]PriceBook, error) {
+	var books []PriceBook
+	query := `
+        SELECT kube_config_id, currency, cpu_core_hour, memory_gib_hour, storage_gib_month,
+               load_balancer_month, created_at, updated_at
+        FROM price_books
+        ORDER BY kube_config_id
+    `
+	if err := DB.Select(&books, query); err != nil {
+		return nil, fmt.Errorf("查询价格表失败: %v", err)
+	}
+	return books, nil
+}
+
+// 获取指定集群已配置的价格表，未配置时返回nil
+func getPriceBookFromDB(kubeConfigID string) (*PriceBook, error) {
+	var book PriceBook
+	query := `
+        SELECT kube_config_id, currency, cpu_core_hour, memory_gib_hour, storage_gib_month,
+               load_balancer_month, created_at, updated_at
+        FROM price_books
+        WHERE kube_config_id = $1
+    `
+	if err := DB.Get(&book, query, kubeConfigID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询价格表失败: %v", err)
+	}
+	return &book, nil
+}
+
+// GetEffectivePriceBook 获取集群生效的价格表：集群价格表 > 全局默认价格表 > 内置价格
+func GetEffectivePriceBook(kubeConfigID string) (*PriceBook, error) {
+	for _, id := range []string{kubeConfigID, ""} {
+		book, err := getPriceBookFromDB(id)
+		if err != nil {
+			return nil, err
+		}
+		if book != nil {
+			return book, nil
+		}
+		if kubeConfigID == "" {
+			break
+		}
+	}
+
+	book := DefaultPriceBook()
+	book.KubeConfigID = kubeConfigID
+	return book, nil
+}
+
+// DeletePriceBookFromDB 删除集群价格表，之后该集群使用全局默认价格
+func DeletePriceBookFromDB(kubeConfigID string) error {
+	result, err := DB.Exec("DELETE FROM price_books WHERE kube_config_id = $1", kubeConfigID)
+	if err != nil {
+		return fmt.Errorf("删除价格表失败: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数时出错: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("未找到集群%q的价格表", kubeConfigID)
+	}
+	return nil
+}
+
+// MonthlyCost 按价格表计算一个月的费用
+// cpu单位为核，memoryGiB和storageGiB单位为GiB，loadBalancers为LoadBalancer数量
+func (book *PriceBook) MonthlyCost(cpu, memoryGiB, storageGiB float64, loadBalancers int) PriceEstimation {
+	estimation := PriceEstimation{
+		CPU:          cpu * book.CPUCoreHour * hoursPerMonth,
+		Memory:       memoryGiB * book.MemoryGiBHour * hoursPerMonth,
+		Storage:      storageGiB * book.StorageGiBMonth,
+		LoadBalancer: float64(loadBalancers) * book.LoadBalancerMonth,
+		Currency:     book.Currency,
+	}
+	estimation.Total = estimation.CPU + estimation.Memory + estimation.Storage + estimation.LoadBalancer
+	return estimation
+}
@@ -0,0 +1,275 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stack 应用栈，由多个存在依赖关系的应用组成，按依赖顺序部署
+type Stack struct {
+	ID          string        `json:"id" db:"id"`
+	Name        string        `json:"name" db:"name"`
+	Description string        `json:"description" db:"description"`
+	Members     []StackMember `json:"members" db:"-"`
+	Status      string        `json:"status" db:"status"`   // created, deploying, deployed, deleting, error
+	Message     string        `json:"message" db:"message"` // 最近一次部署或删除的进度/错误信息
+	CreatedAt   time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time     `json:"updatedAt" db:"updated_at"`
+	DeletedAt   *time.Time    `json:"deletedAt,omitempty" db:"deleted_at"`
+
+	MembersJSON sql.NullString `json:"-" db:"members_json"`
+}
+
+// StackMember 应用栈成员
+type StackMember struct {
+	ApplicationID string   `json:"applicationId"`
+	DependsOn     []string `json:"dependsOn,omitempty"` // 依赖的同一应用栈内其他应用的ID
+}
+
+// ValidateStack 校验应用栈：成员应用存在、依赖在栈内且不存在循环依赖
+func ValidateStack(stack *Stack) error {
+	if stack.Name == "" {
+		return fmt.Errorf("应用栈名称不能为空")
+	}
+	if len(stack.Members) == 0 {
+		return fmt.Errorf("应用栈至少需要包含一个应用")
+	}
+
+	members := make(map[string]bool)
+	for _, member := range stack.Members {
+		if member.ApplicationID == "" {
+			return fmt.Errorf("应用栈成员的applicationId不能为空")
+		}
+		if members[member.ApplicationID] {
+			return fmt.Errorf("应用 %s 在应用栈中重复出现", member.ApplicationID)
+		}
+		members[member.ApplicationID] = true
+
+		if _, err := GetApplicationByIDFromDB(member.ApplicationID); err != nil {
+			return fmt.Errorf("应用 %s 不存在", member.ApplicationID)
+		}
+	}
+
+	for _, member := range stack.Members {
+		for _, dep := range member.DependsOn {
+			if dep == member.ApplicationID {
+				return fmt.Errorf("应用 %s 不能依赖自身", member.ApplicationID)
+			}
+			if !members[dep] {
+				return fmt.Errorf("应用 %s 依赖的应用 %s 不在应用栈中", member.ApplicationID, dep)
+			}
+		}
+	}
+
+	if _, err := StackDeployOrder(stack); err != nil {
+		return err
+	}
+	return nil
+}
+
+// StackDeployOrder 计算应用栈的部署顺序（拓扑排序），依赖在前；无依赖关系的应用保持声明顺序
+func StackDeployOrder(stack *Stack) ([]string, error) {
+	inDegree := make(map[string]int)
+	dependents := make(map[string][]string)
+	for _, member := range stack.Members {
+		inDegree[member.ApplicationID] += 0
+		for _, dep := range member.DependsOn {
+			inDegree[member.ApplicationID]++
+			dependents[dep] = append(dependents[dep], member.ApplicationID)
+		}
+	}
+
+	var order []string
+	done := make(map[string]bool)
+	for len(order) < len(stack.Members) {
+		progressed := false
+		for _, member := range stack.Members {
+			id := member.ApplicationID
+			if done[id] || inDegree[id] > 0 {
+				continue
+			}
+			done[id] = true
+			order = append(order, id)
+			for _, next := range dependents[id] {
+				inDegree[next]--
+			}
+			progressed = true
+			break
+		}
+
+		if !progressed {
+			var cycle []string
+			for _, member := range stack.Members {
+				if !done[member.ApplicationID] {
+					cycle = append(cycle, member.ApplicationID)
+				}
+			}
+			return nil, fmt.Errorf("应用栈存在循环依赖: %s", strings.Join(cycle, ", "))
+		}
+	}
+
+	return order, nil
+}
+
+// SaveStackToDB 保存应用栈到数据库
+func SaveStackToDB(stack *Stack) error {
+	if stack.ID == "" {
+		stack.ID = uuid.New().String()
+		stack.CreatedAt = time.Now()
+	}
+	stack.UpdatedAt = time.Now()
+	if stack.Status == "" {
+		stack.Status = "created"
+	}
+
+	membersJSON, err := serializeJSONField(stack.Members)
+	if err != nil {
+		return fmt.Errorf("序列化应用栈成员失败: %v", err)
+	}
+
+	// 检查是否已存在
+	var exists bool
+	err = DB.Get(&exists, "SELECT EXISTS(SELECT 1 FROM stacks WHERE id = $1)", stack.ID)
+	if err != nil {
+		return fmt.Errorf("检查应用栈是否存在时出错: %v", err)
+	}
+
+	if exists {
+		query := `
+            UPDATE stacks
+            SET name = $1, description = $2, members_json = $3, status = $4, message = $5, updated_at = $6
+            WHERE id = $7
+        `
+		_, err = DB.Exec(query,
+			stack.Name, stack.Description, membersJSON, stack.Status, stack.Message, stack.UpdatedAt, stack.ID)
+		if err != nil {
+			return fmt.Errorf("更新应用栈失败: %v", err)
+		}
+	} else {
+		query := `
+            INSERT INTO stacks (id, name, description, members_json, status, message, created_at, updated_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        `
+		_, err = DB.Exec(query,
+			stack.ID, stack.Name, stack.Description, membersJSON, stack.Status, stack.Message,
+			stack.CreatedAt, stack.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("插入应用栈失败: %v", err)
+		}
+	}
+
+	log.Printf("成功保存应用栈: %s (%s)", stack.Name, stack.ID)
+	return nil
+}
+
+// 解析数据库中的成员JSON
+func (s *Stack) decodeMembers() {
+	s.Members = []StackMember{}
+	if s.MembersJSON.Valid && s.MembersJSON.String != "" {
+		if err := json.Unmarshal([]byte(s.MembersJSON.String), &s.Members); err != nil {
+			log.Printf("解析应用栈成员失败 (ID: %s): %v", s.ID, err)
+		}
+	}
+}
+
+// GetStacksFromDB 从数据库获取所有应用栈
+func GetStacksFromDB() ([]Stack, error) {
+	var stacks []Stack
+	query := `
+        SELECT id, name, description, members_json, status, message, created_at, updated_at, deleted_at
+        FROM stacks
+        WHERE deleted_at IS NULL
+        ORDER BY created_at DESC
+    `
+	if err := DB.Select(&stacks, query); err != nil {
+		return nil, fmt.Errorf("查询应用栈失败: %v", err)
+	}
+
+	for i := range stacks {
+		stacks[i].decodeMembers()
+	}
+	return stacks, nil
+}
+
+// GetStackByIDFromDB 从数据库获取指定ID的应用栈
+func GetStackByIDFromDB(id string) (*Stack, error) {
+	var stack Stack
+	query := `
+        SELECT id, name, description, members_json, status, message, created_at, updated_at, deleted_at
+        FROM stacks
+        WHERE id = $1 AND deleted_at IS NULL
+    `
+	if err := DB.Get(&stack, query, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("未找到ID为%s的应用栈", id)
+		}
+		return nil, fmt.Errorf("查询应用栈失败: %v", err)
+	}
+
+	stack.decodeMembers()
+	return &stack, nil
+}
+
+// UpdateStackStatusToDB 更新应用栈状态和进度信息
+func UpdateStackStatusToDB(id string, status string, message string) error {
+	query := `
+        UPDATE stacks
+        SET status = $1, message = $2, updated_at = $3
+        WHERE id = $4
+    `
+	if _, err := DB.Exec(query, status, message, time.Now(), id); err != nil {
+		return fmt.Errorf("更新应用栈状态失败: %v", err)
+	}
+	return nil
+}
+
+// BeginStackOperationToDB 将应用栈原子地切换到deploying或deleting状态，
+// 应用栈已在部署或删除中时不修改并返回false
+func BeginStackOperationToDB(id string, status string, message string) (bool, error) {
+	query := `
+        UPDATE stacks
+        SET status = $1, message = $2, updated_at = $3
+        WHERE id = $4 AND deleted_at IS NULL AND status NOT IN ('deploying', 'deleting')
+    `
+	result, err := DB.Exec(query, status, message, time.Now(), id)
+	if err != nil {
+		return false, fmt.Errorf("更新应用栈状态失败: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("获取影响行数时出错: %v", err)
+	}
+	return rows > 0, nil
+}
+
+// SoftDeleteStackFromDB 软删除应用栈
+func SoftDeleteStackFromDB(id string) error {
+	now := time.Now()
+	query := `
+        UPDATE stacks
+        SET status = 'deleted', deleted_at = $1, updated_at = $1
+        WHERE id = $2 AND deleted_at IS NULL
+    `
+	result, err := DB.Exec(query, now, id)
+	if err != nil {
+		return fmt.Errorf("软删除应用栈失败: %v", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("获取影响行数时出错: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("未找到ID为%s的应用栈", id)
+	}
+
+	log.Printf("成功软删除应用栈: %s", id)
+	return nil
+}
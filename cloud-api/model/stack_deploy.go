@@ -0,0 +1,206 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// 等待单个应用就绪的默认超时时间
+	defaultStackReadyTimeout = 10 * time.Minute
+	// 轮询应用状态的间隔
+	stackPollInterval = 5 * time.Second
+)
+
+// 获取应用在集群中的命名空间和名称
+func applicationTarget(app *Application) (string, string) {
+	namespace := app.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	name := app.Name
+	if name == "" {
+		name = app.ID
+	}
+	return namespace, name
+}
+
+// WaitForApplicationReady 等待应用的Deployment就绪，判断条件与GetDeploymentStatus一致
+func (km *K8sManager) WaitForApplicationReady(app *Application, timeout time.Duration) error {
+	namespace, name := applicationTarget(app)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		client, err := km.GetClient(app.KubeConfigID)
+		if err != nil {
+			return fmt.Errorf("获取客户端失败: %v", err)
+		}
+
+		deployment, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("获取部署状态失败: %v", err)
+		}
+
+		// 只有控制器已处理最新的spec时状态才可信
+		if err == nil && deployment.Status.ObservedGeneration >= deployment.Generation &&
+			deploymentPhase(deployment) == "running" {
+			log.Printf("应用 %s/%s 已就绪", namespace, name)
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待应用 %s/%s 就绪超时(%v)", namespace, name, timeout)
+		}
+		time.Sleep(stackPollInterval)
+	}
+}
+
+// 等待应用的Deployment被删除
+func (km *K8sManager) waitForApplicationRemoved(app *Application, timeout time.Duration) error {
+	namespace, name := applicationTarget(app)
+	deadline := time.Now().Add(timeout)
+
+	for {
+		client, err := km.GetClient(app.KubeConfigID)
+		if err != nil {
+			return fmt.Errorf("获取客户端失败: %v", err)
+		}
+
+		_, err = client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("获取部署状态失败: %v", err)
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("等待应用 %s/%s 删除超时(%v)", namespace, name, timeout)
+		}
+		time.Sleep(stackPollInterval)
+	}
+}
+
+// DeployStack 按依赖顺序部署应用栈，每个应用就绪后才部署依赖它的下一个应用
+func (km *K8sManager) DeployStack(stack *Stack) error {
+	order, err := StackDeployOrder(stack)
+	if err != nil {
+		UpdateStackStatusToDB(stack.ID, "error", err.Error())
+		return err
+	}
+
+	log.Printf("开始部署应用栈 %s，部署顺序: %v", stack.Name, order)
+	for i, appID := range order {
+		app, err := GetApplicationByIDFromDB(appID)
+		if err != nil {
+			err = fmt.Errorf("获取应用 %s 失败: %v", appID, err)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+
+		// 部署策略检查
+		evaluation, err := EvaluateDeploymentPolicies(app)
+		if err != nil {
+			err = fmt.Errorf("评估应用 %s 的部署策略失败: %v", app.Name, err)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+		if !evaluation.Allowed {
+			err = fmt.Errorf("应用 %s 违反部署策略: %s", app.Name, evaluation.Violations[0].Message)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+
+		// 资源配额检查
+		violations, err := km.CheckApplicationQuota(app)
+		if err != nil {
+			err = fmt.Errorf("检查应用 %s 的资源配额失败: %v", app.Name, err)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+		if len(violations) > 0 {
+			err = fmt.Errorf("应用 %s 超出资源配额: %s", app.Name, strings.Join(violations, "; "))
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+
+		UpdateStackStatusToDB(stack.ID, "deploying", fmt.Sprintf("(%d/%d) 正在部署应用 %s", i+1, len(order), app.Name))
+		UpdateApplicationStatusToDB(app.ID, "deploying")
+
+		if err := km.DeployApplication(app); err != nil {
+			UpdateApplicationStatusToDB(app.ID, "error")
+			err = fmt.Errorf("部署应用 %s 失败: %v", app.Name, err)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+
+		UpdateStackStatusToDB(stack.ID, "deploying", fmt.Sprintf("(%d/%d) 等待应用 %s 就绪", i+1, len(order), app.Name))
+		if err := km.WaitForApplicationReady(app, defaultStackReadyTimeout); err != nil {
+			UpdateApplicationStatusToDB(app.ID, "error")
+			err = fmt.Errorf("应用 %s 未就绪: %v", app.Name, err)
+			UpdateStackStatusToDB(stack.ID, "error", err.Error())
+			return err
+		}
+		UpdateApplicationStatusToDB(app.ID, "running")
+	}
+
+	UpdateStackStatusToDB(stack.ID, "deployed", fmt.Sprintf("已部署%d个应用", len(order)))
+	log.Printf("应用栈 %s 部署完成", stack.Name)
+	return nil
+}
+
+// DeleteStack 按部署顺序的逆序删除应用栈中的应用，deleteApplications为true时同时删除应用记录
+func (km *K8sManager) DeleteStack(stack *Stack, deleteApplications bool) error {
+	order, err := StackDeployOrder(stack)
+	if err != nil {
+		// 依赖关系已损坏时按声明顺序的逆序删除
+		order = nil
+		for _, member := range stack.Members {
+			order = append(order, member.ApplicationID)
+		}
+	}
+
+	var lastErr error
+	for i := len(order) - 1; i >= 0; i-- {
+		app, err := GetApplicationByIDFromDB(order[i])
+		if err != nil {
+			log.Printf("应用栈 %s 中的应用 %s 不存在，跳过: %v", stack.Name, order[i], err)
+			continue
+		}
+
+		namespace, name := applicationTarget(app)
+		UpdateStackStatusToDB(stack.ID, "deleting", fmt.Sprintf("(%d/%d) 正在删除应用 %s", len(order)-i, len(order), app.Name))
+
+		if err := km.DeleteApplicationResources(app.KubeConfigID, namespace, name); err != nil {
+			log.Printf("删除应用 %s 的资源失败: %v", app.Name, err)
+			lastErr = err
+		}
+		// 等待被依赖的应用之前，确保当前应用已停止
+		if err := km.waitForApplicationRemoved(app, defaultStackReadyTimeout); err != nil {
+			log.Printf("等待应用 %s 删除失败: %v", app.Name, err)
+			lastErr = err
+		}
+
+		if deleteApplications {
+			if err := SoftDeleteApplicationFromDB(app.ID); err != nil {
+				log.Printf("删除应用记录 %s 失败: %v", app.Name, err)
+				lastErr = err
+			}
+		} else {
+			UpdateApplicationStatusToDB(app.ID, "stopped")
+		}
+	}
+
+	if lastErr != nil {
+		UpdateStackStatusToDB(stack.ID, "error", fmt.Sprintf("删除应用栈时发生错误: %v", lastErr))
+		return lastErr
+	}
+
+	return SoftDeleteStackFromDB(stack.ID)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStackDeployOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		members []StackMember
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "无依赖时保持声明顺序",
+			members: []StackMember{{ApplicationID: "a"}, {ApplicationID: "b"}, {ApplicationID: "c"}},
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name: "依赖在前",
+			members: []StackMember{
+				{ApplicationID: "web", DependsOn: []string{"api"}},
+				{ApplicationID: "api", DependsOn: []string{"db"}},
+				{ApplicationID: "db"},
+			},
+			want: []string{"db", "api", "web"},
+		},
+		{
+			name: "多个依赖",
+			members: []StackMember{
+				{ApplicationID: "web", DependsOn: []string{"api", "cache"}},
+				{ApplicationID: "cache"},
+				{ApplicationID: "api"},
+			},
+			want: []string{"cache", "api", "web"},
+		},
+		{
+			name:    "空应用栈",
+			members: nil,
+			want:    nil,
+		},
+		{
+			name: "循环依赖",
+			members: []StackMember{
+				{ApplicationID: "a", DependsOn: []string{"b"}},
+				{ApplicationID: "b", DependsOn: []string{"a"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "依赖自身",
+			members: []StackMember{
+				{ApplicationID: "a", DependsOn: []string{"a"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "依赖不在应用栈中",
+			members: []StackMember{
+				{ApplicationID: "a", DependsOn: []string{"missing"}},
+				{ApplicationID: "b"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StackDeployOrder(&Stack{Members: tt.members})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StackDeployOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StackDeployOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
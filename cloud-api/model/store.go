@@ -374,32 +374,6 @@ func GetImages() []string {
 	return preDefinedImages
 }
 
-// GetResourceQuota 获取资源配额
-func GetResourceQuota() ResourceQuota {
-	// 返回模拟的资源配额
-	return ResourceQuota{
-		CPU:     1.0,
-		Memory:  1.0,  // 1GB
-		Storage: 10.0, // 10GB
-		Total:   1.0,  // 模拟的资源配额总量
-	}
-}
-
-// CalculatePrice 计算价格
-func CalculatePrice(cpu float64, memory int, storage int) PriceEstimation {
-	// 简单的价格计算公式
-	cpuPrice := cpu * 0.5
-	memoryPrice := float64(memory) / 1024 * 0.3
-	storagePrice := float64(storage) * 0.05
-
-	return PriceEstimation{
-		CPU:     cpuPrice,
-		Memory:  memoryPrice,
-		Storage: storagePrice,
-		Total:   cpuPrice + memoryPrice + storagePrice,
-	}
-}
-
 // GenerateYAML 从应用配置生成Kubernetes YAML
 func GenerateYAML(app *Application) (string, error) {
 	if app == nil {